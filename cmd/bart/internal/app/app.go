@@ -38,6 +38,7 @@ func NewBartApp() *BartApp {
 	app.Commands = []cli.Command{
 		newServerCommandFor(app),
 		newClientCommandFor(app),
+		newSummaryCommandFor(app),
 	}
 	return app
 }
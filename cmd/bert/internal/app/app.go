@@ -38,6 +38,7 @@ func NewBertApp() *BertApp {
 	app.Commands = []cli.Command{
 		newClientCommandFor(app),
 		newServerCommandFor(app),
+		newSummaryCommandFor(app),
 	}
 	return app
 }
@@ -0,0 +1,40 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"log"
+	"os"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/urfave/cli"
+)
+
+func newSummaryCommandFor(app *BertApp) cli.Command {
+	return cli.Command{
+		Name:        "summary",
+		Usage:       "Print the summary of a model.",
+		UsageText:   programName + " summary --model=<path>",
+		Description: "Print the tree of sub-models and parameters of the model, with their statistics.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "model, m",
+				Required:    true,
+				Usage:       "The path of the model to load.",
+				Destination: &app.modelPath,
+			},
+		},
+		Action: func(c *cli.Context) {
+			model, err := bert.LoadModel(app.modelPath)
+			if err != nil {
+				log.Fatalf("error during model loading (%v)\n", err)
+			}
+			if _, err := nn.Summary(model).WriteTo(os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn/soup"
+	"github.com/urfave/cli"
+)
+
+func main() {
+	var output string
+
+	app := cli.NewApp()
+	app.Name = "modelsoup"
+	app.Usage = "average the parameters of several models with the same architecture"
+	app.UsageText = "modelsoup --output=<file> <model-file> <model-file> [<model-file>...]"
+	app.HideVersion = true
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:        "output, o",
+			Required:    true,
+			Usage:       "The file of the averaged model (e.g. spago_model.bin).",
+			Destination: &output,
+		},
+	}
+	app.Action = func(c *cli.Context) error {
+		if c.NArg() < 2 {
+			return fmt.Errorf("at least two model files are required")
+		}
+		if err := soup.AverageFiles(output, c.Args()...); err != nil {
+			return err
+		}
+		fmt.Printf("Averaged %d models into %s\n", c.NArg(), output)
+		return nil
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -48,6 +48,7 @@ func NewNERApp() *NERApp {
 		newClientCommandFor(app),
 		newServerCommandFor(app),
 		newConvertCommandFor(app),
+		newSummaryCommandFor(app),
 	}
 	return app
 }
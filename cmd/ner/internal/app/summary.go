@@ -0,0 +1,57 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"log"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/sequencelabeler"
+	"github.com/urfave/cli"
+)
+
+func newSummaryCommandFor(app *NERApp) cli.Command {
+	usr, err := user.Current()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cli.Command{
+		Name:        "summary",
+		Usage:       "Print the summary of a model.",
+		UsageText:   programName + " summary --model-name=<model-name> [--models=<path>]",
+		Description: "Print the tree of sub-models and parameters of the model, with their statistics.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "models",
+				Usage:       "Specifies the path to the models.",
+				Value:       path.Join(usr.HomeDir, ".spago"),
+				Destination: &app.modelsFolder,
+			},
+			cli.StringFlag{
+				Name:        "model-name",
+				Usage:       "Specifies the name of the model to use.",
+				Destination: &app.modelName,
+				Required:    true,
+			},
+		},
+		Action: func(c *cli.Context) {
+			modelPath := filepath.Join(app.modelsFolder, app.modelName)
+			if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+				log.Fatal(err)
+			}
+			config := sequencelabeler.LoadConfig(filepath.Join(modelPath, "config.json"))
+			model := sequencelabeler.NewDefaultModel(config, modelPath, true, false)
+			model.LoadParams(modelPath)
+			if _, err := nn.Summary(model).WriteTo(os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rand
+
+import "testing"
+
+func TestLockedRand_MarshalBinary(t *testing.T) {
+	r := NewLockedRand(42)
+	r.Float64()
+	state, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []float64{r.Float64(), r.Float64(), r.Float64()}
+
+	restored := NewLockedRand(1)
+	if err := restored.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range expected {
+		if y := restored.Float64(); y != x {
+			t.Errorf("value %d: expected %f, got %f", i, x, y)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"math"
+)
+
+var (
+	_ Function = &AdaptiveMaxPooling{}
+	_ Function = &AdaptiveAvgPooling{}
+)
+
+// AdaptiveMaxPooling is an operator to perform max pooling to a target output size.
+// The size of the (possibly overlapping) windows is derived from the input size, so
+// that inputs of any dimensions are reduced to a matrix of outRows × outCols.
+type AdaptiveMaxPooling struct {
+	x       Operand
+	outRows int
+	outCols int
+	// initialized during the forward pass
+	argmaxI [][]int
+	argmaxJ [][]int
+}
+
+// NewAdaptiveMaxPooling returns a new AdaptiveMaxPooling Function.
+func NewAdaptiveMaxPooling(x Operand, outRows, outCols int) *AdaptiveMaxPooling {
+	return &AdaptiveMaxPooling{
+		x:       x,
+		outRows: outRows,
+		outCols: outCols,
+		argmaxI: nil,
+		argmaxJ: nil,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *AdaptiveMaxPooling) Forward() mat.Matrix {
+	xv := r.x.Value()
+	checkAdaptivePoolingSize(xv, r.outRows, r.outCols)
+	y := mat.GetEmptyDenseWorkspace(r.outRows, r.outCols)
+	r.argmaxI = utils.MakeIntMatrix(r.outRows, r.outCols)
+	r.argmaxJ = utils.MakeIntMatrix(r.outRows, r.outCols)
+	for row := 0; row < r.outRows; row++ {
+		startI, endI := adaptiveRange(row, xv.Rows(), r.outRows)
+		for col := 0; col < r.outCols; col++ {
+			startJ, endJ := adaptiveRange(col, xv.Columns(), r.outCols)
+			max := math.Inf(-1)
+			for i := startI; i < endI; i++ {
+				for j := startJ; j < endJ; j++ {
+					if val := xv.At(i, j); val > max {
+						max = val
+						r.argmaxI[row][col] = i
+						r.argmaxJ[row][col] = j
+					}
+				}
+			}
+			y.Set(row, col, max)
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *AdaptiveMaxPooling) Backward(gy mat.Matrix) {
+	if !(gy.Rows() == r.outRows && gy.Columns() == r.outCols) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := r.x.Value().ZerosLike()
+		defer mat.ReleaseDense(gx.(*mat.Dense))
+		for row := 0; row < r.outRows; row++ {
+			for col := 0; col < r.outCols; col++ {
+				i, j := r.argmaxI[row][col], r.argmaxJ[row][col]
+				gx.Set(i, j, gx.At(i, j)+gy.At(row, col)) // windows may overlap
+			}
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
+
+// AdaptiveAvgPooling is an operator to perform average pooling to a target output size.
+// The size of the (possibly overlapping) windows is derived from the input size, so
+// that inputs of any dimensions are reduced to a matrix of outRows × outCols.
+type AdaptiveAvgPooling struct {
+	x       Operand
+	outRows int
+	outCols int
+}
+
+// NewAdaptiveAvgPooling returns a new AdaptiveAvgPooling Function.
+func NewAdaptiveAvgPooling(x Operand, outRows, outCols int) *AdaptiveAvgPooling {
+	return &AdaptiveAvgPooling{
+		x:       x,
+		outRows: outRows,
+		outCols: outCols,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *AdaptiveAvgPooling) Forward() mat.Matrix {
+	xv := r.x.Value()
+	checkAdaptivePoolingSize(xv, r.outRows, r.outCols)
+	y := mat.GetEmptyDenseWorkspace(r.outRows, r.outCols)
+	for row := 0; row < r.outRows; row++ {
+		startI, endI := adaptiveRange(row, xv.Rows(), r.outRows)
+		for col := 0; col < r.outCols; col++ {
+			startJ, endJ := adaptiveRange(col, xv.Columns(), r.outCols)
+			sum := 0.0
+			for i := startI; i < endI; i++ {
+				for j := startJ; j < endJ; j++ {
+					sum += xv.At(i, j)
+				}
+			}
+			y.Set(row, col, sum/float64((endI-startI)*(endJ-startJ)))
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *AdaptiveAvgPooling) Backward(gy mat.Matrix) {
+	if !(gy.Rows() == r.outRows && gy.Columns() == r.outCols) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		xv := r.x.Value()
+		gx := xv.ZerosLike()
+		defer mat.ReleaseDense(gx.(*mat.Dense))
+		for row := 0; row < r.outRows; row++ {
+			startI, endI := adaptiveRange(row, xv.Rows(), r.outRows)
+			for col := 0; col < r.outCols; col++ {
+				startJ, endJ := adaptiveRange(col, xv.Columns(), r.outCols)
+				g := gy.At(row, col) / float64((endI-startI)*(endJ-startJ))
+				for i := startI; i < endI; i++ {
+					for j := startJ; j < endJ; j++ {
+						gx.Set(i, j, gx.At(i, j)+g) // windows may overlap
+					}
+				}
+			}
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
+
+func checkAdaptivePoolingSize(x mat.Matrix, outRows, outCols int) {
+	if outRows <= 0 || outCols <= 0 || x.Rows() < outRows || x.Columns() < outCols {
+		panic("fn: size mismatch")
+	}
+}
+
+// adaptiveRange returns the [start, end) interval of the input covered by the i-th
+// output element, given the input and output sizes.
+func adaptiveRange(i, inSize, outSize int) (start, end int) {
+	start = (i * inSize) / outSize
+	end = ((i+1)*inSize + outSize - 1) / outSize // ceil
+	return
+}
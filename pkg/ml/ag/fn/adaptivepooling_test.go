@@ -0,0 +1,73 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestAdaptiveMaxPool_Forward(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(3, 3, []float64{
+			0.4, 0.1, -0.9,
+			-0.4, 0.3, 0.7,
+			0.8, 0.2, 0.6,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewAdaptiveMaxPooling(x, 2, 2)
+	y := f.Forward()
+
+	if !floats.EqualApprox(y.Data(), []float64{
+		0.4, 0.7,
+		0.8, 0.7,
+	}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	f.Backward(mat.NewDense(2, 2, []float64{
+		0.5, -0.7,
+		0.8, 0.2,
+	}))
+
+	if !floats.EqualApprox(x.grad.Data(), []float64{
+		0.5, 0.0, 0.0,
+		0.0, 0.0, -0.5,
+		0.8, 0.0, 0.0,
+	}, 1.0e-6) {
+		t.Error("The x-gradients don't match the expected values")
+	}
+}
+
+func TestAdaptiveAvgPool_Forward(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(2, 5, []float64{
+			0.4, 0.1, -0.9, 0.2, 0.3,
+			-0.4, 0.3, 0.7, 0.1, -0.2,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewAdaptiveAvgPooling(x, 1, 2)
+	y := f.Forward()
+
+	if !floats.EqualApprox(y.Data(), []float64{
+		0.2 / 6.0, 0.2 / 6.0,
+	}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	f.Backward(mat.NewDense(1, 2, []float64{0.6, 1.2}))
+
+	if !floats.EqualApprox(x.grad.Data(), []float64{
+		0.1, 0.1, 0.3, 0.2, 0.2,
+		0.1, 0.1, 0.3, 0.2, 0.2,
+	}, 1.0e-6) {
+		t.Error("The x-gradients don't match the expected values")
+	}
+}
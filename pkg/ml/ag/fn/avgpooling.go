@@ -0,0 +1,71 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+)
+
+var _ Function = &AvgPooling{}
+
+// AvgPooling is an operator to perform average pooling.
+// The input matrix is divided into non-overlapping windows of size rows × cols,
+// and each window is replaced by the mean of its values.
+type AvgPooling struct {
+	x    Operand
+	rows int
+	cols int
+}
+
+// NewAvgPooling returns a new AvgPooling Function.
+func NewAvgPooling(x Operand, r, c int) *AvgPooling {
+	return &AvgPooling{
+		x:    x,
+		rows: r,
+		cols: c,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *AvgPooling) Forward() mat.Matrix {
+	xv := r.x.Value()
+	if !(xv.Rows()%r.rows == 0 && xv.Columns()%r.cols == 0) {
+		panic("fn: size mismatch")
+	}
+	y := mat.GetEmptyDenseWorkspace(xv.Rows()/r.rows, xv.Columns()/r.cols)
+	size := float64(r.rows * r.cols)
+	for row := 0; row < y.Rows(); row++ {
+		for col := 0; col < y.Columns(); col++ {
+			sum := 0.0
+			for i := row * r.rows; i < (row*r.rows)+r.rows; i++ {
+				for j := col * r.cols; j < (col*r.cols)+r.cols; j++ {
+					sum += xv.At(i, j)
+				}
+			}
+			y.Set(row, col, sum/size)
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *AvgPooling) Backward(gy mat.Matrix) {
+	if r.x.RequiresGrad() {
+		gx := r.x.Value().ZerosLike()
+		defer mat.ReleaseDense(gx.(*mat.Dense))
+		size := float64(r.rows * r.cols)
+		for row := 0; row < gy.Rows(); row++ {
+			for col := 0; col < gy.Columns(); col++ {
+				g := gy.At(row, col) / size
+				for i := row * r.rows; i < (row*r.rows)+r.rows; i++ {
+					for j := col * r.cols; j < (col*r.cols)+r.cols; j++ {
+						gx.Set(i, j, g)
+					}
+				}
+			}
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
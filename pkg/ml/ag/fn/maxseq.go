@@ -0,0 +1,75 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+)
+
+var _ Function = &MaxSeq{}
+
+// MaxSeq is an operator to perform the element-wise max over a sequence
+// of matrices of the same dimensions (e.g. global max pooling over time).
+// The gradients are propagated only to the operand that holds the max value
+// at each position.
+type MaxSeq struct {
+	xs []Operand
+	// initialized during the forward pass
+	argmax []int
+}
+
+// NewMaxSeq returns a new MaxSeq Function.
+func NewMaxSeq(xs []Operand) *MaxSeq {
+	return &MaxSeq{
+		xs:     xs,
+		argmax: nil,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *MaxSeq) Forward() mat.Matrix {
+	if len(r.xs) == 0 {
+		panic("fn: the sequence cannot be empty")
+	}
+	first := r.xs[0].Value()
+	y := first.Clone()
+	yData := y.Data()
+	r.argmax = make([]int, len(yData))
+	for k := 1; k < len(r.xs); k++ {
+		xv := r.xs[k].Value()
+		if !mat.SameDims(first, xv) {
+			panic("fn: matrices with not compatible size")
+		}
+		for i, v := range xv.Data() {
+			if v > yData[i] {
+				yData[i] = v
+				r.argmax[i] = k
+			}
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *MaxSeq) Backward(gy mat.Matrix) {
+	if !mat.SameDims(r.xs[0].Value(), gy) {
+		panic("fn: matrices with not compatible size")
+	}
+	gyData := gy.Data()
+	for k, x := range r.xs {
+		if !x.RequiresGrad() {
+			continue
+		}
+		gx := x.Value().ZerosLike()
+		gxData := gx.Data()
+		for i, index := range r.argmax {
+			if index == k {
+				gxData[i] = gyData[i]
+			}
+		}
+		x.PropagateGrad(gx)
+		mat.ReleaseDense(gx.(*mat.Dense))
+	}
+}
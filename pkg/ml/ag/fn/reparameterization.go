@@ -0,0 +1,71 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"math"
+)
+
+var _ Function = &Reparameterization{}
+
+// Reparameterization is an operator to draw a sample from a Gaussian distribution with diagonal covariance,
+// given its mean μ and the logarithm of its variance log(σ²), keeping the sample differentiable with respect
+// to both of them (Kingma and Welling, 2014):
+//    z = μ + σ ⊙ ε, with ε ~ N(0, I)
+type Reparameterization struct {
+	mean    Operand
+	logVar  Operand
+	randGen *rand.LockedRand
+	epsilon mat.Matrix // filled during the forward
+	std     mat.Matrix // filled during the forward
+}
+
+// NewReparameterization returns a new Reparameterization Function.
+func NewReparameterization(mean, logVar Operand, randGen *rand.LockedRand) *Reparameterization {
+	return &Reparameterization{
+		mean:    mean,
+		logVar:  logVar,
+		randGen: randGen,
+		epsilon: nil,
+		std:     nil,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *Reparameterization) Forward() mat.Matrix {
+	mean := r.mean.Value()
+	logVar := r.logVar.Value()
+	if !(mat.SameDims(mean, logVar) || mat.VectorsOfSameSize(mean, logVar)) {
+		panic("fn: matrices with not compatible size")
+	}
+	epsilon := make([]float64, mean.Size())
+	for i := range epsilon {
+		epsilon[i] = r.randGen.NormFloat64()
+	}
+	r.epsilon = mat.NewDense(mean.Rows(), mean.Columns(), epsilon)
+	r.std = logVar.ProdScalar(0.5)
+	r.std.Apply(func(i, j int, v float64) float64 {
+		return math.Exp(v)
+	}, r.std)
+	return mean.Add(r.std.Prod(r.epsilon))
+}
+
+// Backward computes the backward pass.
+func (r *Reparameterization) Backward(gy mat.Matrix) {
+	if !(mat.SameDims(r.mean.Value(), gy) || mat.VectorsOfSameSize(r.mean.Value(), gy)) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.mean.RequiresGrad() {
+		r.mean.PropagateGrad(gy)
+	}
+	if r.logVar.RequiresGrad() {
+		// ∂z/∂log(σ²) = ε ⊙ σ / 2
+		gx := gy.Prod(r.epsilon).ProdInPlace(r.std).ProdScalarInPlace(0.5)
+		defer mat.ReleaseDense(gx.(*mat.Dense))
+		r.logVar.PropagateGrad(gx)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"gonum.org/v1/gonum/floats"
+	"math"
+	"testing"
+)
+
+func TestReparameterization_Forward(t *testing.T) {
+	mean := &variable{
+		value:        mat.NewVecDense([]float64{0.1, -0.2, 0.3}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	logVar := &variable{
+		value:        mat.NewVecDense([]float64{0.0, math.Log(4.0), math.Log(0.25)}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+
+	f := NewReparameterization(mean, logVar, rand.NewLockedRand(42))
+	y := f.Forward()
+
+	// the same random generator gives the same noise
+	rndGen := rand.NewLockedRand(42)
+	epsilon := []float64{rndGen.NormFloat64(), rndGen.NormFloat64(), rndGen.NormFloat64()}
+	std := []float64{1.0, 2.0, 0.5}
+	expected := make([]float64, 3)
+	for i := range expected {
+		expected[i] = mean.value.Data()[i] + std[i]*epsilon[i]
+	}
+	if !floats.EqualApprox(y.Data(), expected, 1.0e-9) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	f.Backward(mat.NewVecDense([]float64{1.0, 2.0, -1.0}))
+
+	if !floats.EqualApprox(mean.grad.Data(), []float64{1.0, 2.0, -1.0}, 1.0e-9) {
+		t.Error("The mean gradients don't match the expected values")
+	}
+	expectedLogVarGrad := []float64{
+		1.0 * epsilon[0] * std[0] * 0.5,
+		2.0 * epsilon[1] * std[1] * 0.5,
+		-1.0 * epsilon[2] * std[2] * 0.5,
+	}
+	if !floats.EqualApprox(logVar.grad.Data(), expectedLogVarGrad, 1.0e-9) {
+		t.Error("The log-variance gradients don't match the expected values")
+	}
+}
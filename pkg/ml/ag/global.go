@@ -204,6 +204,21 @@ func MaxPooling(x Node, rows, columns int) Node {
 	return globalGraph.MaxPooling(x, rows, columns)
 }
 
+// AvgPooling returns a new operator node as a result of the fn.AvgPooling function.
+func AvgPooling(x Node, rows, columns int) Node {
+	return globalGraph.AvgPooling(x, rows, columns)
+}
+
+// AdaptiveMaxPooling returns a new operator node as a result of the fn.AdaptiveMaxPooling function.
+func AdaptiveMaxPooling(x Node, rows, columns int) Node {
+	return globalGraph.AdaptiveMaxPooling(x, rows, columns)
+}
+
+// AdaptiveAvgPooling returns a new operator node as a result of the fn.AdaptiveAvgPooling function.
+func AdaptiveAvgPooling(x Node, rows, columns int) Node {
+	return globalGraph.AdaptiveAvgPooling(x, rows, columns)
+}
+
 // View returns a new operator node as a result of the fn.View function.
 func View(x Node, row, column, xStride, yStride int) Node {
 	return globalGraph.View(x, row, column, xStride, yStride)
@@ -419,3 +434,13 @@ func Concat(xs ...Node) Node {
 func Stack(xs ...Node) Node {
 	return globalGraph.Stack(xs...)
 }
+
+// MaxSeq returns a new operator node as a result of the fn.MaxSeq function.
+func MaxSeq(xs ...Node) Node {
+	return globalGraph.MaxSeq(xs...)
+}
+
+// Reparameterize returns a new operator node as a result of the fn.Reparameterization function.
+func Reparameterize(mean Node, logVar Node) Node {
+	return globalGraph.Reparameterize(mean, logVar)
+}
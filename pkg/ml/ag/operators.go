@@ -139,6 +139,16 @@ const (
 	OpConcat
 	// OpStack identifies the Graph.Stack operator.
 	OpStack
+	// OpAvgPooling identifies the Graph.AvgPooling operator.
+	OpAvgPooling
+	// OpAdaptiveMaxPooling identifies the Graph.AdaptiveMaxPooling operator.
+	OpAdaptiveMaxPooling
+	// OpAdaptiveAvgPooling identifies the Graph.AdaptiveAvgPooling operator.
+	OpAdaptiveAvgPooling
+	// OpMaxSeq identifies the Graph.MaxSeq operator.
+	OpMaxSeq
+	// OpReparameterize identifies the Graph.Reparameterize operator.
+	OpReparameterize
 )
 
 var opNameToMethodName = map[OpName]string{
@@ -204,6 +214,12 @@ var opNameToMethodName = map[OpName]string{
 	OpSum:           "Sum",
 	OpConcat:        "Concat",
 	OpStack:         "Stack",
+
+	OpAvgPooling:         "AvgPooling",
+	OpAdaptiveMaxPooling: "AdaptiveMaxPooling",
+	OpAdaptiveAvgPooling: "AdaptiveAvgPooling",
+	OpMaxSeq:             "MaxSeq",
+	OpReparameterize:     "Reparameterize",
 }
 
 // strToOpName is the inverse map of opNameToMethodName
@@ -335,6 +351,21 @@ func (g *Graph) MaxPooling(x Node, rows, columns int) Node {
 	return g.NewOperator(fn.NewMaxPooling(x, rows, columns), x)
 }
 
+// AvgPooling returns a new operator node as a result of the fn.AvgPooling function.
+func (g *Graph) AvgPooling(x Node, rows, columns int) Node {
+	return g.NewOperator(fn.NewAvgPooling(x, rows, columns), x)
+}
+
+// AdaptiveMaxPooling returns a new operator node as a result of the fn.AdaptiveMaxPooling function.
+func (g *Graph) AdaptiveMaxPooling(x Node, rows, columns int) Node {
+	return g.NewOperator(fn.NewAdaptiveMaxPooling(x, rows, columns), x)
+}
+
+// AdaptiveAvgPooling returns a new operator node as a result of the fn.AdaptiveAvgPooling function.
+func (g *Graph) AdaptiveAvgPooling(x Node, rows, columns int) Node {
+	return g.NewOperator(fn.NewAdaptiveAvgPooling(x, rows, columns), x)
+}
+
 // View returns a new operator node as a result of the fn.View function.
 func (g *Graph) View(x Node, row, column, xStride, yStride int) Node {
 	return g.NewOperator(fn.NewView(x, row, column, xStride, yStride), x)
@@ -535,3 +566,14 @@ func (g *Graph) Concat(xs ...Node) Node {
 func (g *Graph) Stack(xs ...Node) Node {
 	return g.NewOperator(fn.NewStack(Operands(xs)), xs...)
 }
+
+// MaxSeq returns a new operator node as a result of the fn.MaxSeq function.
+func (g *Graph) MaxSeq(xs ...Node) Node {
+	return g.NewOperator(fn.NewMaxSeq(Operands(xs)), xs...)
+}
+
+// Reparameterize returns a new operator node as a result of the fn.Reparameterization function.
+// The random sample is drawn using the random generator of the graph.
+func (g *Graph) Reparameterize(mean Node, logVar Node) Node {
+	return g.NewOperator(fn.NewReparameterization(mean, logVar, g.randGen), mean, logVar)
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pe
+
+import (
+	"math"
+)
+
+// ALiBiSlopes returns the head-specific slopes of the Attention with Linear Biases (Press et al., 2021).
+// Instead of adding positional encodings to the input, ALiBi biases the attention score of a query for
+// a key by -slope·|query position - key position|.
+// For n heads (with n power of 2) the slopes are the geometric sequence starting at 2^(-8/n) with that
+// same ratio; otherwise the slopes of the closest lower power of 2 are completed with every other slope
+// of the following power of 2, as in the reference implementation.
+func ALiBiSlopes(numOfHeads int) []float64 {
+	powerOf2 := 1 << uint(math.Floor(math.Log2(float64(numOfHeads))))
+	slopes := geometricSlopes(powerOf2)
+	if powerOf2 == numOfHeads {
+		return slopes
+	}
+	extra := geometricSlopes(2 * powerOf2)
+	for i := 0; len(slopes) < numOfHeads; i += 2 {
+		slopes = append(slopes, extra[i])
+	}
+	return slopes
+}
+
+func geometricSlopes(n int) []float64 {
+	start := math.Pow(2, -8.0/float64(n))
+	slopes := make([]float64, n)
+	for i := range slopes {
+		slopes[i] = math.Pow(start, float64(i+1))
+	}
+	return slopes
+}
@@ -5,7 +5,10 @@
 package pe
 
 import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"gonum.org/v1/gonum/floats"
+	"math"
 	"testing"
 )
 
@@ -44,3 +47,77 @@ func TestAxialPositionalEncoder_Forward(t *testing.T) {
 		t.Error("Fourth position doesn't match the expected values")
 	}
 }
+
+func TestRelativePositionBucket(t *testing.T) {
+	bidirectional := []struct{ relativePosition, bucket int }{
+		{0, 0}, {-1, 1}, {1, 17}, {-7, 7}, {-100, 15}, {-1000, 15}, {1000, 31},
+	}
+	for _, c := range bidirectional {
+		if b := RelativePositionBucket(c.relativePosition, true, 32, 128); b != c.bucket {
+			t.Errorf("Bidirectional bucket of %d: expected %d, got %d", c.relativePosition, c.bucket, b)
+		}
+	}
+	unidirectional := []struct{ relativePosition, bucket int }{
+		{5, 0}, {-3, 3}, {-20, 17}, {-1000, 31},
+	}
+	for _, c := range unidirectional {
+		if b := RelativePositionBucket(c.relativePosition, false, 32, 128); b != c.bucket {
+			t.Errorf("Unidirectional bucket of %d: expected %d, got %d", c.relativePosition, c.bucket, b)
+		}
+	}
+}
+
+func TestALiBiSlopes(t *testing.T) {
+	if !floats.EqualApprox(ALiBiSlopes(4), []float64{0.25, 0.0625, 0.015625, 0.00390625}, 1.0e-12) {
+		t.Error("The slopes of 4 heads don't match the expected values")
+	}
+	if !floats.EqualApprox(ALiBiSlopes(6), []float64{0.25, 0.0625, 0.015625, 0.00390625, 0.5, 0.125}, 1.0e-12) {
+		t.Error("The slopes of 6 heads don't match the expected values")
+	}
+}
+
+func TestRotaryPositionalEncoder_Encode(t *testing.T) {
+	encoder := NewRotaryPositionalEncoder(4, 10)
+	g := ag.NewGraph()
+	q := g.NewVariable(mat.NewVecDense([]float64{0.3, -0.5, 0.8, 0.1}), false)
+	k := g.NewVariable(mat.NewVecDense([]float64{-0.2, 0.4, 0.6, -0.9}), false)
+
+	ys := encoder.Encode(g, q, q, q, k, k, k)
+
+	if !floats.EqualApprox(ys[0].Value().Data(), q.Value().Data(), 1.0e-12) {
+		t.Error("The position 0 must not be rotated")
+	}
+	if !floats.EqualApprox([]float64{ys[2].Value().DotUnitary(ys[2].Value())}, []float64{q.Value().DotUnitary(q.Value())}, 1.0e-12) {
+		t.Error("The rotation must preserve the norm")
+	}
+	// the dot product depends only on the relative position (3 in both cases)
+	d1 := ys[0].Value().DotUnitary(ys[3].Value())
+	d2 := ys[2].Value().DotUnitary(ys[5].Value())
+	if !floats.EqualApprox([]float64{d1}, []float64{d2}, 1.0e-12) {
+		t.Error("The dot product must depend only on the relative position")
+	}
+}
+
+func TestRotaryPositionalEncoder_Backward(t *testing.T) {
+	encoder := NewRotaryPositionalEncoder(4, 10)
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{0.3, -0.5, 0.8, 0.1}), true)
+
+	y := encoder.Encode(g, x, x)[1]
+
+	// the pairs are rotated by the angles 1 and 0.01
+	c0, s0, c1, s1 := math.Cos(1), math.Sin(1), math.Cos(0.01), math.Sin(0.01)
+	if !floats.EqualApprox(y.Value().Data(), []float64{
+		0.3*c0 + 0.5*s0,
+		0.3*s0 - 0.5*c0,
+		0.8*c1 - 0.1*s1,
+		0.8*s1 + 0.1*c1,
+	}, 1.0e-12) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	g.Backward(y, ag.OutputGrad(mat.NewVecDense([]float64{1.0, 0.0, 0.0, 1.0})))
+	if !floats.EqualApprox(x.Grad().Data(), []float64{c0, -s0, s1, c1}, 1.0e-12) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
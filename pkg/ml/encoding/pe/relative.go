@@ -0,0 +1,39 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pe
+
+import (
+	"math"
+)
+
+// RelativePositionBucket translates a relative position (key position - query position) into a bucket number,
+// as in the relative attention of T5 (Raffel et al., 2019).
+// Half of the buckets are for exact increments in positions, while the others are for logarithmically
+// bigger bins, up to maxDistance; all larger distances share the last bucket.
+// If bidirectional is false, positive relative positions (i.e. keys following the query) are mapped to the
+// bucket 0; otherwise, half of the buckets are reserved to them.
+func RelativePositionBucket(relativePosition int, bidirectional bool, numOfBuckets, maxDistance int) int {
+	bucket := 0
+	n := -relativePosition
+	if bidirectional {
+		numOfBuckets /= 2
+		if n < 0 {
+			bucket += numOfBuckets
+			n = -n
+		}
+	} else if n < 0 {
+		n = 0
+	}
+	maxExact := numOfBuckets / 2
+	if n < maxExact {
+		return bucket + n
+	}
+	large := maxExact + int(math.Log(float64(n)/float64(maxExact))/
+		math.Log(float64(maxDistance)/float64(maxExact))*float64(numOfBuckets-maxExact))
+	if large > numOfBuckets-1 {
+		large = numOfBuckets - 1
+	}
+	return bucket + large
+}
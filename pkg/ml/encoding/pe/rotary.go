@@ -0,0 +1,102 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pe
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+	"math"
+)
+
+// RotaryPositionalEncoder implements the Rotary Position Embedding (RoPE) of RoFormer (Su et al., 2021).
+// Instead of being added to the input, the encoding rotates each pair of elements (2i, 2i+1) of the
+// query and key vectors by the angle pos·θi, with θi = 10000^(-2i/size), so that the dot product
+// between a query and a key depends only on their relative position.
+type RotaryPositionalEncoder struct {
+	// Size is the encoding vector size (it must be even).
+	Size int
+	// Length is the max number of positions.
+	Length int
+	// cos and sin contain the pre-computed cosines and sines of the angles of each pair, for each position.
+	cos [][]float64
+	sin [][]float64
+}
+
+// NewRotaryPositionalEncoder returns a new RotaryPositionalEncoder ready to use.
+func NewRotaryPositionalEncoder(size, length int) *RotaryPositionalEncoder {
+	if size%2 != 0 {
+		panic("pe: the size of the rotary encoding must be even")
+	}
+	pe := &RotaryPositionalEncoder{
+		Size:   size,
+		Length: length,
+		cos:    make([][]float64, length),
+		sin:    make([][]float64, length),
+	}
+	for pos := 0; pos < length; pos++ {
+		pe.cos[pos] = make([]float64, size/2)
+		pe.sin[pos] = make([]float64, size/2)
+		for i := 0; i < size; i += 2 {
+			angle := float64(pos) * math.Exp(float64(i)*-math.Log(10000.0)/float64(size))
+			pe.cos[pos][i/2] = math.Cos(angle)
+			pe.sin[pos][i/2] = math.Sin(angle)
+		}
+	}
+	return pe
+}
+
+// Encode returns the rotations of the xs, each one by the angles of its position in the sequence.
+func (r *RotaryPositionalEncoder) Encode(g *ag.Graph, xs ...ag.Node) []ag.Node {
+	if len(xs) > r.Length {
+		panic("pe: the sequence exceeds the max length of the rotary encoding")
+	}
+	ys := make([]ag.Node, len(xs))
+	for pos, x := range xs {
+		ys[pos] = g.NewOperator(&rotation{x: x, cos: r.cos[pos], sin: r.sin[pos]}, x)
+	}
+	return ys
+}
+
+var _ fn.Function = &rotation{}
+
+// rotation is a fn.Function which rotates each pair of elements (x[2i], x[2i+1]) of a vector by the angle
+// whose cosine and sine are cos[i] and sin[i]:
+//    y[2i]   = x[2i]·cos[i] - x[2i+1]·sin[i]
+//    y[2i+1] = x[2i]·sin[i] + x[2i+1]·cos[i]
+type rotation struct {
+	x   fn.Operand
+	cos []float64
+	sin []float64
+}
+
+// Forward computes the output of the function.
+func (r *rotation) Forward() mat.Matrix {
+	return mat.NewVecDense(rotate(r.x.Value().Data(), r.cos, r.sin, 1.0))
+}
+
+// Backward computes the backward pass, which is the rotation by the opposite angles.
+func (r *rotation) Backward(gy mat.Matrix) {
+	if r.x.RequiresGrad() {
+		gx := mat.NewVecDense(rotate(gy.Data(), r.cos, r.sin, -1.0))
+		defer mat.ReleaseDense(gx)
+		r.x.PropagateGrad(gx)
+	}
+}
+
+// rotate returns the rotation of the pairs of elements of x, with the sines multiplied by sign.
+func rotate(x, cos, sin []float64, sign float64) []float64 {
+	if len(x) != 2*len(cos) {
+		panic("pe: the size of the vector doesn't match the rotary encoding")
+	}
+	y := make([]float64, len(x))
+	for i := range cos {
+		x0, x1 := x[2*i], x[2*i+1]
+		s := sign * sin[i]
+		y[2*i] = x0*cos[i] - x1*s
+		y[2*i+1] = x0*s + x1*cos[i]
+	}
+	return y
+}
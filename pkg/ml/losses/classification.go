@@ -0,0 +1,165 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package losses
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"math"
+)
+
+// BCEWithLogits measures the binary cross-entropy between each element in the input logits x and the target
+// probabilities y. It combines the Sigmoid and the binary cross-entropy in a numerically stable way:
+//    loss = log(1 + exp(x)) - x * y
+func BCEWithLogits(g *ag.Graph, x ag.Node, y ag.Node, reduceMean bool) ag.Node {
+	loss := g.Sub(softPlus(g, x), g.Prod(x, y))
+	if reduceMean {
+		return g.ReduceMean(loss)
+	}
+	return g.ReduceSum(loss)
+}
+
+// WeightedBCEWithLogits is a variant of BCEWithLogits where the loss of the positive examples of each
+// element is multiplied by the corresponding element of posWeight, to trade off recall and precision:
+//    loss = (1 - y) * x + (1 + (posWeight - 1) * y) * log(1 + exp(-x))
+func WeightedBCEWithLogits(g *ag.Graph, x ag.Node, y ag.Node, posWeight ag.Node, reduceMean bool) ag.Node {
+	one := g.Constant(1.0)
+	logWeight := g.AddScalar(g.Prod(g.SubScalar(posWeight, one), y), one)
+	loss := g.Add(g.Prod(g.ReverseSub(y, one), x), g.Prod(logWeight, softPlus(g, g.Neg(x))))
+	if reduceMean {
+		return g.ReduceMean(loss)
+	}
+	return g.ReduceSum(loss)
+}
+
+// softPlus returns log(1 + exp(x)), which is computed as x when x is greater than 20 to avoid overflows.
+func softPlus(g *ag.Graph, x ag.Node) ag.Node {
+	return g.SoftPlus(x, g.Constant(1.0), g.Constant(20.0))
+}
+
+// logSumExp returns log(Σ exp(x)), subtracting the maximum value of x before the exponentiation to avoid overflows.
+func logSumExp(g *ag.Graph, x ag.Node) ag.Node {
+	m := g.Constant(x.Value().Max())
+	return g.AddScalar(g.Log(g.ReduceSum(g.Exp(g.SubScalar(x, m)))), m)
+}
+
+// WeightedCrossEntropy is a variant of CrossEntropy where the loss is multiplied by the weight
+// of the gold class c, to balance the classes.
+func WeightedCrossEntropy(g *ag.Graph, x ag.Node, c int, weights []float64) ag.Node {
+	return g.ProdScalar(CrossEntropy(g, x, c), g.Constant(weights[c]))
+}
+
+// Focal implements the focal loss of the logits x respect to the gold class c (Lin et al., 2017,
+// "Focal Loss for Dense Object Detection"), which down-weights the well-classified examples:
+//    loss = -(1 - p(c))^γ log(p(c))
+// With gamma equal to zero it is the same as CrossEntropy.
+func Focal(g *ag.Graph, x ag.Node, c int, gamma float64) ag.Node {
+	logProb := g.Sub(g.AtVec(x, c), logSumExp(g, x))
+	weight := g.Pow(g.ReverseSub(g.Exp(logProb), g.Constant(1.0)), gamma)
+	return g.Neg(g.Prod(weight, logProb))
+}
+
+// LabelSmoothingCrossEntropy implements the cross-entropy of the logits x respect to the gold class c,
+// smoothing the one-hot target distribution with the uniform distribution over the K classes:
+//    q(k) = (1 - ε) 1[k = c] + ε / K
+// With epsilon equal to zero it is the same as CrossEntropy.
+func LabelSmoothingCrossEntropy(g *ag.Graph, x ag.Node, c int, epsilon float64) ag.Node {
+	k := float64(x.Value().Size())
+	target := g.Add(
+		g.ProdScalar(g.AtVec(x, c), g.Constant(1.0-epsilon)),
+		g.ProdScalar(g.ReduceSum(x), g.Constant(epsilon/k)),
+	)
+	return g.Sub(logSumExp(g, x), target)
+}
+
+// KLDivergence returns the Kullback-Leibler divergence KL(y || softmax(x)) between the target probability
+// distribution y and the distribution predicted by the logits x:
+//    KL = Σ y (log(y) - log(softmax(x)))
+// The terms where y is zero are equal to zero.
+func KLDivergence(g *ag.Graph, x ag.Node, y ag.Node) ag.Node {
+	negEntropy := 0.0
+	for _, v := range y.Value().Data() {
+		if v > 0 {
+			negEntropy += v * math.Log(v)
+		}
+	}
+	crossEntropy := g.Sub(g.ProdScalar(logSumExp(g, x), g.ReduceSum(y)), g.Dot(y, x))
+	return g.AddScalar(crossEntropy, g.Constant(negEntropy))
+}
+
+// BCEWithLogitsSeq calculates the BCEWithLogits loss on the given sequence.
+func BCEWithLogitsSeq(g *ag.Graph, predicted []ag.Node, target []ag.Node, reduceMean bool) ag.Node {
+	loss := BCEWithLogits(g, predicted[0], target[0], false)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, BCEWithLogits(g, predicted[i], target[i], false))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(predicted))))
+	}
+	return loss
+}
+
+// WeightedBCEWithLogitsSeq calculates the WeightedBCEWithLogits loss on the given sequence.
+func WeightedBCEWithLogitsSeq(g *ag.Graph, predicted []ag.Node, target []ag.Node, posWeight ag.Node, reduceMean bool) ag.Node {
+	loss := WeightedBCEWithLogits(g, predicted[0], target[0], posWeight, false)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, WeightedBCEWithLogits(g, predicted[i], target[i], posWeight, false))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(predicted))))
+	}
+	return loss
+}
+
+// WeightedCrossEntropySeq calculates the WeightedCrossEntropy loss on the given sequence.
+// If reduceMean is true, the loss is divided by the sum of the weights of the gold classes,
+// so that it is a weighted mean.
+func WeightedCrossEntropySeq(g *ag.Graph, predicted []ag.Node, target []int, weights []float64, reduceMean bool) ag.Node {
+	loss := WeightedCrossEntropy(g, predicted[0], target[0], weights)
+	sum := weights[target[0]]
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, WeightedCrossEntropy(g, predicted[i], target[i], weights))
+		sum += weights[target[i]]
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(sum))
+	}
+	return loss
+}
+
+// FocalSeq calculates the Focal loss on the given sequence.
+func FocalSeq(g *ag.Graph, predicted []ag.Node, target []int, gamma float64, reduceMean bool) ag.Node {
+	loss := Focal(g, predicted[0], target[0], gamma)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, Focal(g, predicted[i], target[i], gamma))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(predicted))))
+	}
+	return loss
+}
+
+// LabelSmoothingCrossEntropySeq calculates the LabelSmoothingCrossEntropy loss on the given sequence.
+func LabelSmoothingCrossEntropySeq(g *ag.Graph, predicted []ag.Node, target []int, epsilon float64, reduceMean bool) ag.Node {
+	loss := LabelSmoothingCrossEntropy(g, predicted[0], target[0], epsilon)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, LabelSmoothingCrossEntropy(g, predicted[i], target[i], epsilon))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(predicted))))
+	}
+	return loss
+}
+
+// KLDivergenceSeq calculates the KLDivergence loss on the given sequence.
+func KLDivergenceSeq(g *ag.Graph, predicted []ag.Node, target []ag.Node, reduceMean bool) ag.Node {
+	loss := KLDivergence(g, predicted[0], target[0])
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, KLDivergence(g, predicted[i], target[i]))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(predicted))))
+	}
+	return loss
+}
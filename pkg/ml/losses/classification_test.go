@@ -0,0 +1,140 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package losses
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestBCEWithLogits(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{-2.0, 0.0, 3.0, 100.0}), true)
+	y := g.NewVariable(mat.NewVecDense([]float64{0.0, 1.0, 1.0, 0.5}), false)
+	loss := BCEWithLogits(g, x, y, true)
+
+	if !equalApprox(loss.Value().Scalar(), 12.717166) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x.Grad().Data(), []float64{0.029801, -0.125, -0.011856, 0.125}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
+
+func TestWeightedBCEWithLogits(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{-2.0, 0.0, 3.0, 100.0}), true)
+	y := g.NewVariable(mat.NewVecDense([]float64{0.0, 1.0, 1.0, 0.5}), false)
+	posWeight := g.NewVariable(mat.NewVecDense([]float64{2.0, 2.0, 2.0, 2.0}), false)
+	loss := WeightedBCEWithLogits(g, x, y, posWeight, false)
+
+	if !equalApprox(loss.Value().Scalar(), 51.610397) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x.Grad().Data(), []float64{0.119203, -1.0, -0.094852, 0.5}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
+
+func TestBCEWithLogitsSeq(t *testing.T) {
+	g := ag.NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]float64{-2.0, 0.0}), true)
+	y1 := g.NewVariable(mat.NewVecDense([]float64{0.0, 1.0}), false)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{3.0, 100.0}), true)
+	y2 := g.NewVariable(mat.NewVecDense([]float64{1.0, 0.5}), false)
+	loss := BCEWithLogitsSeq(g, []ag.Node{x1, x2}, []ag.Node{y1, y2}, true)
+
+	if !equalApprox(loss.Value().Scalar(), 25.434331) {
+		t.Error("The loss doesn't match the expected value")
+	}
+}
+
+func TestFocal(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, 2.0, 3.0}), true)
+	loss := Focal(g, x, 0, 2.0)
+
+	if !equalApprox(loss.Value().Scalar(), 1.993605) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x.Grad().Data(), []float64{-1.112466, 0.299188, 0.813278}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
+
+func TestFocalSeq_WithoutFocusing(t *testing.T) {
+	g := ag.NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]float64{1.0, 2.0, 3.0}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{-500, 0, 0.693147}), true)
+	focal := FocalSeq(g, []ag.Node{x1, x2}, []int{0, 2}, 0.0, true)
+	crossEntropy := CrossEntropySeq(g, []ag.Node{x1, x2}, []int{0, 2}, true)
+
+	if !equalApprox(focal.Value().Scalar(), crossEntropy.Value().Scalar()) {
+		t.Error("The focal loss with gamma equal to zero doesn't match the cross-entropy")
+	}
+}
+
+func TestLabelSmoothingCrossEntropy(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, 2.0, 3.0}), true)
+	loss := LabelSmoothingCrossEntropy(g, x, 2, 0.1)
+
+	if !equalApprox(loss.Value().Scalar(), 0.507606) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x.Grad().Data(), []float64{0.056697, 0.211395, -0.268092}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
+
+func TestKLDivergence(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, 2.0, 3.0}), true)
+	y := g.NewVariable(mat.NewVecDense([]float64{0.2, 0.3, 0.5}), false)
+	loss := KLDivergence(g, x, y)
+
+	if !equalApprox(loss.Value().Scalar(), 0.077953) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x.Grad().Data(), []float64{-0.109969, -0.055272, 0.165241}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
+
+func TestWeightedCrossEntropySeq(t *testing.T) {
+	g := ag.NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]float64{-500, 0, 0.693147, 1.94591}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{-500, 0, 0.693147, 1.94591}), true)
+	weights := []float64{1.0, 1.0, 3.0, 1.0}
+	loss := WeightedCrossEntropySeq(g, []ag.Node{x1, x2}, []int{2, 1}, weights, true)
+
+	// (3 * 1.609438 + 2.302585) / 4
+	if !equalApprox(loss.Value().Scalar(), 1.782725) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x1.Grad().Data(), []float64{0.0, 0.075, -0.6, 0.525}, 1.0e-6) {
+		t.Error("The x1-gradients don't match the expected values")
+	}
+}
@@ -26,6 +26,31 @@ func MSE(g *ag.Graph, x ag.Node, y ag.Node, reduceMean bool) ag.Node {
 	return g.ReduceSum(loss)
 }
 
+// Huber measures the Huber loss between each element in the input x and target y, which is quadratic
+// for absolute errors smaller than delta and linear otherwise, so that it is less sensitive to outliers than MSE:
+//    loss = 0.5 d²             if |d| <= δ
+//    loss = δ (|d| - 0.5 δ)    otherwise
+// where d = x - y.
+func Huber(g *ag.Graph, x ag.Node, y ag.Node, delta float64, reduceMean bool) ag.Node {
+	d := g.Abs(g.Sub(x, y))
+	quadratic := g.Sub(d, g.ReLU(g.SubScalar(d, g.Constant(delta)))) // min(|d|, δ)
+	linear := g.Sub(d, quadratic)
+	loss := g.Add(
+		g.ProdScalar(g.Square(quadratic), g.Constant(0.5)),
+		g.ProdScalar(linear, g.Constant(delta)),
+	)
+	if reduceMean {
+		return g.ReduceMean(loss)
+	}
+	return g.ReduceSum(loss)
+}
+
+// SmoothL1 measures the smooth L1 loss between each element in the input x and target y,
+// which is equal to the Huber loss with delta equal to beta, divided by beta.
+func SmoothL1(g *ag.Graph, x ag.Node, y ag.Node, beta float64, reduceMean bool) ag.Node {
+	return g.DivScalar(Huber(g, x, y, beta, reduceMean), g.Constant(beta))
+}
+
 // NLL returns the loss of the input x respect to the target y.
 // The target is expected to be a one-hot vector.
 func NLL(g *ag.Graph, x ag.Node, y ag.Node) ag.Node {
@@ -89,6 +114,30 @@ func MAESeq(g *ag.Graph, predicted []ag.Node, target []ag.Node, reduceMean bool)
 	return loss
 }
 
+// HuberSeq calculates the Huber loss on the given sequence.
+func HuberSeq(g *ag.Graph, predicted []ag.Node, target []ag.Node, delta float64, reduceMean bool) ag.Node {
+	loss := Huber(g, predicted[0], target[0], delta, false)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, Huber(g, predicted[i], target[i], delta, false))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(predicted))))
+	}
+	return loss
+}
+
+// SmoothL1Seq calculates the SmoothL1 loss on the given sequence.
+func SmoothL1Seq(g *ag.Graph, predicted []ag.Node, target []ag.Node, beta float64, reduceMean bool) ag.Node {
+	loss := SmoothL1(g, predicted[0], target[0], beta, false)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, SmoothL1(g, predicted[i], target[i], beta, false))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(predicted))))
+	}
+	return loss
+}
+
 // CrossEntropySeq calculates the CrossEntropy loss on the given sequence.
 func CrossEntropySeq(g *ag.Graph, predicted []ag.Node, target []int, reduceMean bool) ag.Node {
 	loss := CrossEntropy(g, predicted[0], target[0])
@@ -111,3 +160,13 @@ func SPG(g *ag.Graph, logPropActions []ag.Node, logProbTargets []ag.Node) ag.Nod
 	}
 	return g.Neg(loss)
 }
+
+// GaussianKLDivergence returns the Kullback-Leibler divergence KL(N(μ, σ²) || N(0, I)) between a Gaussian
+// distribution with diagonal covariance, given its mean μ and the logarithm of its variance log(σ²),
+// and the standard normal distribution:
+//    KL = 0.5 * Σ(μ² + σ² - log(σ²) - 1)
+// It is the regularization term of the Variational Autoencoder loss.
+func GaussianKLDivergence(g *ag.Graph, mean ag.Node, logVar ag.Node) ag.Node {
+	kl := g.Sub(g.Add(g.Square(mean), g.Exp(logVar)), g.AddScalar(logVar, g.Constant(1.0)))
+	return g.ProdScalar(g.ReduceSum(kl), g.Constant(0.5))
+}
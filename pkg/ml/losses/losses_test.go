@@ -8,6 +8,7 @@ import (
 	"github.com/nlpodyssey/spago/pkg/mat"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"gonum.org/v1/gonum/floats"
+	"math"
 	"testing"
 )
 
@@ -131,6 +132,63 @@ func TestMSESeqLoss(t *testing.T) {
 	}
 }
 
+func TestGaussianKLDivergence(t *testing.T) {
+	g := ag.NewGraph()
+	mean := g.NewVariable(mat.NewVecDense([]float64{0.5, -1.0}), true)
+	logVar := g.NewVariable(mat.NewVecDense([]float64{0.0, math.Log(2.0)}), true)
+	loss := GaussianKLDivergence(g, mean, logVar)
+
+	if !equalApprox(loss.Value().Scalar(), 0.778426) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(mean.Grad().Data(), []float64{0.5, -1.0}, 1.0e-6) {
+		t.Error("The mean gradients don't match the expected values")
+	}
+	if !floats.EqualApprox(logVar.Grad().Data(), []float64{0.0, 0.5}, 1.0e-6) {
+		t.Error("The log-variance gradients don't match the expected values")
+	}
+}
+
 func equalApprox(a, b float64) bool {
 	return floats.EqualWithinAbsOrRel(a, b, 1.0e-06, 1.0e-06)
 }
+
+func TestHuberLoss(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{0.0, 1.0, 3.0, -2.0}), true)
+	y := g.NewVariable(mat.NewVecDense([]float64{0.5, 0.0, 0.0, 0.0}), false)
+	loss := Huber(g, x, y, 1.0, false)
+
+	if !equalApprox(loss.Value().Scalar(), 4.625) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x.Grad().Data(), []float64{-0.5, 1.0, 1.0, -1.0}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
+
+func TestSmoothL1SeqLoss(t *testing.T) {
+	g := ag.NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]float64{0.0, 1.0}), true)
+	y1 := g.NewVariable(mat.NewVecDense([]float64{0.5, 0.0}), false)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{3.0, -2.0}), true)
+	y2 := g.NewVariable(mat.NewVecDense([]float64{0.0, 0.0}), false)
+	loss := SmoothL1Seq(g, []ag.Node{x1, x2}, []ag.Node{y1, y2}, 2.0, true)
+
+	// (0.0625 + 0.25 + 2 + 1) / 2
+	if !equalApprox(loss.Value().Scalar(), 1.65625) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(x2.Grad().Data(), []float64{0.5, -0.5}, 1.0e-6) {
+		t.Error("The x2-gradients don't match the expected values")
+	}
+}
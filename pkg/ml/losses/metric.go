@@ -0,0 +1,94 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package losses
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+)
+
+// epsilon prevents the division by zero and the undefined gradients of the norm of zero vectors.
+const epsilon = 1.0e-12
+
+// TripletMargin implements the triplet margin loss, which pushes the Euclidean distance between the anchor
+// and the positive example to be smaller than the distance between the anchor and the negative example
+// by at least the margin (Schroff et al., 2015, "FaceNet: A Unified Embedding for Face Recognition and Clustering"):
+//    loss = max(0, d(a, p) - d(a, n) + margin)
+func TripletMargin(g *ag.Graph, anchor, positive, negative ag.Node, margin float64) ag.Node {
+	dp := euclideanDistance(g, anchor, positive)
+	dn := euclideanDistance(g, anchor, negative)
+	return g.ReLU(g.AddScalar(g.Sub(dp, dn), g.Constant(margin)))
+}
+
+// CosineEmbedding implements the cosine embedding loss, a contrastive loss which pushes the cosine similarity
+// of similar pairs (y = 1) to one, and the cosine similarity of dissimilar pairs (y = -1) below the margin:
+//    loss = 1 - cos(x1, x2)                 if y = 1
+//    loss = max(0, cos(x1, x2) - margin)    if y = -1
+func CosineEmbedding(g *ag.Graph, x1, x2 ag.Node, y int, margin float64) ag.Node {
+	cos := cosineSimilarity(g, x1, x2)
+	if y == 1 {
+		return g.ReverseSub(cos, g.Constant(1.0))
+	}
+	return g.ReLU(g.SubScalar(cos, g.Constant(margin)))
+}
+
+// InfoNCE implements the InfoNCE loss with in-batch negatives (van den Oord et al., 2018, "Representation
+// Learning with Contrastive Predictive Coding"). The i-th key is the positive example of the i-th query,
+// while the other keys are its negative examples. The loss of each query is the cross-entropy of the
+// cosine similarities with all the keys, divided by the temperature, respect to its positive key:
+//    loss(i) = -log(exp(cos(q(i), k(i)) / τ) / Σ exp(cos(q(i), k(j)) / τ))
+// The losses of the queries are summed, or averaged if reduceMean is true.
+func InfoNCE(g *ag.Graph, queries, keys []ag.Node, temperature float64, reduceMean bool) ag.Node {
+	normalizedKeys := make([]ag.Node, len(keys))
+	for i, k := range keys {
+		normalizedKeys[i] = normalize(g, k)
+	}
+	stackedKeys := g.Stack(normalizedKeys...)
+	t := g.Constant(temperature)
+	var loss ag.Node
+	for i, q := range queries {
+		logits := g.DivScalar(g.Mul(stackedKeys, normalize(g, q)), t)
+		loss = g.Add(loss, CrossEntropy(g, logits, i))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(queries))))
+	}
+	return loss
+}
+
+// TripletMarginSeq calculates the TripletMargin loss on the given sequence of triplets.
+func TripletMarginSeq(g *ag.Graph, anchors, positives, negatives []ag.Node, margin float64, reduceMean bool) ag.Node {
+	loss := TripletMargin(g, anchors[0], positives[0], negatives[0], margin)
+	for i := 1; i < len(anchors); i++ {
+		loss = g.Add(loss, TripletMargin(g, anchors[i], positives[i], negatives[i], margin))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(anchors))))
+	}
+	return loss
+}
+
+// CosineEmbeddingSeq calculates the CosineEmbedding loss on the given sequence of pairs.
+func CosineEmbeddingSeq(g *ag.Graph, x1, x2 []ag.Node, y []int, margin float64, reduceMean bool) ag.Node {
+	loss := CosineEmbedding(g, x1[0], x2[0], y[0], margin)
+	for i := 1; i < len(x1); i++ {
+		loss = g.Add(loss, CosineEmbedding(g, x1[i], x2[i], y[i], margin))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(float64(len(x1))))
+	}
+	return loss
+}
+
+func euclideanDistance(g *ag.Graph, x1, x2 ag.Node) ag.Node {
+	return g.Sqrt(g.AddScalar(g.ReduceSum(g.Square(g.Sub(x1, x2))), g.Constant(epsilon)))
+}
+
+func normalize(g *ag.Graph, x ag.Node) ag.Node {
+	return g.DivScalar(x, g.Sqrt(g.AddScalar(g.ReduceSum(g.Square(x)), g.Constant(epsilon))))
+}
+
+func cosineSimilarity(g *ag.Graph, x1, x2 ag.Node) ag.Node {
+	return g.Dot(normalize(g, x1), normalize(g, x2))
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package losses
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestTripletMargin(t *testing.T) {
+	g := ag.NewGraph()
+	anchor := g.NewVariable(mat.NewVecDense([]float64{1.0, 0.0}), true)
+	positive := g.NewVariable(mat.NewVecDense([]float64{0.0, 1.0}), false)
+	negative := g.NewVariable(mat.NewVecDense([]float64{2.0, 2.0}), false)
+	loss := TripletMargin(g, anchor, positive, negative, 1.5)
+
+	if !equalApprox(loss.Value().Scalar(), 0.678146) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	if !floats.EqualApprox(anchor.Grad().Data(), []float64{1.154320, 0.187320}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+
+	if TripletMargin(g, anchor, positive, negative, 0.5).Value().Scalar() != 0.0 {
+		t.Error("Expected zero loss when the margin is satisfied")
+	}
+}
+
+func TestCosineEmbeddingSeq(t *testing.T) {
+	g := ag.NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]float64{1.0, 2.0}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{2.0, 1.0}), true)
+
+	if !equalApprox(CosineEmbedding(g, x1, x2, 1, 0.5).Value().Scalar(), 0.2) {
+		t.Error("The loss of the similar pair doesn't match the expected value")
+	}
+	if !equalApprox(CosineEmbedding(g, x1, x2, -1, 0.5).Value().Scalar(), 0.3) {
+		t.Error("The loss of the dissimilar pair doesn't match the expected value")
+	}
+
+	loss := CosineEmbeddingSeq(g, []ag.Node{x1, x1}, []ag.Node{x2, x2}, []int{1, -1}, 0.5, true)
+	if !equalApprox(loss.Value().Scalar(), 0.25) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	// the gradients of the two pairs cancel each other out
+	if !floats.EqualApprox(x1.Grad().Data(), []float64{0.0, 0.0}, 1.0e-6) {
+		t.Error("The gradients don't match the expected values")
+	}
+}
+
+func TestInfoNCE(t *testing.T) {
+	g := ag.NewGraph()
+	queries := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]float64{1.0, 0.0}), true),
+		g.NewVariable(mat.NewVecDense([]float64{0.0, 1.0}), true),
+	}
+	keys := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]float64{1.0, 1.0}), true),
+		g.NewVariable(mat.NewVecDense([]float64{-1.0, 2.0}), true),
+	}
+	loss := InfoNCE(g, queries, keys, 0.5, true)
+
+	if !equalApprox(loss.Value().Scalar(), 0.309015) {
+		t.Error("The loss doesn't match the expected value")
+	}
+
+	g.Backward(loss)
+
+	for _, x := range append(queries, keys...) {
+		if !x.HasGrad() {
+			t.Error("Expected gradients for all the queries and keys")
+		}
+	}
+}
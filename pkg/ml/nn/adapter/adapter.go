@@ -0,0 +1,92 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package adapter provides the bottleneck adapter, as introduced by Neil Houlsby et al. in
+// "Parameter-Efficient Transfer Learning for NLP", 2019 (https://arxiv.org/pdf/1902.00751.pdf).
+//
+// An adapter is a small residual feed-forward network
+//    y = x + Up(f(Down(x)))
+// that is inserted into a frozen pre-trained model. Down projects the input to a small bottleneck size
+// and Up projects it back; since Up is initialized to zeros, the adapter is initially the identity function.
+package adapter
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+)
+
+var (
+	_ nn.Model     = &Model{}
+	_ nn.Processor = &Processor{}
+)
+
+// Config provides configuration settings for an adapter Model.
+type Config struct {
+	Size           int
+	BottleneckSize int
+	Activation     ag.OpName
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	Config
+	Down       *linear.Model
+	Activation *activation.Model
+	Up         *linear.Model
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	return &Model{
+		Config:     config,
+		Down:       linear.New(config.Size, config.BottleneckSize),
+		Activation: activation.New(config.Activation),
+		Up:         linear.New(config.BottleneckSize, config.Size),
+	}
+}
+
+// Initialize set the Down projection with random values, while the Up projection is left to zeros,
+// so that the adapter is initially the identity function.
+func Initialize(m *Model, rndGen *rand.LockedRand) {
+	initializers.XavierUniform(m.Down.W.Value(), initializers.Gain(m.Config.Activation), rndGen)
+	initializers.Zeros(m.Up.W.Value())
+	initializers.Zeros(m.Up.B.Value())
+}
+
+// Processor implements the nn.Processor interface for an adapter Model.
+type Processor struct {
+	nn.BaseProcessor
+	down       nn.Processor
+	activation nn.Processor
+	up         nn.Processor
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Model) NewProc(ctx nn.Context) nn.Processor {
+	return &Processor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: false,
+		},
+		down:       m.Down.NewProc(ctx),
+		activation: m.Activation.NewProc(ctx),
+		up:         m.Up.NewProc(ctx),
+	}
+}
+
+// Forward performs the forward step for each input and returns the result.
+func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
+	hs := p.up.Forward(p.activation.Forward(p.down.Forward(xs...)...)...)
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = p.Graph.Add(x, hs[i])
+	}
+	return ys
+}
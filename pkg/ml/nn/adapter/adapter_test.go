@@ -0,0 +1,64 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package adapter
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestModel_Forward(t *testing.T) {
+	model := New(Config{Size: 3, BottleneckSize: 2, Activation: ag.OpReLU})
+	model.Down.W.Value().SetData([]float64{
+		0.5, -0.1, 0.2,
+		-0.3, 0.4, 0.1,
+	})
+	model.Down.B.Value().SetData([]float64{0.1, -0.2})
+	model.Up.W.Value().SetData([]float64{
+		1.0, 0.5,
+		-1.0, 0.0,
+		0.2, 0.3,
+	})
+	model.Up.B.Value().SetData([]float64{0.0, 0.1, 0.0})
+
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, 0.5, -1.0}), true)
+	y := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).Forward(x)[0]
+
+	// Down(x) = [0.35, -0.6], ReLU = [0.35, 0], Up = [0.35, -0.25, 0.07]
+	if !floats.EqualApprox(y.Value().Data(), []float64{1.35, 0.25, -0.93}, 1.0e-06) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	g.Backward(y, ag.OutputGrad(mat.NewVecDense([]float64{1.0, 1.0, 1.0})))
+
+	if !floats.EqualApprox(model.Up.B.Grad().Data(), []float64{1.0, 1.0, 1.0}, 1.0e-06) {
+		t.Error("Up B doesn't match the expected values")
+	}
+	if !floats.EqualApprox(x.Grad().Data(), []float64{1.1, 0.98, 1.04}, 1.0e-06) {
+		t.Error("The input gradients don't match the expected values")
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	model := New(Config{Size: 4, BottleneckSize: 2, Activation: ag.OpGELU})
+	Initialize(model, rand.NewLockedRand(42))
+
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{0.1, -0.2, 0.3, 0.4}), false)
+	y := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(x)[0]
+
+	if model.Down.W.Value().Norm(2) == 0 {
+		t.Error("The down projection is expected to be initialized")
+	}
+	if !floats.EqualApprox(y.Value().Data(), x.Value().Data(), 0) {
+		t.Error("The adapter is expected to be initially the identity function")
+	}
+}
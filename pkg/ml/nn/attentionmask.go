@@ -0,0 +1,72 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"math"
+)
+
+// An attention mask is an additive matrix of size (number of queries × number of keys) which is
+// summed to the attention scores before the softmax: the element (i, j) is added to the score of
+// the i-th query for the j-th key. A value of 0 leaves the score unchanged, while -Inf prevents
+// the query from attending to the key. A nil mask does not mask anything.
+//
+// The functions below build the most common masks; they can be combined with CombineMasks.
+// Make sure that every query can attend to at least one key, otherwise its attention is undefined.
+
+// CausalMask returns an attention mask which prevents each query from attending to the keys
+// in subsequent positions.
+func CausalMask(numOfQueries, numOfKeys int) mat.Matrix {
+	return BooleanMask(numOfQueries, numOfKeys, func(i, j int) bool {
+		return j > i
+	})
+}
+
+// KeyPaddingMask returns an attention mask which prevents all queries from attending to the
+// keys marked as padding (true) in the paddingMask. It is useful to process batches of
+// sequences of unequal lengths.
+func KeyPaddingMask(numOfQueries int, paddingMask []bool) mat.Matrix {
+	return BooleanMask(numOfQueries, len(paddingMask), func(_, j int) bool {
+		return paddingMask[j]
+	})
+}
+
+// BooleanMask returns an attention mask of size numOfQueries × numOfKeys, where the element
+// (i, j) is -Inf if masked(i, j) returns true (the i-th query cannot attend to the j-th key),
+// and 0 otherwise.
+// Arbitrary structured attentions (e.g. restricted to the same segment) can be defined this way.
+func BooleanMask(numOfQueries, numOfKeys int, masked func(i, j int) bool) mat.Matrix {
+	mask := mat.NewEmptyDense(numOfQueries, numOfKeys)
+	negInf := math.Inf(-1)
+	for i := 0; i < numOfQueries; i++ {
+		for j := 0; j < numOfKeys; j++ {
+			if masked(i, j) {
+				mask.Set(i, j, negInf)
+			}
+		}
+	}
+	return mask
+}
+
+// CombineMasks returns the sum of the given attention masks, ignoring the nil ones.
+// It returns nil if all masks are nil. It panics if the masks have different dimensions.
+func CombineMasks(masks ...mat.Matrix) mat.Matrix {
+	var combined mat.Matrix
+	for _, mask := range masks {
+		if mask == nil {
+			continue
+		}
+		if combined == nil {
+			combined = mask.Clone()
+			continue
+		}
+		if !mat.SameDims(combined, mask) {
+			panic("nn: attention masks with not compatible size")
+		}
+		combined.AddInPlace(mask)
+	}
+	return combined
+}
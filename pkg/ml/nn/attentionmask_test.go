@@ -0,0 +1,88 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"gonum.org/v1/gonum/floats"
+	"math"
+	"testing"
+)
+
+func TestCausalMask(t *testing.T) {
+	negInf := math.Inf(-1)
+	mask := CausalMask(2, 3)
+	if !floats.Equal(mask.Data(), []float64{
+		0, negInf, negInf,
+		0, 0, negInf,
+	}) {
+		t.Error("The causal mask doesn't match the expected values")
+	}
+}
+
+func TestKeyPaddingMask(t *testing.T) {
+	negInf := math.Inf(-1)
+	mask := KeyPaddingMask(2, []bool{false, true, false})
+	if !floats.Equal(mask.Data(), []float64{
+		0, negInf, 0,
+		0, negInf, 0,
+	}) {
+		t.Error("The key padding mask doesn't match the expected values")
+	}
+}
+
+func TestCombineMasks(t *testing.T) {
+	if CombineMasks(nil, nil) != nil {
+		t.Error("Expected nil combining nil masks")
+	}
+	negInf := math.Inf(-1)
+	causal := CausalMask(2, 2)
+	mask := CombineMasks(causal, nil, KeyPaddingMask(2, []bool{true, false}))
+	if !floats.Equal(mask.Data(), []float64{
+		negInf, negInf,
+		negInf, 0,
+	}) {
+		t.Error("The combined mask doesn't match the expected values")
+	}
+	if !floats.Equal(causal.Data(), []float64{0, negInf, 0, 0}) {
+		t.Error("CombineMasks must not modify the given masks")
+	}
+}
+
+func TestScaledDotProductAttentionWithMask(t *testing.T) {
+	g := ag.NewGraph()
+	qs := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]float64{0.1, 0.8}), true),
+		g.NewVariable(mat.NewVecDense([]float64{-0.5, 0.3}), true),
+	}
+	ks := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]float64{0.4, -0.2}), true),
+		g.NewVariable(mat.NewVecDense([]float64{0.9, 0.6}), true),
+		g.NewVariable(mat.NewVecDense([]float64{-0.7, 0.2}), true),
+	}
+	vs := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]float64{0.2, 0.3}), true),
+		g.NewVariable(mat.NewVecDense([]float64{-0.6, 0.5}), true),
+		g.NewVariable(mat.NewVecDense([]float64{1.0, -0.4}), true),
+	}
+
+	context, prob := ScaledDotProductAttentionWithMask(g, qs, ks, vs, 1.0, KeyPaddingMask(2, []bool{false, false, true}))
+	expected, _ := ScaledDotProductAttention(g, qs, ks[:2], vs[:2], 1.0, false)
+
+	for i := range qs {
+		if prob[i].Data()[2] != 0 {
+			t.Errorf("Query %d attends to a masked key", i)
+		}
+		if !floats.EqualApprox(context[i].Value().Data(), expected[i].Value().Data(), 1.0e-9) {
+			t.Errorf("The context of query %d doesn't match the expected values", i)
+		}
+	}
+
+	g.Backward(g.ReduceSum(g.Add(context[0], context[1])))
+	if !floats.Equal(vs[2].Grad().Data(), []float64{0, 0}) {
+		t.Error("The masked value must have zero gradients")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package ctc implements the Connectionist Temporal Classification loss and decoders, which allow to train
+and use sequence models (e.g. the recurrent networks of pkg/ml/nn/rec) when the alignment between the
+input steps and the output labels is unknown, as in speech and handwriting recognition.
+
+At each step, the model predicts the log-probabilities of the labels plus a special blank label.
+A labeling is obtained from a path of predictions by merging the repeated labels and then removing the blanks.
+
+Reference: "Connectionist Temporal Classification: Labelling Unsegmented Sequence Data with Recurrent
+Neural Networks" by Alex Graves, Santiago Fernández, Faustino Gomez and Jürgen Schmidhuber (2006)
+(https://www.cs.toronto.edu/~graves/icml_2006.pdf)
+*/
+package ctc
+
+import (
+	"math"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+)
+
+// Loss returns the negative log-likelihood of the target labeling given the sequence of the
+// log-probabilities of the labels at each step, summing the probabilities of all the paths which
+// produce the target. The forward-backward algorithm is computed in log space, to prevent underflows.
+//
+// The log-probabilities are typically the output of a log-softmax. If the sequence is too short to
+// produce the target, the loss is +Inf and the gradients are zero.
+func Loss(g *ag.Graph, logProbs []ag.Node, target []int, blank int) ag.Node {
+	return g.NewOperator(newLossFunction(ag.Operands(logProbs), target, blank), logProbs...)
+}
+
+var _ fn.Function = &lossFunction{}
+
+// lossFunction implements the CTC loss as a fn.Function, computing its gradients analytically.
+type lossFunction struct {
+	xs      []fn.Operand
+	labels  []int // the target with a blank at the beginning, at the end and between each label
+	blank   int
+	alpha   [][]float64
+	beta    [][]float64
+	logProb float64
+}
+
+func newLossFunction(xs []fn.Operand, target []int, blank int) *lossFunction {
+	labels := make([]int, 0, 2*len(target)+1)
+	labels = append(labels, blank)
+	for _, label := range target {
+		labels = append(labels, label, blank)
+	}
+	return &lossFunction{
+		xs:     xs,
+		labels: labels,
+		blank:  blank,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *lossFunction) Forward() mat.Matrix {
+	r.forward()
+	r.backward()
+	return mat.NewScalar(-r.logProb)
+}
+
+// canSkip reports whether the path can move directly from the label s-2 to the label s, skipping the blank
+// between them, which is possible unless they are equal.
+func (r *lossFunction) canSkip(s int) bool {
+	return s >= 2 && r.labels[s] != r.blank && r.labels[s] != r.labels[s-2]
+}
+
+// forward computes the log of the forward variables alpha(t, s), that is the total probability of the
+// paths of the steps 0..t ending in the extended label s.
+func (r *lossFunction) forward() {
+	steps, size := len(r.xs), len(r.labels)
+	r.alpha = newLogMatrix(steps, size)
+	if steps == 0 {
+		r.logProb = math.Inf(-1)
+		return
+	}
+	y := r.xs[0].Value()
+	r.alpha[0][0] = y.AtVec(r.labels[0])
+	if size > 1 {
+		r.alpha[0][1] = y.AtVec(r.labels[1])
+	}
+	for t := 1; t < steps; t++ {
+		y := r.xs[t].Value()
+		for s := 0; s < size; s++ {
+			a := r.alpha[t-1][s]
+			if s >= 1 {
+				a = logAdd(a, r.alpha[t-1][s-1])
+			}
+			if r.canSkip(s) {
+				a = logAdd(a, r.alpha[t-1][s-2])
+			}
+			r.alpha[t][s] = a + y.AtVec(r.labels[s])
+		}
+	}
+	r.logProb = r.alpha[steps-1][size-1]
+	if size > 1 {
+		r.logProb = logAdd(r.logProb, r.alpha[steps-1][size-2])
+	}
+}
+
+// backward computes the log of the backward variables beta(t, s), that is the total probability of the
+// paths of the steps t..T-1 starting from the extended label s.
+func (r *lossFunction) backward() {
+	steps, size := len(r.xs), len(r.labels)
+	r.beta = newLogMatrix(steps, size)
+	if steps == 0 {
+		return
+	}
+	y := r.xs[steps-1].Value()
+	r.beta[steps-1][size-1] = y.AtVec(r.labels[size-1])
+	if size > 1 {
+		r.beta[steps-1][size-2] = y.AtVec(r.labels[size-2])
+	}
+	for t := steps - 2; t >= 0; t-- {
+		y := r.xs[t].Value()
+		for s := 0; s < size; s++ {
+			b := r.beta[t+1][s]
+			if s+1 < size {
+				b = logAdd(b, r.beta[t+1][s+1])
+			}
+			if s+2 < size && r.canSkip(s+2) {
+				b = logAdd(b, r.beta[t+1][s+2])
+			}
+			r.beta[t][s] = b + y.AtVec(r.labels[s])
+		}
+	}
+}
+
+// Backward computes the backward pass.
+// The gradient of the loss respect to the log-probability of the label k at the step t is the opposite
+// of the posterior probability of passing through k at t:
+//    ∂L/∂log(y(t, k)) = -Σ_{s: l(s) = k} alpha(t, s) beta(t, s) / (y(t, k) p)
+func (r *lossFunction) Backward(gy mat.Matrix) {
+	feasible := !math.IsInf(r.logProb, -1)
+	for t, x := range r.xs {
+		if !x.RequiresGrad() {
+			continue
+		}
+		y := x.Value()
+		gx := mat.NewEmptyVecDense(y.Size())
+		if feasible {
+			for s, label := range r.labels {
+				occupancy := math.Exp(r.alpha[t][s] + r.beta[t][s] - y.AtVec(label) - r.logProb)
+				gx.SetVec(label, gx.AtVec(label)-occupancy)
+			}
+			gx.ProdScalarInPlace(gy.Scalar())
+		}
+		x.PropagateGrad(gx)
+		mat.ReleaseDense(gx)
+	}
+}
+
+func newLogMatrix(rows, columns int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, columns)
+		for j := range m[i] {
+			m[i][j] = math.Inf(-1)
+		}
+	}
+	return m
+}
+
+// logAdd returns log(exp(a) + exp(b)) without underflows.
+func logAdd(a, b float64) float64 {
+	if math.IsInf(a, -1) {
+		return b
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log1p(math.Exp(b-a))
+}
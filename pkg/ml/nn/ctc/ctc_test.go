@@ -0,0 +1,208 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ctc
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"gonum.org/v1/gonum/floats"
+	"math"
+	"reflect"
+	"testing"
+)
+
+const blank = 0
+
+func TestLoss(t *testing.T) {
+	for _, target := range [][]int{{1, 2}, {1, 1}, {2}, {}} {
+		g := ag.NewGraph()
+		logits := newTestLogits(g, 4, 3)
+		logProbs := make([]ag.Node, len(logits))
+		for i, x := range logits {
+			logProbs[i] = g.Log(g.Softmax(x))
+		}
+		loss := Loss(g, logProbs, target, blank)
+
+		probs := make([][]float64, len(logProbs))
+		for i, x := range logProbs {
+			probs[i] = make([]float64, x.Value().Size())
+			for k, v := range x.Value().Data() {
+				probs[i][k] = math.Exp(v)
+			}
+		}
+		p, occupancy := bruteForce(probs, target)
+		if !floats.EqualWithinAbs(loss.ScalarValue(), -math.Log(p), 1.0e-9) {
+			t.Errorf("Target %v: expected loss %g, got %g", target, -math.Log(p), loss.ScalarValue())
+		}
+
+		g.Backward(loss)
+		// the gradients of the logits are the probabilities minus the posterior occupancies
+		for i, x := range logits {
+			expected := make([]float64, len(probs[i]))
+			for k := range expected {
+				expected[k] = probs[i][k] - occupancy[i][k]/p
+			}
+			if !floats.EqualApprox(x.Grad().Data(), expected, 1.0e-9) {
+				t.Errorf("Target %v: expected gradients %v at step %d, got %v", target, expected, i, x.Grad().Data())
+			}
+		}
+	}
+}
+
+func TestLoss_Infeasible(t *testing.T) {
+	g := ag.NewGraph()
+	logProbs := newTestLogits(g, 2, 3)
+	loss := Loss(g, logProbs, []int{1, 1}, blank) // requires at least 3 steps
+	if !math.IsInf(loss.ScalarValue(), 1) {
+		t.Errorf("Expected an infinite loss, got %g", loss.ScalarValue())
+	}
+	g.Backward(loss)
+	for _, x := range logProbs {
+		if !floats.Equal(x.Grad().Data(), []float64{0, 0, 0}) {
+			t.Errorf("Expected zero gradients, got %v", x.Grad().Data())
+		}
+	}
+}
+
+func TestGreedyDecode(t *testing.T) {
+	g := ag.NewGraph()
+	logProbs := newLogProbs(g, [][]float64{
+		{0.1, 0.8, 0.1},
+		{0.1, 0.8, 0.1},
+		{0.8, 0.1, 0.1},
+		{0.1, 0.8, 0.1},
+		{0.1, 0.1, 0.8},
+		{0.8, 0.1, 0.1},
+	})
+	if labels := GreedyDecode(logProbs, blank); !reflect.DeepEqual(labels, []int{1, 1, 2}) {
+		t.Errorf("Expected [1 1 2], got %v", labels)
+	}
+}
+
+func TestPrefixBeamSearch(t *testing.T) {
+	g := ag.NewGraph()
+	// the most probable path is blank-blank, but the most probable labeling is [1]
+	logProbs := newLogProbs(g, [][]float64{
+		{0.6, 0.4},
+		{0.6, 0.4},
+	})
+	if labels := GreedyDecode(logProbs, blank); len(labels) != 0 {
+		t.Errorf("Expected an empty greedy labeling, got %v", labels)
+	}
+	hypotheses := PrefixBeamSearch(logProbs, blank, 3)
+	if !reflect.DeepEqual(hypotheses[0].Labels, []int{1}) {
+		t.Fatalf("Expected [1], got %v", hypotheses[0].Labels)
+	}
+	if !floats.EqualWithinAbs(hypotheses[0].LogProb, math.Log(0.64), 1.0e-9) {
+		t.Errorf("Expected log-probability %g, got %g", math.Log(0.64), hypotheses[0].LogProb)
+	}
+}
+
+func TestPrefixBeamSearch_MatchesLoss(t *testing.T) {
+	g := ag.NewGraph()
+	logits := newTestLogits(g, 5, 3)
+	logProbs := make([]ag.Node, len(logits))
+	for i, x := range logits {
+		logProbs[i] = g.Log(g.Softmax(x))
+	}
+	// with a large enough beam the search is exhaustive
+	for _, h := range PrefixBeamSearch(logProbs, blank, 1000) {
+		loss := Loss(g, logProbs, h.Labels, blank)
+		if !floats.EqualWithinAbs(h.LogProb, -loss.ScalarValue(), 1.0e-9) {
+			t.Errorf("Labels %v: expected log-probability %g, got %g", h.Labels, -loss.ScalarValue(), h.LogProb)
+		}
+	}
+}
+
+func TestLabelsKey(t *testing.T) {
+	// surrogates and values beyond the Unicode range must not collide
+	labelings := [][]int{{0xD800}, {0xDFFF}, {0x10FFFF}, {0x110000}, {0x110001}, {1 << 40}, {0, 0xD800}}
+	keys := make(map[string][]int)
+	for _, labels := range labelings {
+		key := labelsKey(labels)
+		if other, ok := keys[key]; ok {
+			t.Errorf("The labels %v and %v have the same key", labels, other)
+		}
+		keys[key] = labels
+	}
+	sorted := [][]int{{}, {1}, {1, 0}, {1, 2}, {1, 300}, {2}, {0x110000}}
+	for i := 1; i < len(sorted); i++ {
+		if !(labelsKey(sorted[i-1]) < labelsKey(sorted[i])) {
+			t.Errorf("Expected the key of %v to precede the key of %v", sorted[i-1], sorted[i])
+		}
+	}
+}
+
+func newTestLogits(g *ag.Graph, steps, size int) []ag.Node {
+	rndGen := rand.NewLockedRand(42)
+	xs := make([]ag.Node, steps)
+	for i := range xs {
+		x := mat.NewEmptyVecDense(size)
+		initializers.Uniform(x, -2.0, 2.0, rndGen)
+		xs[i] = g.NewVariable(x, true)
+	}
+	return xs
+}
+
+func newLogProbs(g *ag.Graph, probs [][]float64) []ag.Node {
+	xs := make([]ag.Node, len(probs))
+	for i, p := range probs {
+		logProbs := make([]float64, len(p))
+		for k, v := range p {
+			logProbs[k] = math.Log(v)
+		}
+		xs[i] = g.NewVariable(mat.NewVecDense(logProbs), true)
+	}
+	return xs
+}
+
+// bruteForce returns the probability of the target, enumerating all the paths, and the total probability
+// of the paths producing the target which pass through each label at each step.
+func bruteForce(probs [][]float64, target []int) (float64, [][]float64) {
+	steps, size := len(probs), len(probs[0])
+	occupancy := make([][]float64, steps)
+	for i := range occupancy {
+		occupancy[i] = make([]float64, size)
+	}
+	total := 0.0
+	path := make([]int, steps)
+	var visit func(t int)
+	visit = func(t int) {
+		if t == steps {
+			if !reflect.DeepEqual(collapse(path), target) {
+				return
+			}
+			p := 1.0
+			for i, k := range path {
+				p *= probs[i][k]
+			}
+			total += p
+			for i, k := range path {
+				occupancy[i][k] += p
+			}
+			return
+		}
+		for k := 0; k < size; k++ {
+			path[t] = k
+			visit(t + 1)
+		}
+	}
+	visit(0)
+	return total, occupancy
+}
+
+func collapse(path []int) []int {
+	labels := make([]int, 0)
+	prev := blank
+	for _, k := range path {
+		if k != blank && k != prev {
+			labels = append(labels, k)
+		}
+		prev = k
+	}
+	return labels
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ctc
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/nlpodyssey/spago/pkg/mat/f64utils"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+)
+
+// GreedyDecode returns the labeling of the most probable path, taking the most probable label at each step,
+// then merging the repeated labels and removing the blanks. It is fast, but the labeling of the most
+// probable path is not necessarily the most probable labeling.
+func GreedyDecode(logProbs []ag.Node, blank int) []int {
+	labels := make([]int, 0)
+	prev := blank
+	for _, x := range logProbs {
+		label := f64utils.ArgMax(x.Value().Data())
+		if label != blank && label != prev {
+			labels = append(labels, label)
+		}
+		prev = label
+	}
+	return labels
+}
+
+// Hypothesis is a labeling with its log-probability, found by PrefixBeamSearch.
+type Hypothesis struct {
+	Labels  []int
+	LogProb float64
+}
+
+// prefix is a labeling under construction, with the log-probabilities of the paths
+// producing it ending in a blank and in a non-blank label.
+type prefix struct {
+	labels   []int
+	blank    float64
+	nonBlank float64
+}
+
+func (p *prefix) logProb() float64 {
+	return logAdd(p.blank, p.nonBlank)
+}
+
+func (p *prefix) last() int {
+	if len(p.labels) == 0 {
+		return -1
+	}
+	return p.labels[len(p.labels)-1]
+}
+
+// prefixes is a set of prefixes indexed by their labeling.
+type prefixes map[string]*prefix
+
+func (ps prefixes) get(labels []int) *prefix {
+	key := labelsKey(labels)
+	if p, ok := ps[key]; ok {
+		return p
+	}
+	p := &prefix{labels: labels, blank: math.Inf(-1), nonBlank: math.Inf(-1)}
+	ps[key] = p
+	return p
+}
+
+// labelsKey returns a string which uniquely identifies the labels, encoding each of them in 8 bytes
+// (big-endian), so that the keys of non-negative labels sort in the same order of the labels.
+func labelsKey(labels []int) string {
+	key := make([]byte, 8*len(labels))
+	for i, label := range labels {
+		binary.BigEndian.PutUint64(key[8*i:], uint64(label))
+	}
+	return string(key)
+}
+
+// PrefixBeamSearch returns the most probable labelings, sorted by decreasing probability, searching the
+// space of the labelings rather than the space of the paths: at each step, only the beamSize most
+// probable prefixes are extended, but the probability of each prefix sums all the paths producing it.
+func PrefixBeamSearch(logProbs []ag.Node, blank, beamSize int) []Hypothesis {
+	beam := []*prefix{{labels: []int{}, blank: 0.0, nonBlank: math.Inf(-1)}}
+	for _, x := range logProbs {
+		y := x.Value().Data()
+		next := make(prefixes)
+		for _, p := range beam {
+			for label, logProb := range y {
+				if label == blank {
+					n := next.get(p.labels)
+					n.blank = logAdd(n.blank, p.logProb()+logProb)
+					continue
+				}
+				extended := next.get(appendLabel(p.labels, label))
+				if label == p.last() {
+					// a repeated label extends the prefix only if a blank separates them
+					extended.nonBlank = logAdd(extended.nonBlank, p.blank+logProb)
+					n := next.get(p.labels)
+					n.nonBlank = logAdd(n.nonBlank, p.nonBlank+logProb)
+				} else {
+					extended.nonBlank = logAdd(extended.nonBlank, p.logProb()+logProb)
+				}
+			}
+		}
+		beam = beam[:0]
+		for _, p := range next {
+			beam = append(beam, p)
+		}
+		sortPrefixes(beam)
+		if len(beam) > beamSize {
+			beam = beam[:beamSize]
+		}
+	}
+	hypotheses := make([]Hypothesis, len(beam))
+	for i, p := range beam {
+		hypotheses[i] = Hypothesis{Labels: p.labels, LogProb: p.logProb()}
+	}
+	return hypotheses
+}
+
+func appendLabel(labels []int, label int) []int {
+	extended := make([]int, len(labels)+1)
+	copy(extended, labels)
+	extended[len(labels)] = label
+	return extended
+}
+
+// sortPrefixes sorts the prefixes by decreasing probability, breaking ties by labeling for determinism.
+func sortPrefixes(ps []*prefix) {
+	sort.Slice(ps, func(i, j int) bool {
+		pi, pj := ps[i].logProb(), ps[j].logProb()
+		if pi != pj {
+			return pi > pj
+		}
+		return labelsKey(ps[i].labels) < labelsKey(ps[j].labels)
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gnn_test
+
+import (
+	"fmt"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/f64utils"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/gnn/gcn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+)
+
+// nodeClassifier is a two-layer graph convolutional network.
+type nodeClassifier struct {
+	Layer1 *gcn.Model
+	Layer2 *gcn.Model
+}
+
+func (m *nodeClassifier) NewProc(ctx nn.Context) nn.Processor {
+	panic("not used in this example")
+}
+
+func (m *nodeClassifier) forward(g *ag.Graph, adjacency mat.Matrix, xs []ag.Node) []ag.Node {
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+	hs := m.Layer1.NewProc(ctx).(*gcn.Processor).ForwardWithAdjacency(adjacency, xs...)
+	for i, h := range hs {
+		hs[i] = g.ReLU(h)
+	}
+	return m.Layer2.NewProc(ctx).(*gcn.Processor).ForwardWithAdjacency(adjacency, hs...)
+}
+
+// Example_nodeClassification trains a GCN to classify the nodes of a synthetic graph made of two
+// communities, whose node features are too noisy to classify the nodes on their own, knowing the
+// labels of just two nodes per community.
+func Example_nodeClassification() {
+	const numOfNodes, numOfFeatures = 40, 4
+	rndGen := rand.NewLockedRand(42)
+
+	// the nodes are densely connected within their community and sparsely across
+	labels := make([]int, numOfNodes)
+	adjacency := mat.NewEmptyDense(numOfNodes, numOfNodes)
+	for i := 0; i < numOfNodes; i++ {
+		labels[i] = i % 2
+		for j := 0; j < i; j++ {
+			p := 0.02
+			if i%2 == j%2 {
+				p = 0.3
+			}
+			if rndGen.Float64() < p {
+				adjacency.Set(i, j, 1.0)
+				adjacency.Set(j, i, 1.0)
+			}
+		}
+	}
+	// the first feature is weakly correlated to the community, the others are noise
+	features := make([]mat.Matrix, numOfNodes)
+	for i := range features {
+		data := make([]float64, numOfFeatures)
+		for k := range data {
+			data[k] = rndGen.NormFloat64()
+		}
+		data[0] += float64(2*labels[i]-1) * 0.5
+		features[i] = mat.NewVecDense(data)
+	}
+	labeled := []int{0, 1, 2, 3}
+
+	model := &nodeClassifier{
+		Layer1: gcn.New(numOfFeatures, 8),
+		Layer2: gcn.New(8, 2),
+	}
+	initializers.XavierUniform(model.Layer1.W.Value(), 1.0, rndGen)
+	initializers.XavierUniform(model.Layer2.W.Value(), 1.0, rndGen)
+	optimizer := gd.NewOptimizer(adam.New(adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8)), nn.NewDefaultParamsIterator(model))
+
+	predict := func(g *ag.Graph) []ag.Node {
+		xs := make([]ag.Node, numOfNodes)
+		for i, x := range features {
+			xs[i] = g.NewVariable(x, false)
+		}
+		return model.forward(g, adjacency, xs)
+	}
+
+	for epoch := 0; epoch < 100; epoch++ {
+		g := ag.NewGraph()
+		ys := predict(g)
+		var loss ag.Node
+		for _, i := range labeled {
+			l := losses.CrossEntropy(g, ys[i], labels[i])
+			if loss == nil {
+				loss = l
+			} else {
+				loss = g.Add(loss, l)
+			}
+		}
+		g.Backward(loss)
+		optimizer.Optimize()
+		optimizer.IncExample()
+	}
+
+	correct := 0
+	for i, y := range predict(ag.NewGraph()) {
+		if f64utils.ArgMax(y.Value().Data()) == labels[i] {
+			correct++
+		}
+	}
+	fmt.Printf("accuracy: %.2f\n", float64(correct)/numOfNodes)
+	// Output: accuracy: 1.00
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gat implements the graph attention layer introduced by Petar Veličković, Guillem Cucurull et al. in
+// "Graph Attention Networks", 2018 (https://arxiv.org/pdf/1710.10903.pdf).
+//
+// For each head, each node attends to its neighbors and to itself:
+//     h(j) = W x(j)
+//     e(i,j) = LeakyReLU(aDst·h(i) + aSrc·h(j))
+//     α(i,j) = softmax over j ∈ N(i) ∪ {i} of e(i,j)
+//     y(i) = Σj α(i,j) h(j)
+// The outputs of the heads are either concatenated or averaged (usually in the last layer).
+// The edge weights are ignored. The non-linearity is left to the subsequent layers.
+package gat
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/gnn"
+)
+
+var (
+	_ nn.Model     = &Model{}
+	_ nn.Processor = &Processor{}
+)
+
+// Config provides configuration settings for a GAT Model.
+type Config struct {
+	InputSize int
+	// OutputSize is the output size of each head.
+	OutputSize int
+	NumOfHeads int
+	// Average sets whether to average the outputs of the heads instead of concatenating them.
+	Average bool
+	// NegativeSlope is the slope of the LeakyReLU for negative inputs (usually 0.2).
+	NegativeSlope float64
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	Config
+	// W contains the weights of each head.
+	W []*nn.Param `type:"weights"`
+	// ASrc contains the attention vector of each head applied to the neighbors.
+	ASrc []*nn.Param `type:"weights"`
+	// ADst contains the attention vector of each head applied to the receiving node.
+	ADst []*nn.Param `type:"weights"`
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	w := make([]*nn.Param, config.NumOfHeads)
+	aSrc := make([]*nn.Param, config.NumOfHeads)
+	aDst := make([]*nn.Param, config.NumOfHeads)
+	for i := 0; i < config.NumOfHeads; i++ {
+		w[i] = nn.NewParam(mat.NewEmptyDense(config.OutputSize, config.InputSize))
+		aSrc[i] = nn.NewParam(mat.NewEmptyVecDense(config.OutputSize))
+		aDst[i] = nn.NewParam(mat.NewEmptyVecDense(config.OutputSize))
+	}
+	return &Model{
+		Config: config,
+		W:      w,
+		ASrc:   aSrc,
+		ADst:   aDst,
+	}
+}
+
+// Processor implements the nn.Processor interface for a GAT Model.
+type Processor struct {
+	nn.BaseProcessor
+	Config
+	w    []ag.Node
+	aSrc []ag.Node
+	aDst []ag.Node
+	// Attention contains, for each head, the attention weights of each node over its incoming
+	// edges of the last forward step (see AttentionEdges).
+	Attention [][]mat.Matrix
+	// AttentionEdges contains, for each node, the indices of the nodes it attends to (itself first,
+	// then its neighbors), in the same order of the attention weights.
+	AttentionEdges [][]int
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Model) NewProc(ctx nn.Context) nn.Processor {
+	g := ctx.Graph
+	w := make([]ag.Node, m.NumOfHeads)
+	aSrc := make([]ag.Node, m.NumOfHeads)
+	aDst := make([]ag.Node, m.NumOfHeads)
+	for i := 0; i < m.NumOfHeads; i++ {
+		w[i] = g.NewWrap(m.W[i])
+		aSrc[i] = g.NewWrap(m.ASrc[i])
+		aDst[i] = g.NewWrap(m.ADst[i])
+	}
+	return &Processor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		Config: m.Config,
+		w:      w,
+		aSrc:   aSrc,
+		aDst:   aDst,
+	}
+}
+
+// Forward is not implemented for GAT (it always panics).
+// You should use ForwardWithAdjacency instead.
+func (p *Processor) Forward(_ ...ag.Node) []ag.Node {
+	panic("gat: Forward() not implemented; use ForwardWithAdjacency() instead.")
+}
+
+// ForwardWithAdjacency performs the forward step for the features xs of each node of the graph
+// described by the adjacency matrix (see package gnn), and returns the new features of each node.
+func (p *Processor) ForwardWithAdjacency(adjacency mat.Matrix, xs ...ag.Node) []ag.Node {
+	gnn.CheckGraph(len(xs), adjacency)
+	p.AttentionEdges = make([][]int, len(xs))
+	for i, edges := range gnn.Neighbors(adjacency) {
+		p.AttentionEdges[i] = append(p.AttentionEdges[i], i)
+		for _, edge := range edges {
+			if edge.Node != i {
+				p.AttentionEdges[i] = append(p.AttentionEdges[i], edge.Node)
+			}
+		}
+	}
+	p.Attention = make([][]mat.Matrix, p.NumOfHeads)
+	heads := make([][]ag.Node, p.NumOfHeads)
+	for h := range heads {
+		heads[h] = p.head(h, xs)
+	}
+	ys := make([]ag.Node, len(xs))
+	for i := range ys {
+		buf := make([]ag.Node, p.NumOfHeads)
+		for h := range heads {
+			buf[h] = heads[h][i]
+		}
+		if p.Average {
+			ys[i] = p.Graph.Mean(buf)
+		} else {
+			ys[i] = p.Graph.Concat(buf...)
+		}
+	}
+	return ys
+}
+
+// head returns the output of the h-th head for each node.
+func (p *Processor) head(h int, xs []ag.Node) []ag.Node {
+	g := p.Graph
+	hs := make([]ag.Node, len(xs))
+	srcScores := make([]ag.Node, len(xs))
+	for j, x := range xs {
+		hs[j] = g.Mul(p.w[h], x)
+		srcScores[j] = g.Dot(p.aSrc[h], hs[j])
+	}
+	slope := g.Constant(p.NegativeSlope)
+	p.Attention[h] = make([]mat.Matrix, len(xs))
+	ys := make([]ag.Node, len(xs))
+	for i, nodes := range p.AttentionEdges {
+		dstScore := g.Dot(p.aDst[h], hs[i])
+		scores := make([]ag.Node, len(nodes))
+		values := make([]ag.Node, len(nodes))
+		for k, j := range nodes {
+			scores[k] = srcScores[j]
+			values[k] = hs[j]
+		}
+		attention := g.Softmax(g.LeakyReLU(g.AddScalar(g.Concat(scores...), dstScore), slope))
+		ys[i] = g.Mul(g.T(g.Stack(values...)), attention)
+		p.Attention[h][i] = attention.Value()
+	}
+	return ys
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gat
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestModel_ForwardWithAdjacency(t *testing.T) {
+	model := New(Config{InputSize: 2, OutputSize: 2, NumOfHeads: 2, NegativeSlope: 0.2})
+	for _, w := range model.W {
+		w.Value().SetData([]float64{1.0, 0.0, 0.0, 1.0})
+	}
+	// star graph centered in 0
+	adjacency := mat.NewDense(3, 3, []float64{
+		0, 1, 1,
+		1, 0, 0,
+		1, 0, 0,
+	})
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+	xs := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]float64{0.3, 0.6}), true),
+		g.NewVariable(mat.NewVecDense([]float64{0.9, -0.3}), true),
+		g.NewVariable(mat.NewVecDense([]float64{-0.6, 0.3}), true),
+	}
+	ys := proc.ForwardWithAdjacency(adjacency, xs...)
+
+	// with zero attention vectors, each node averages itself and its neighbors
+	if !floats.EqualApprox(ys[0].Value().Data(), []float64{0.2, 0.2, 0.2, 0.2}, 1.0e-9) {
+		t.Error("The output of node 0 doesn't match the expected values")
+	}
+	if !floats.EqualApprox(ys[1].Value().Data(), []float64{0.6, 0.15, 0.6, 0.15}, 1.0e-9) {
+		t.Error("The output of node 1 doesn't match the expected values")
+	}
+	if len(proc.AttentionEdges[0]) != 3 || len(proc.AttentionEdges[2]) != 2 {
+		t.Error("The attention edges don't match the expected ones")
+	}
+
+	g.Backward(g.ReduceSum(g.Square(g.Sum(ys...))))
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if !param.HasGrad() {
+			t.Errorf("Param %s has no gradients", param.Name())
+		}
+	})
+}
+
+func TestModel_Average(t *testing.T) {
+	model := New(Config{InputSize: 2, OutputSize: 3, NumOfHeads: 4, Average: true})
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	x := g.NewVariable(mat.NewVecDense([]float64{0.3, 0.6}), false)
+	if ys := proc.ForwardWithAdjacency(mat.NewEmptyDense(1, 1), x); ys[0].Value().Size() != 3 {
+		t.Error("Expected the average of the heads")
+	}
+}
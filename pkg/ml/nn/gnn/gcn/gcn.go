@@ -0,0 +1,103 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gcn implements the graph convolutional layer introduced by Thomas N. Kipf and Max Welling in
+// "Semi-Supervised Classification with Graph Convolutional Networks", 2017 (https://arxiv.org/pdf/1609.02907.pdf).
+//
+// Each node aggregates the linear transformation of the features of its neighbors and of itself,
+// normalized by the degrees of the nodes:
+//     y(i) = Σj Â(i,j) / sqrt(deg(i) deg(j)) W x(j) + b
+// where Â = A + I is the adjacency matrix with self-loops and deg(i) = Σj Â(i,j). The diagonal of A is ignored,
+// so that each node has a self-loop of weight 1.
+// The non-linearity is left to the subsequent layers.
+package gcn
+
+import (
+	"math"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/gnn"
+)
+
+var (
+	_ nn.Model     = &Model{}
+	_ nn.Processor = &Processor{}
+)
+
+// Model contains the serializable parameters.
+type Model struct {
+	W *nn.Param `type:"weights"`
+	B *nn.Param `type:"biases"`
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(in, out int) *Model {
+	return &Model{
+		W: nn.NewParam(mat.NewEmptyDense(out, in)),
+		B: nn.NewParam(mat.NewEmptyVecDense(out)),
+	}
+}
+
+// Processor implements the nn.Processor interface for a GCN Model.
+type Processor struct {
+	nn.BaseProcessor
+	w ag.Node
+	b ag.Node
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Model) NewProc(ctx nn.Context) nn.Processor {
+	return &Processor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		w: ctx.Graph.NewWrap(m.W),
+		b: ctx.Graph.NewWrap(m.B),
+	}
+}
+
+// Forward is not implemented for GCN (it always panics).
+// You should use ForwardWithAdjacency instead.
+func (p *Processor) Forward(_ ...ag.Node) []ag.Node {
+	panic("gcn: Forward() not implemented; use ForwardWithAdjacency() instead.")
+}
+
+// ForwardWithAdjacency performs the forward step for the features xs of each node of the graph
+// described by the adjacency matrix (see package gnn), and returns the new features of each node.
+func (p *Processor) ForwardWithAdjacency(adjacency mat.Matrix, xs ...ag.Node) []ag.Node {
+	gnn.CheckGraph(len(xs), adjacency)
+	g := p.Graph
+	neighbors := gnn.Neighbors(adjacency)
+	degrees := make([]float64, len(xs))
+	for i, edges := range neighbors {
+		degrees[i] = 1.0 // self-loop
+		for _, edge := range edges {
+			if edge.Node != i { // the self-loop is added once, even if the adjacency matrix has it
+				degrees[i] += edge.Weight
+			}
+		}
+	}
+	hs := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		hs[i] = g.Mul(p.w, x)
+	}
+	ys := make([]ag.Node, len(xs))
+	for i, edges := range neighbors {
+		y := g.ProdScalar(hs[i], g.Constant(1.0/degrees[i]))
+		for _, edge := range edges {
+			if edge.Node == i {
+				continue
+			}
+			norm := edge.Weight / math.Sqrt(degrees[i]*degrees[edge.Node])
+			y = g.Add(y, g.ProdScalar(hs[edge.Node], g.Constant(norm)))
+		}
+		ys[i] = g.Add(y, p.b)
+	}
+	return ys
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestModel_ForwardWithAdjacency(t *testing.T) {
+	model := New(1, 1)
+	model.W.Value().SetData([]float64{2.0})
+	model.B.Value().SetData([]float64{0.1})
+
+	// path graph 0 - 1 - 2
+	adjacency := mat.NewDense(3, 3, []float64{
+		0, 1, 0,
+		1, 0, 1,
+		0, 1, 0,
+	})
+	expected := []float64{2.732993, 4.699319, 4.732993}
+
+	for _, adj := range []mat.Matrix{adjacency, mat.NewSparse(3, 3, adjacency.Data())} {
+		g := ag.NewGraph()
+		proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+		xs := []ag.Node{
+			g.NewVariable(mat.NewScalar(1.0), true),
+			g.NewVariable(mat.NewScalar(2.0), true),
+			g.NewVariable(mat.NewScalar(3.0), true),
+		}
+		ys := proc.ForwardWithAdjacency(adj, xs...)
+		for i, y := range ys {
+			if !floats.EqualApprox(y.Value().Data(), []float64{expected[i]}, 1.0e-6) {
+				t.Errorf("The output of node %d doesn't match the expected value", i)
+			}
+		}
+		g.Backward(g.Sum(ys...))
+		if !floats.EqualApprox(model.B.Grad().Data(), []float64{3.0}, 1.0e-6) {
+			t.Error("The bias gradients don't match the expected values")
+		}
+		nn.ZeroGrad(model)
+	}
+}
+
+func TestModel_ForwardWithSelfLoops(t *testing.T) {
+	model := New(1, 1)
+	model.W.Value().SetData([]float64{2.0})
+	model.B.Value().SetData([]float64{0.1})
+
+	// path graph 0 - 1 - 2, with the self-loops already in the adjacency matrix
+	adjacency := mat.NewDense(3, 3, []float64{
+		1, 1, 0,
+		1, 1, 1,
+		0, 1, 1,
+	})
+	expected := []float64{2.732993, 4.699319, 4.732993}
+
+	for _, adj := range []mat.Matrix{adjacency, mat.NewSparse(3, 3, adjacency.Data())} {
+		g := ag.NewGraph()
+		proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+		xs := []ag.Node{
+			g.NewVariable(mat.NewScalar(1.0), false),
+			g.NewVariable(mat.NewScalar(2.0), false),
+			g.NewVariable(mat.NewScalar(3.0), false),
+		}
+		for i, y := range proc.ForwardWithAdjacency(adj, xs...) {
+			if !floats.EqualApprox(y.Value().Data(), []float64{expected[i]}, 1.0e-6) {
+				t.Errorf("The output of node %d doesn't match the expected value", i)
+			}
+		}
+	}
+}
+
+func TestModel_ForwardWithNegativeWeights(t *testing.T) {
+	model := New(1, 1)
+	adjacency := mat.NewDense(2, 2, []float64{
+		0, -1,
+		-1, 0,
+	})
+	for _, adj := range []mat.Matrix{adjacency, mat.NewSparse(2, 2, adjacency.Data())} {
+		g := ag.NewGraph()
+		proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+		xs := []ag.Node{g.NewScalar(1.0), g.NewScalar(2.0)}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected a panic with negative weights")
+				}
+			}()
+			proc.ForwardWithAdjacency(adj, xs...)
+		}()
+	}
+}
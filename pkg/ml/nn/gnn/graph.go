@@ -0,0 +1,70 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gnn provides the utilities shared by the message-passing graph neural network layers
+// implemented in its sub-packages (gcn, graphsage and gat).
+//
+// Those layers take the features of the nodes of a graph, as a list of nodes (one vector for each node
+// of the graph), together with its adjacency matrix, which can be either a mat.Dense or a mat.Sparse.
+// The element (i, j) of the adjacency matrix is the weight of the edge from the j-th node to the i-th
+// node (zero means no edge), so that the i-th row lists the neighbors whose messages the i-th node receives.
+// For undirected graphs the adjacency matrix is symmetric.
+package gnn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+)
+
+// Edge is an incoming edge of a node.
+type Edge struct {
+	// Node is the index of the neighbor the edge comes from.
+	Node int
+	// Weight is the weight of the edge.
+	Weight float64
+}
+
+// Neighbors returns the incoming edges of each node of the graph described by the given adjacency matrix,
+// in ascending order of neighbor index. It panics if the adjacency matrix is not square.
+func Neighbors(adjacency mat.Matrix) [][]Edge {
+	n := adjacency.Rows()
+	if adjacency.Columns() != n {
+		panic("gnn: the adjacency matrix must be square")
+	}
+	neighbors := make([][]Edge, n)
+	if sparse, ok := adjacency.(*mat.Sparse); ok {
+		sparse.DoNonZero(func(i, j int, v float64) {
+			neighbors[i] = append(neighbors[i], Edge{Node: j, Weight: v})
+		})
+		return neighbors
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if v := adjacency.At(i, j); v != 0 {
+				neighbors[i] = append(neighbors[i], Edge{Node: j, Weight: v})
+			}
+		}
+	}
+	return neighbors
+}
+
+// CheckGraph panics if the number of nodes features doesn't match the size of the adjacency matrix,
+// or if the adjacency matrix contains negative weights.
+func CheckGraph(numOfNodes int, adjacency mat.Matrix) {
+	if adjacency.Rows() != numOfNodes || adjacency.Columns() != numOfNodes {
+		panic("gnn: the adjacency matrix must have a row and a column for each node")
+	}
+	negative := false
+	if sparse, ok := adjacency.(*mat.Sparse); ok {
+		sparse.DoNonZero(func(_, _ int, v float64) {
+			negative = negative || v < 0
+		})
+	} else {
+		for _, v := range adjacency.Data() {
+			negative = negative || v < 0
+		}
+	}
+	if negative {
+		panic("gnn: the weights of the edges must not be negative")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graphsage implements the GraphSAGE layer introduced by William L. Hamilton, Rex Ying and Jure Leskovec
+// in "Inductive Representation Learning on Large Graphs", 2017 (https://arxiv.org/pdf/1706.02216.pdf).
+//
+// Each node combines its own features with the aggregation of the features of its neighbors:
+//     y(i) = Ws x(i) + Wn Aggregate({x(j) : j ∈ N(i)}) + b
+// optionally normalizing the result to unit length. The edge weights are ignored.
+// The non-linearity is left to the subsequent layers.
+package graphsage
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/gnn"
+)
+
+var (
+	_ nn.Model     = &Model{}
+	_ nn.Processor = &Processor{}
+)
+
+// epsilon prevents the division by zero in the normalization of zero outputs.
+const epsilon = 1.0e-12
+
+// Aggregator is the function which aggregates the features of the neighbors.
+type Aggregator int
+
+const (
+	// Mean aggregates the neighbors by their element-wise mean.
+	Mean Aggregator = iota
+	// Max aggregates the neighbors by their element-wise max.
+	Max
+)
+
+// Config provides configuration settings for a GraphSAGE Model.
+type Config struct {
+	InputSize  int
+	OutputSize int
+	Aggregator Aggregator
+	// Normalize sets whether to normalize each output vector to unit length.
+	Normalize bool
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	Config
+	WSelf      *nn.Param `type:"weights"`
+	WNeighbors *nn.Param `type:"weights"`
+	B          *nn.Param `type:"biases"`
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	return &Model{
+		Config:     config,
+		WSelf:      nn.NewParam(mat.NewEmptyDense(config.OutputSize, config.InputSize)),
+		WNeighbors: nn.NewParam(mat.NewEmptyDense(config.OutputSize, config.InputSize)),
+		B:          nn.NewParam(mat.NewEmptyVecDense(config.OutputSize)),
+	}
+}
+
+// Processor implements the nn.Processor interface for a GraphSAGE Model.
+type Processor struct {
+	nn.BaseProcessor
+	Config
+	wSelf      ag.Node
+	wNeighbors ag.Node
+	b          ag.Node
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Model) NewProc(ctx nn.Context) nn.Processor {
+	return &Processor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		Config:     m.Config,
+		wSelf:      ctx.Graph.NewWrap(m.WSelf),
+		wNeighbors: ctx.Graph.NewWrap(m.WNeighbors),
+		b:          ctx.Graph.NewWrap(m.B),
+	}
+}
+
+// Forward is not implemented for GraphSAGE (it always panics).
+// You should use ForwardWithAdjacency instead.
+func (p *Processor) Forward(_ ...ag.Node) []ag.Node {
+	panic("graphsage: Forward() not implemented; use ForwardWithAdjacency() instead.")
+}
+
+// ForwardWithAdjacency performs the forward step for the features xs of each node of the graph
+// described by the adjacency matrix (see package gnn), and returns the new features of each node.
+// The nodes without neighbors only use their own features.
+func (p *Processor) ForwardWithAdjacency(adjacency mat.Matrix, xs ...ag.Node) []ag.Node {
+	gnn.CheckGraph(len(xs), adjacency)
+	g := p.Graph
+	ys := make([]ag.Node, len(xs))
+	for i, edges := range gnn.Neighbors(adjacency) {
+		y := g.Add(g.Mul(p.wSelf, xs[i]), p.b)
+		if len(edges) > 0 {
+			y = g.Add(y, g.Mul(p.wNeighbors, p.aggregate(xs, edges)))
+		}
+		if p.Normalize {
+			y = g.DivScalar(y, g.Sqrt(g.AddScalar(g.ReduceSum(g.Square(y)), g.Constant(epsilon))))
+		}
+		ys[i] = y
+	}
+	return ys
+}
+
+func (p *Processor) aggregate(xs []ag.Node, edges []gnn.Edge) ag.Node {
+	neighbors := make([]ag.Node, len(edges))
+	for i, edge := range edges {
+		neighbors[i] = xs[edge.Node]
+	}
+	switch p.Aggregator {
+	case Mean:
+		return p.Graph.Mean(neighbors)
+	case Max:
+		return p.Graph.MaxSeq(neighbors...)
+	default:
+		panic("graphsage: unknown aggregator")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphsage
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+	"math"
+	"testing"
+)
+
+func TestModel_ForwardWithAdjacency(t *testing.T) {
+	// path graph 0 - 1 - 2, plus the isolated node 3
+	adjacency := mat.NewSparseFromMap(4, 4, map[mat.Coordinate]float64{
+		{I: 0, J: 1}: 1, {I: 1, J: 0}: 1,
+		{I: 1, J: 2}: 1, {I: 2, J: 1}: 1,
+	})
+	cases := []struct {
+		aggregator Aggregator
+		expected   []float64
+	}{
+		{Mean, []float64{0.0, 6.5, 2.0, 0.5}},
+		{Max, []float64{0.0, 7.0, 2.0, 0.5}},
+	}
+	for _, c := range cases {
+		model := New(Config{InputSize: 2, OutputSize: 1, Aggregator: c.aggregator})
+		model.WSelf.Value().SetData([]float64{1.0, 0.0})
+		model.WNeighbors.Value().SetData([]float64{0.0, 1.0})
+
+		g := ag.NewGraph()
+		proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+		xs := []ag.Node{
+			g.NewVariable(mat.NewVecDense([]float64{1.0, 5.0}), true),
+			g.NewVariable(mat.NewVecDense([]float64{2.0, -1.0}), true),
+			g.NewVariable(mat.NewVecDense([]float64{3.0, 4.0}), true),
+			g.NewVariable(mat.NewVecDense([]float64{0.5, 9.0}), true),
+		}
+		ys := proc.ForwardWithAdjacency(adjacency, xs...)
+		for i, y := range ys {
+			if !floats.EqualApprox(y.Value().Data(), []float64{c.expected[i]}, 1.0e-9) {
+				t.Errorf("The output of node %d doesn't match the expected value (aggregator %d)", i, c.aggregator)
+			}
+		}
+	}
+}
+
+func TestModel_Normalize(t *testing.T) {
+	model := New(Config{InputSize: 2, OutputSize: 2, Normalize: true})
+	model.WSelf.Value().SetData([]float64{1.0, 0.0, 0.0, 1.0})
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	x := g.NewVariable(mat.NewVecDense([]float64{3.0, 4.0}), false)
+	ys := proc.ForwardWithAdjacency(mat.NewEmptyDense(1, 1), x)
+	if !floats.EqualApprox(ys[0].Value().Data(), []float64{0.6, 0.8}, 1.0e-9) {
+		t.Error("The output doesn't match the expected values")
+	}
+}
+
+func TestModel_NormalizeZero(t *testing.T) {
+	model := New(Config{InputSize: 2, OutputSize: 2, Normalize: true})
+	model.WSelf.Value().SetData([]float64{1.0, 0.0, 0.0, 1.0})
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+	x := g.NewVariable(mat.NewVecDense([]float64{0.0, 0.0}), true)
+	ys := proc.ForwardWithAdjacency(mat.NewEmptyDense(1, 1), x)
+	if !floats.Equal(ys[0].Value().Data(), []float64{0.0, 0.0}) {
+		t.Errorf("Expected a zero output, got %v", ys[0].Value().Data())
+	}
+	g.Backward(g.ReduceSum(ys[0]))
+	for _, v := range append(x.Grad().Data(), model.WSelf.Grad().Data()...) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Fatal("Expected finite gradients for a zero output")
+		}
+	}
+}
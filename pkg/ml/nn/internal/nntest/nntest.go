@@ -0,0 +1,37 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nntest provides the fixtures shared by the tests of the models of the nn sub-packages,
+// whose expected behavior doesn't depend on specific values of the parameters and of the inputs.
+package nntest
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+// InitRandom initializes the parameters of the model with values drawn uniformly from [-0.5, 0.5],
+// using a generator with the given seed.
+func InitRandom(model nn.Model, seed uint64) {
+	rndGen := rand.NewLockedRand(seed)
+	nn.ForEachParam(model, func(param *nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, rndGen)
+	})
+}
+
+// NewInputs returns n variables requiring gradients, whose values of the given size are drawn uniformly
+// from [-1, 1], using a generator with the given seed.
+func NewInputs(g *ag.Graph, n, size int, seed uint64) []ag.Node {
+	rndGen := rand.NewLockedRand(seed)
+	xs := make([]ag.Node, n)
+	for i := range xs {
+		x := mat.NewEmptyVecDense(size)
+		initializers.Uniform(x, -1.0, 1.0, rndGen)
+		xs[i] = g.NewVariable(x, true)
+	}
+	return xs
+}
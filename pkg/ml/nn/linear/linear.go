@@ -45,6 +45,35 @@ func New(in, out int, options ...Option) *Model {
 	return model
 }
 
+// SelectOutputs keeps only the outputs of the model at the given indices, in the given order,
+// replacing W and B with their corresponding rows. It allows the structured pruning of the model.
+func (m *Model) SelectOutputs(indices []int) {
+	w := m.W.Value()
+	newW := mat.NewEmptyDense(len(indices), w.Columns())
+	newB := mat.NewEmptyVecDense(len(indices))
+	for i, index := range indices {
+		for j := 0; j < w.Columns(); j++ {
+			newW.Set(i, j, w.At(index, j))
+		}
+		newB.Set(i, 0, m.B.Value().At(index, 0))
+	}
+	m.W.ReplaceValue(newW)
+	m.B.ReplaceValue(newB)
+}
+
+// SelectInputs keeps only the inputs of the model at the given indices, in the given order,
+// replacing W with its corresponding columns. It allows the structured pruning of the model.
+func (m *Model) SelectInputs(indices []int) {
+	w := m.W.Value()
+	newW := mat.NewEmptyDense(w.Rows(), len(indices))
+	for i := 0; i < w.Rows(); i++ {
+		for j, index := range indices {
+			newW.Set(i, j, w.At(i, index))
+		}
+	}
+	m.W.ReplaceValue(newW)
+}
+
 const defaultConcurrency = true
 
 // Processor implements the nn.Processor interface for a linear Model.
@@ -0,0 +1,144 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lora provides the Low-Rank Adaptation (LoRA) of linear layers, as introduced by Edward J. Hu et al.
+// in "LoRA: Low-Rank Adaptation of Large Language Models", 2021 (https://arxiv.org/pdf/2106.09685.pdf).
+//
+// The weights of the wrapped linear layer are frozen, and only the low-rank update
+//    ΔW = (Alpha / Rank) * B A
+// is trained, where A is a Rank x In matrix and B is an Out x Rank matrix. Since B is initialized to zeros,
+// the wrapped layer initially behaves exactly as the original one.
+//
+// Only the adapter parameters require gradients, so they can be stored apart from the frozen model using
+// nn.NewTrainableParamsSerializer. After the training, Merge folds the update into the base weights,
+// so that the base linear layer can be used alone at inference time without any overhead.
+package lora
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+var (
+	_ nn.Model     = &Model{}
+	_ nn.Processor = &Processor{}
+)
+
+// Config provides configuration settings for a LoRA Model.
+type Config struct {
+	// Rank is the rank of the update matrix.
+	Rank int
+	// Alpha is the scaling numerator; the update is scaled by Alpha / Rank.
+	Alpha float64
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	Config
+	Base *linear.Model
+	A    *nn.Param `type:"weights"`
+	B    *nn.Param `type:"weights"`
+}
+
+// New returns a new LoRA model wrapping the given linear model, whose parameters are frozen.
+// The parameters of the update are initialized to zeros (see Initialize).
+func New(base *linear.Model, config Config) *Model {
+	if config.Rank <= 0 {
+		panic("lora: the rank must be positive")
+	}
+	out, in := base.W.Value().Dims()
+	nn.Freeze(base)
+	return &Model{
+		Config: config,
+		Base:   base,
+		A:      nn.NewParam(mat.NewEmptyDense(config.Rank, in)),
+		B:      nn.NewParam(mat.NewEmptyDense(out, config.Rank)),
+	}
+}
+
+// Initialize set the A matrix with random values and the B matrix to zeros, so that the update is initially null
+// but its gradients are not.
+func Initialize(m *Model, rndGen *rand.LockedRand) {
+	initializers.XavierUniform(m.A.Value(), 1.0, rndGen)
+	initializers.Zeros(m.B.Value())
+}
+
+// Scale returns the scaling factor of the update.
+func (m *Model) Scale() float64 {
+	return m.Alpha / float64(m.Rank)
+}
+
+// Merge adds the update to the weights of the base linear model, then resets the B matrix to zeros,
+// so that the function computed by the model doesn't change.
+func (m *Model) Merge() {
+	delta := m.B.Value().Mul(m.A.Value()).ProdScalarInPlace(m.Scale())
+	m.Base.W.Value().AddInPlace(delta)
+	mat.ReleaseDense(delta.(*mat.Dense))
+	m.B.Value().Zeros()
+}
+
+// Wrap replaces each linear layer of the stack with a LoRA model wrapping it, initialized with Initialize.
+// It returns the new LoRA models.
+func Wrap(s *stack.Model, config Config, rndGen *rand.LockedRand) []*Model {
+	var wrapped []*Model
+	for i, layer := range s.Layers {
+		if base, ok := layer.(*linear.Model); ok {
+			m := New(base, config)
+			Initialize(m, rndGen)
+			s.Layers[i] = m
+			wrapped = append(wrapped, m)
+		}
+	}
+	return wrapped
+}
+
+// MergeAndUnwrap merges each LoRA layer of the stack (see Merge) and replaces it with its base linear model.
+func MergeAndUnwrap(s *stack.Model) {
+	for i, layer := range s.Layers {
+		if m, ok := layer.(*Model); ok {
+			m.Merge()
+			s.Layers[i] = m.Base
+		}
+	}
+}
+
+// Processor implements the nn.Processor interface for a LoRA Model.
+type Processor struct {
+	nn.BaseProcessor
+	base  *linear.Processor
+	a     ag.Node
+	b     ag.Node
+	scale ag.Node
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Model) NewProc(ctx nn.Context) nn.Processor {
+	return &Processor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: false,
+		},
+		base:  m.Base.NewProc(ctx).(*linear.Processor),
+		a:     ctx.Graph.NewWrap(m.A),
+		b:     ctx.Graph.NewWrap(m.B),
+		scale: ctx.Graph.Constant(m.Scale()),
+	}
+}
+
+// Forward performs the forward step for each input and returns the result.
+func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
+	g := p.Graph
+	ys := p.base.Forward(xs...)
+	for i, x := range xs {
+		ys[i] = g.Add(ys[i], g.ProdScalar(g.Mul(p.b, g.Mul(p.a, x)), p.scale))
+	}
+	return ys
+}
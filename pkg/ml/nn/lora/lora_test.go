@@ -0,0 +1,124 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lora
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestModel_Forward(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training})
+
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, 2.0}), true)
+	y := proc.Forward(x)[0]
+
+	if !floats.EqualApprox(y.Value().Data(), []float64{-0.4, -2.5, 2.6}, 1.0e-06) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	g.Backward(y, ag.OutputGrad(mat.NewVecDense([]float64{1.0, 1.0, 1.0})))
+
+	if !floats.EqualApprox(model.A.Grad().Data(), []float64{4.0, 8.0}, 1.0e-06) {
+		t.Error("A doesn't match the expected values")
+	}
+	if !floats.EqualApprox(model.B.Grad().Data(), []float64{-1.0, -1.0, -1.0}, 1.0e-06) {
+		t.Error("B doesn't match the expected values")
+	}
+	if model.Base.W.HasGrad() || model.Base.B.HasGrad() {
+		t.Error("The base parameters are expected to be frozen")
+	}
+}
+
+func TestModel_Merge(t *testing.T) {
+	model := newTestModel()
+	x := mat.NewVecDense([]float64{1.0, 2.0})
+
+	g := ag.NewGraph()
+	expected := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(g.NewVariable(x, false))[0]
+
+	model.Merge()
+
+	if !floats.EqualApprox(model.B.Value().Data(), []float64{0, 0, 0}, 0) {
+		t.Error("B is expected to be reset to zeros")
+	}
+
+	g = ag.NewGraph()
+	merged := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(g.NewVariable(x, false))[0]
+	base := model.Base.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(g.NewVariable(x, false))[0]
+
+	if !floats.EqualApprox(merged.Value().Data(), expected.Value().Data(), 1.0e-09) {
+		t.Error("The merge changes the output of the model")
+	}
+	if !floats.EqualApprox(base.Value().Data(), expected.Value().Data(), 1.0e-09) {
+		t.Error("The merged base model doesn't match the output of the LoRA model")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	s := stack.New(linear.New(2, 3), activation.New(ag.OpTanh), linear.New(3, 2))
+	wrapped := Wrap(s, Config{Rank: 1, Alpha: 1.0}, rand.NewLockedRand(42))
+
+	if len(wrapped) != 2 || s.Layers[0] != wrapped[0] || s.Layers[2] != wrapped[1] {
+		t.Fatal("The linear layers are expected to be wrapped")
+	}
+	for _, m := range wrapped {
+		if m.A.Value().Norm(2) == 0 {
+			t.Error("A is expected to be initialized")
+		}
+	}
+
+	MergeAndUnwrap(s)
+
+	if s.Layers[0] != wrapped[0].Base || s.Layers[2] != wrapped[1].Base {
+		t.Error("The LoRA layers are expected to be replaced by their base models")
+	}
+}
+
+func TestTrainableParamsSerializer(t *testing.T) {
+	model := newTestModel()
+	var buf bytes.Buffer
+	if _, err := nn.NewTrainableParamsSerializer(model).Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	other := New(linear.New(2, 3), Config{Rank: 1, Alpha: 2.0})
+	if _, err := nn.NewTrainableParamsSerializer(other).Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !floats.EqualApprox(other.A.Value().Data(), model.A.Value().Data(), 0) ||
+		!floats.EqualApprox(other.B.Value().Data(), model.B.Value().Data(), 0) {
+		t.Error("The adapter parameters don't match the serialized ones")
+	}
+	if other.Base.W.Value().Norm(2) != 0 || other.Base.B.Value().Norm(2) != 0 {
+		t.Error("The frozen parameters are not expected to be serialized")
+	}
+}
+
+func newTestModel() *Model {
+	base := linear.New(2, 3)
+	base.W.Value().SetData([]float64{
+		0.1, 0.2,
+		0.3, -0.4,
+		0.5, 0.6,
+	})
+	base.B.Value().SetData([]float64{0.1, 0.0, -0.1})
+	model := New(base, Config{Rank: 1, Alpha: 2.0})
+	model.A.Value().SetData([]float64{0.5, -0.5})
+	model.B.Value().SetData([]float64{1.0, 2.0, -1.0})
+	return model
+}
@@ -47,10 +47,17 @@ func NewDefaultParamsIterator(models ...Model) *DefaultParamsIterator {
 
 // ParamsList returns a slice with all Param elements from all models held by
 // the DefaultParamsIterator.
+// The parameters shared among several models (or sub-models) are returned once,
+// so that they are optimized once.
 func (i *DefaultParamsIterator) ParamsList() []*Param {
 	params := make([]*Param, 0)
+	visited := make(map[*Param]bool)
 	for _, model := range i.models {
 		ForEachParam(model, func(param *Param) {
+			if visited[param] {
+				return
+			}
+			visited[param] = true
 			params = append(params, param)
 		})
 	}
@@ -65,6 +72,20 @@ func ZeroGrad(m Model) {
 	})
 }
 
+// Freeze prevents all model's parameters (including sub-params) from being trained.
+func Freeze(m Model) {
+	ForEachParam(m, func(param *Param) {
+		RequiresGrad(false)(param)
+	})
+}
+
+// Unfreeze allows all model's parameters (including sub-params) to be trained.
+func Unfreeze(m Model) {
+	ForEachParam(m, func(param *Param) {
+		RequiresGrad(true)(param)
+	})
+}
+
 // ClearSupport clears the support structure of all model's parameters (including sub-params).
 // TODO: use ParamsIterator?
 func ClearSupport(m Model) {
@@ -112,6 +133,7 @@ var _ utils.Deserializer = &ParamsSerializer{}
 // parameters of a given Model.
 type ParamsSerializer struct {
 	Model
+	trainableOnly bool
 }
 
 // NewParamsSerializer returns a new ParamsSerializer.
@@ -119,10 +141,27 @@ func NewParamsSerializer(m Model) *ParamsSerializer {
 	return &ParamsSerializer{Model: m}
 }
 
+// NewTrainableParamsSerializer returns a new ParamsSerializer which only considers the
+// parameters requiring gradients. It allows to store separately the few parameters
+// trained on top of a frozen model, such as adapters, from the frozen ones.
+func NewTrainableParamsSerializer(m Model) *ParamsSerializer {
+	return &ParamsSerializer{Model: m, trainableOnly: true}
+}
+
+// forEachParam iterates the params considered by the serializer.
+func (m *ParamsSerializer) forEachParam(callback func(param *Param)) {
+	ForEachParam(m.Model, func(param *Param) {
+		if m.trainableOnly && !param.RequiresGrad() {
+			return
+		}
+		callback(param)
+	})
+}
+
 // Serialize dumps the params values to the writer.
 // TODO: use ParamsIterator?
 func (m *ParamsSerializer) Serialize(w io.Writer) (n int, err error) {
-	ForEachParam(m, func(param *Param) {
+	m.forEachParam(func(param *Param) {
 		cnt, err2 := mat.MarshalBinaryTo(param.Value(), w)
 		n += cnt
 		if err2 != nil {
@@ -136,7 +175,7 @@ func (m *ParamsSerializer) Serialize(w io.Writer) (n int, err error) {
 // Deserialize assigns the params with the values obtained from the reader.
 // TODO: use ParamsIterator?
 func (m *ParamsSerializer) Deserialize(r io.Reader) (n int, err error) {
-	ForEachParam(m, func(param *Param) {
+	m.forEachParam(func(param *Param) {
 		cnt, err2 := mat.UnmarshalBinaryFrom(param.Value(), r)
 		n += cnt
 		if err2 != nil {
@@ -0,0 +1,30 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+)
+
+type sharedParamsTestModel struct {
+	ParamsTraversalBaseModel
+	W *Param
+	V *Param
+}
+
+func TestDefaultParamsIterator_SharedParams(t *testing.T) {
+	shared := NewParam(mat.NewScalar(1.0))
+	other := NewParam(mat.NewScalar(2.0))
+	m1 := &sharedParamsTestModel{W: shared, V: shared}
+	m2 := &sharedParamsTestModel{W: other, V: shared}
+
+	params := NewDefaultParamsIterator(m1, m2).ParamsList()
+
+	if len(params) != 2 || params[0] != shared || params[1] != other {
+		t.Errorf("The shared params are expected to be listed once, got %d params", len(params))
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package moe implements a sparse Mixture-of-Experts feed-forward layer, where a learned gate routes
+each input to the top-k of N expert feed-forward networks, whose outputs are combined according to
+the gate probabilities.
+
+In order to keep the computation balanced, each expert can process a limited number of inputs
+(capacity); the inputs exceeding the capacity of an expert are not dispatched to it. Moreover,
+the Processor computes an auxiliary loss encouraging the gate to distribute the inputs evenly,
+which should be added to the training loss.
+
+Reference: "Switch Transformers: Scaling to Trillion Parameter Models with Simple and Efficient Sparsity"
+by William Fedus, Barret Zoph and Noam Shazeer (2021) (https://arxiv.org/pdf/2101.03961.pdf)
+*/
+package moe
+
+import (
+	"math"
+	"sort"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+var (
+	_ nn.Model     = &Model{}
+	_ nn.Processor = &Processor{}
+)
+
+// Config provides configuration settings for a Mixture-of-Experts Model.
+type Config struct {
+	// Size is the dimension of the input and output vectors.
+	Size int
+	// HiddenSize is the dimension of the hidden layer of each expert.
+	HiddenSize int
+	// HiddenActivation is the activation function of the hidden layer of each expert.
+	HiddenActivation ag.OpName
+	// NumOfExperts is the number of expert feed-forward networks.
+	NumOfExperts int
+	// TopK is the number of experts each input is routed to.
+	TopK int
+	// CapacityFactor sets the max number of inputs each expert can process, that is
+	// ceil(CapacityFactor * number of inputs * TopK / NumOfExperts). Zero means unlimited capacity.
+	CapacityFactor float64
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	Config
+	Gate    *linear.Model
+	Experts []*stack.Model
+}
+
+// New returns a new model with parameters initialized to zeros.
+// Each expert is a position-wise feed-forward network: W2 Activation(W1 x + b1) + b2.
+func New(config Config) *Model {
+	if config.TopK < 1 || config.TopK > config.NumOfExperts {
+		panic("moe: the top-k must be in the range [1, number of experts]")
+	}
+	experts := make([]*stack.Model, config.NumOfExperts)
+	for i := range experts {
+		experts[i] = stack.New(
+			linear.New(config.Size, config.HiddenSize),
+			activation.New(config.HiddenActivation),
+			linear.New(config.HiddenSize, config.Size),
+		)
+	}
+	return &Model{
+		Config:  config,
+		Gate:    linear.New(config.Size, config.NumOfExperts),
+		Experts: experts,
+	}
+}
+
+// Processor implements the nn.Processor interface for a Mixture-of-Experts Model.
+type Processor struct {
+	nn.BaseProcessor
+	gate    *linear.Processor
+	experts []*stack.Processor
+	// AuxLoss is the load-balancing auxiliary loss of the last forward step.
+	// It is minimal (1.0) when the inputs are uniformly distributed among the experts.
+	// It is usually scaled by a small coefficient (e.g. 0.01) and added to the training loss.
+	AuxLoss ag.Node
+	// Routes contains, for each input of the last forward step, the indices of the experts it
+	// has been dispatched to. The experts which had already reached their capacity are excluded.
+	Routes [][]int
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Model) NewProc(ctx nn.Context) nn.Processor {
+	experts := make([]*stack.Processor, len(m.Experts))
+	for i, expert := range m.Experts {
+		experts[i] = expert.NewProc(ctx).(*stack.Processor)
+	}
+	return &Processor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true, // the capacity depends on the number of inputs
+		},
+		gate:    m.Gate.NewProc(ctx).(*linear.Processor),
+		experts: experts,
+		AuxLoss: nil,
+		Routes:  nil,
+	}
+}
+
+// Forward performs the forward step for each input and returns the result.
+// The output of an input which has not been dispatched to any expert is a zero vector.
+func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
+	config := p.Model.(*Model).Config
+	g := p.Graph
+	probs := make([]ag.Node, len(xs))
+	choices := make([][]int, len(xs))
+	weights := make([]map[int]ag.Node, len(xs))
+	for i, logits := range p.gate.Forward(xs...) {
+		probs[i] = g.Softmax(logits)
+		choices[i] = topK(probs[i].Value().Data(), config.TopK)
+		weights[i] = p.gateWeights(probs[i], choices[i])
+	}
+
+	// the inputs are dispatched by rank, so that the first choices take priority over the capacity
+	capacity := p.capacity(len(xs))
+	dispatched := make([][]int, config.NumOfExperts)
+	p.Routes = make([][]int, len(xs))
+	for rank := 0; rank < config.TopK; rank++ {
+		for i, choice := range choices {
+			expert := choice[rank]
+			if capacity > 0 && len(dispatched[expert]) >= capacity {
+				continue
+			}
+			dispatched[expert] = append(dispatched[expert], i)
+			p.Routes[i] = append(p.Routes[i], expert)
+		}
+	}
+
+	ys := make([]ag.Node, len(xs))
+	for expert, indices := range dispatched {
+		if len(indices) == 0 {
+			continue
+		}
+		input := make([]ag.Node, len(indices))
+		for j, i := range indices {
+			input[j] = xs[i]
+		}
+		for j, y := range p.experts[expert].Forward(input...) {
+			i := indices[j]
+			y = g.ProdScalar(y, weights[i][expert])
+			if ys[i] != nil {
+				y = g.Add(ys[i], y)
+			}
+			ys[i] = y
+		}
+	}
+	for i, y := range ys {
+		if y == nil {
+			ys[i] = g.NewVariable(mat.NewEmptyVecDense(config.Size), false)
+		}
+	}
+	p.AuxLoss = p.loadBalancingLoss(probs, choices)
+	return ys
+}
+
+// gateWeights returns the weight of each chosen expert, that is its gate probability
+// renormalized over the chosen experts.
+func (p *Processor) gateWeights(probs ag.Node, choices []int) map[int]ag.Node {
+	g := p.Graph
+	weights := make(map[int]ag.Node, len(choices))
+	if len(choices) == 1 {
+		weights[choices[0]] = g.AtVec(probs, choices[0])
+		return weights
+	}
+	var sum ag.Node
+	for _, expert := range choices {
+		weights[expert] = g.AtVec(probs, expert)
+		if sum == nil {
+			sum = weights[expert]
+		} else {
+			sum = g.Add(sum, weights[expert])
+		}
+	}
+	for expert, w := range weights {
+		weights[expert] = g.Div(w, sum)
+	}
+	return weights
+}
+
+// capacity returns the max number of inputs each expert can process, or 0 if unlimited.
+func (p *Processor) capacity(numOfInputs int) int {
+	config := p.Model.(*Model).Config
+	if config.CapacityFactor <= 0 {
+		return 0
+	}
+	return int(math.Ceil(config.CapacityFactor * float64(numOfInputs*config.TopK) / float64(config.NumOfExperts)))
+}
+
+// loadBalancingLoss returns N·Σ f(i)·P(i), where N is the number of experts, f(i) is the fraction of
+// choices of the i-th expert and P(i) is the average gate probability of the i-th expert.
+// Only P is differentiable.
+func (p *Processor) loadBalancingLoss(probs []ag.Node, choices [][]int) ag.Node {
+	config := p.Model.(*Model).Config
+	g := p.Graph
+	fractions := mat.NewEmptyVecDense(config.NumOfExperts)
+	total := float64(len(choices) * config.TopK)
+	for _, choice := range choices {
+		for _, expert := range choice {
+			fractions.SetVec(expert, fractions.AtVec(expert)+1.0/total)
+		}
+	}
+	meanProbs := g.DivScalar(g.Sum(probs...), g.NewScalar(float64(len(probs))))
+	loss := g.ReduceSum(g.Prod(meanProbs, g.NewVariable(fractions, false)))
+	return g.ProdScalar(loss, g.NewScalar(float64(config.NumOfExperts)))
+}
+
+// topK returns the indices of the k greatest values, in descending order of value.
+// Ties are broken in favor of the lower index.
+func topK(values []float64, k int) []int {
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return values[indices[i]] > values[indices[j]]
+	})
+	return indices[:k]
+}
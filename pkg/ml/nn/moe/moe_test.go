@@ -0,0 +1,129 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moe
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestModel_Forward(t *testing.T) {
+	model := newTestModel(2, 0)
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+	ys := proc.Forward(
+		g.NewVariable(mat.NewVecDense([]float64{1.0, 0.0}), true),
+		g.NewVariable(mat.NewVecDense([]float64{0.0, 2.0}), true),
+		g.NewVariable(mat.NewVecDense([]float64{-0.5, -1.0}), true),
+		g.NewVariable(mat.NewVecDense([]float64{0.5, -1.0}), true), // tie between the experts 0 and 2
+	)
+
+	expectedRoutes := [][]int{{0, 1}, {1, 0}, {2, 0}, {0, 2}}
+	expected := [][]float64{
+		{0.757952721, 0.295835564},
+		{1.849673864, 0.326485552},
+		{-0.440398539, 0.0},
+		{-0.5, 0.0},
+	}
+	for i, y := range ys {
+		if !(len(proc.Routes[i]) == 2 && proc.Routes[i][0] == expectedRoutes[i][0] &&
+			proc.Routes[i][1] == expectedRoutes[i][1]) {
+			t.Errorf("The input %d is routed to %v, expected %v", i, proc.Routes[i], expectedRoutes[i])
+		}
+		if !floats.EqualApprox(y.Value().Data(), expected[i], 1.0e-06) {
+			t.Errorf("The output %d doesn't match the expected values", i)
+		}
+	}
+	// the experts are chosen 4, 2 and 2 times
+	if !floats.EqualApprox([]float64{proc.AuxLoss.ScalarValue()}, []float64{1.001912479}, 1.0e-06) {
+		t.Error("The auxiliary loss doesn't match the expected value")
+	}
+
+	loss := g.Add(g.ReduceSum(g.Square(g.Sum(ys...))), proc.AuxLoss)
+	g.Backward(loss)
+	if !model.Gate.W.HasGrad() {
+		t.Error("The gate has no gradients")
+	}
+}
+
+func TestModel_Capacity(t *testing.T) {
+	model := New(newTestConfig(1, 1.0))
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	xs := make([]ag.Node, 6)
+	for i := range xs {
+		xs[i] = g.NewVariable(mat.NewVecDense([]float64{float64(i), 1.0}), false)
+	}
+	// with zero parameters the gate is uniform, so all inputs choose the first expert
+	ys := proc.Forward(xs...)
+
+	// the capacity is ceil(1.0 * 6 * 1 / 3) = 2
+	for i, route := range proc.Routes {
+		if i < 2 && len(route) != 1 {
+			t.Errorf("Expected input %d to be dispatched", i)
+		}
+		if i >= 2 && len(route) != 0 {
+			t.Errorf("Expected input %d to be dropped", i)
+		}
+	}
+	if !floats.Equal(ys[5].Value().Data(), []float64{0, 0}) {
+		t.Error("Expected a zero output for a dropped input")
+	}
+	if !floats.EqualApprox([]float64{proc.AuxLoss.ScalarValue()}, []float64{1.0}, 1.0e-9) {
+		t.Error("The auxiliary loss doesn't match the expected value")
+	}
+}
+
+func TestTopK(t *testing.T) {
+	if got := topK([]float64{0.1, 0.5, 0.1, 0.3}, 3); !(got[0] == 1 && got[1] == 3 && got[2] == 0) {
+		t.Errorf("Unexpected top-k indices %v", got)
+	}
+}
+
+// newTestConfig returns the Config of a model with 3 experts of size 2.
+func newTestConfig(topK int, capacityFactor float64) Config {
+	return Config{
+		Size:             2,
+		HiddenSize:       2,
+		HiddenActivation: ag.OpReLU,
+		NumOfExperts:     3,
+		TopK:             topK,
+		CapacityFactor:   capacityFactor,
+	}
+}
+
+// newTestModel returns a model whose gate prefers the expert 0 for the first feature, the expert 1 for
+// the second one and the expert 2 for negative inputs. The experts compute:
+//    e0(x) = ReLU(x)
+//    e1(x) = ReLU(swap(x)) + 0.1
+//    e2(x) = -ReLU(x + 1)
+func newTestModel(topK int, capacityFactor float64) *Model {
+	model := New(newTestConfig(topK, capacityFactor))
+	model.Gate.W.Value().SetData([]float64{
+		1.0, 0.0,
+		0.0, 1.0,
+		-1.0, -1.0,
+	})
+	setExpert(model.Experts[0], []float64{1.0, 0.0, 0.0, 1.0}, []float64{0.0, 0.0},
+		[]float64{1.0, 0.0, 0.0, 1.0}, []float64{0.0, 0.0})
+	setExpert(model.Experts[1], []float64{0.0, 1.0, 1.0, 0.0}, []float64{0.0, 0.0},
+		[]float64{1.0, 0.0, 0.0, 1.0}, []float64{0.1, 0.1})
+	setExpert(model.Experts[2], []float64{1.0, 0.0, 0.0, 1.0}, []float64{1.0, 1.0},
+		[]float64{-1.0, 0.0, 0.0, -1.0}, []float64{0.0, 0.0})
+	return model
+}
+
+func setExpert(expert *stack.Model, w1, b1, w2, b2 []float64) {
+	expert.Layers[0].(*linear.Model).W.Value().SetData(w1)
+	expert.Layers[0].(*linear.Model).B.Value().SetData(b1)
+	expert.Layers[2].(*linear.Model).W.Value().SetData(w2)
+	expert.Layers[2].(*linear.Model).B.Value().SetData(b2)
+}
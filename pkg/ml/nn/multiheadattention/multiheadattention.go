@@ -5,7 +5,9 @@
 package multiheadattention
 
 import (
+	"github.com/nlpodyssey/spago/pkg/mat"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/encoding/pe"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/selfattention"
@@ -21,13 +23,43 @@ var (
 type Model struct {
 	Attention   []*selfattention.Model
 	OutputMerge *linear.Model
-	h           int // number of heads
-	dm          int // input and output vectors dimension
-	dk          int // hidden vectors dimension (dm/h)
+	// RelativePositionBias is the optional learned relative position bias (see WithRelativePositionBias).
+	RelativePositionBias *RelativePositionBias
+	h                    int // number of heads
+	dm                   int // input and output vectors dimension
+	dk                   int // hidden vectors dimension (dm/h)
+	rotary               *pe.RotaryPositionalEncoder
+	alibiSlopes          []float64
+}
+
+// Option allows to configure a new Model with your specific needs.
+type Option func(*Model)
+
+// WithRelativePositionBias adds the learned relative position bias of T5 to the attention scores
+// of each head (see RelativePositionBias).
+func WithRelativePositionBias(config RelativePositionBiasConfig) Option {
+	return func(m *Model) {
+		m.RelativePositionBias = NewRelativePositionBias(m.h, config)
+	}
+}
+
+// WithRotaryEmbeddings applies the rotary position embeddings (RoPE) to the queries and the keys
+// of each head, up to maxLength positions (see pe.RotaryPositionalEncoder).
+func WithRotaryEmbeddings(maxLength int) Option {
+	return func(m *Model) {
+		m.rotary = pe.NewRotaryPositionalEncoder(m.dk, maxLength)
+	}
+}
+
+// WithALiBi adds the linear biases of ALiBi to the attention scores of each head (see pe.ALiBiSlopes).
+func WithALiBi() Option {
+	return func(m *Model) {
+		m.alibiSlopes = pe.ALiBiSlopes(m.h)
+	}
 }
 
 // New returns a new model with parameters initialized to zeros.
-func New(size, numOfHeads int, useCausalMask bool) *Model {
+func New(size, numOfHeads int, useCausalMask bool, options ...Option) *Model {
 	dm := size
 	dk := size / numOfHeads
 	attention := make([]*selfattention.Model, numOfHeads)
@@ -42,20 +74,102 @@ func New(size, numOfHeads int, useCausalMask bool) *Model {
 	for i := 0; i < numOfHeads; i++ {
 		attention[i] = selfattention.New(attentionConfig)
 	}
-	return &Model{
+	model := &Model{
 		Attention:   attention,
 		OutputMerge: linear.New(dk*numOfHeads, dm),
 		h:           numOfHeads,
 		dm:          dm,
 		dk:          dk,
 	}
+	for _, option := range options {
+		option(model)
+	}
+	return model
+}
+
+// NumOfHeads returns the number of heads of the model.
+func (m *Model) NumOfHeads() int {
+	return m.h
+}
+
+// HeadSize returns the dimension of the hidden vectors of each head.
+func (m *Model) HeadSize() int {
+	return m.dk
+}
+
+// PruneHeads removes the heads at the given indices, together with the corresponding input columns
+// of the output projection, reducing the size of the model. It panics if all the heads are removed.
+func (m *Model) PruneHeads(heads []int) {
+	removed := make(map[int]bool, len(heads))
+	for _, h := range heads {
+		if h < 0 || h >= m.h {
+			panic("multiheadattention: head index out of range")
+		}
+		removed[h] = true
+	}
+	if len(removed) == m.h {
+		panic("multiheadattention: cannot prune all the heads")
+	}
+	var attention []*selfattention.Model
+	var slopes []float64
+	var biases []*nn.Param
+	var columns []int
+	for h := 0; h < m.h; h++ {
+		if removed[h] {
+			continue
+		}
+		attention = append(attention, m.Attention[h])
+		if m.alibiSlopes != nil {
+			slopes = append(slopes, m.alibiSlopes[h])
+		}
+		if m.RelativePositionBias != nil {
+			biases = append(biases, m.RelativePositionBias.Heads[h])
+		}
+		for k := 0; k < m.dk; k++ {
+			columns = append(columns, h*m.dk+k)
+		}
+	}
+	m.OutputMerge.SelectInputs(columns)
+	m.Attention = attention
+	m.h = len(attention)
+	if m.alibiSlopes != nil {
+		m.alibiSlopes = slopes
+	}
+	if m.RelativePositionBias != nil {
+		m.RelativePositionBias.Heads = biases
+	}
+}
+
+// HeadImportance returns the importance of each head, estimated from the gradients accumulated by the
+// output projection, as the absolute value of the first-order change of the loss if the head is removed
+// (Michel et al., 2019, "Are Sixteen Heads Really Better than One?"):
+//    I(h) = |Σ W[:,h] ∘ ∂L/∂W[:,h]|
+// where W[:,h] are the input columns of the output projection corresponding to the head h.
+// It returns zeros if the output projection has no gradients.
+func (m *Model) HeadImportance() []float64 {
+	importance := make([]float64, m.h)
+	if !m.OutputMerge.W.HasGrad() {
+		return importance
+	}
+	w, gw := m.OutputMerge.W.Value(), m.OutputMerge.W.Grad()
+	for h := range importance {
+		sum := 0.0
+		for i := 0; i < w.Rows(); i++ {
+			for k := h * m.dk; k < (h+1)*m.dk; k++ {
+				sum += w.At(i, k) * gw.At(i, k)
+			}
+		}
+		importance[h] = math.Abs(sum)
+	}
+	return importance
 }
 
 // Processor implements the nn.Processor interface for a multi-head attention Model.
 type Processor struct {
 	nn.BaseProcessor
-	HeadAttentionProc []*selfattention.Processor
-	outputMerge       *linear.Processor
+	HeadAttentionProc    []*selfattention.Processor
+	outputMerge          *linear.Processor
+	relativePositionBias *RelativePositionBiasProcessor
 }
 
 // NewProc returns a new processor to execute the forward step.
@@ -64,6 +178,10 @@ func (m *Model) NewProc(ctx nn.Context) nn.Processor {
 	for i := 0; i < m.h; i++ {
 		headAttentionProc[i] = m.Attention[i].NewProc(ctx).(*selfattention.Processor)
 	}
+	var relativePositionBias *RelativePositionBiasProcessor
+	if m.RelativePositionBias != nil {
+		relativePositionBias = m.RelativePositionBias.NewProc(ctx).(*RelativePositionBiasProcessor)
+	}
 	return &Processor{
 		BaseProcessor: nn.BaseProcessor{
 			Model:             m,
@@ -71,40 +189,72 @@ func (m *Model) NewProc(ctx nn.Context) nn.Processor {
 			Graph:             ctx.Graph,
 			FullSeqProcessing: true,
 		},
-		HeadAttentionProc: headAttentionProc,
-		outputMerge:       m.OutputMerge.NewProc(ctx).(*linear.Processor),
+		HeadAttentionProc:    headAttentionProc,
+		outputMerge:          m.OutputMerge.NewProc(ctx).(*linear.Processor),
+		relativePositionBias: relativePositionBias,
 	}
 }
 
 // Forward performs the forward step for each input and returns the result.
 func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
-	h := p.Model.(*Model).h
-	headsAttention := make([][]ag.Node, h)
-	for h, proc := range p.HeadAttentionProc {
-		headsAttention[h] = proc.Forward(xs...)
-	}
-	concatHeads := make([]ag.Node, len(xs))
-	for i := 0; i < len(xs); i++ {
-		buf := make([]ag.Node, h)
-		for j := 0; j < h; j++ {
-			buf[j] = headsAttention[j][i]
-		}
-		concatHeads[i] = p.Graph.Concat(buf...)
-	}
-	return p.outputMerge.Forward(concatHeads...)
+	return p.ForwardWithMask(nil, xs...)
+}
+
+// ForwardWithMask performs the forward step for each input and returns the result,
+// applying the given additive attention mask to every head (see nn.ScaledDotProductAttentionWithMask).
+// The mask has a row for each query and a column for each key; it can be nil.
+func (p *Processor) ForwardWithMask(mask mat.Matrix, xs ...ag.Node) []ag.Node {
+	return p.ForwardQKVWithMask(xs, xs, xs, mask)
 }
 
 // ForwardQKV performs the forward step for each input and returns the result.
 // This is a variant of the standard Forward, where you can specify independent
 // sets of queries, keys and values.
 func (p *Processor) ForwardQKV(qs []ag.Node, ks []ag.Node, vs []ag.Node) []ag.Node {
-	h := p.Model.(*Model).h
-	headsAttention := make([][]ag.Node, h)
+	return p.ForwardQKVWithMask(qs, ks, vs, nil)
+}
+
+// ForwardQKVWithMask is a variant of ForwardQKV which applies the given additive attention
+// mask to every head (see nn.ScaledDotProductAttentionWithMask).
+// The mask has a row for each query and a column for each key; it can be nil.
+// The relative position encodings of the Model, if any, are applied on top of it.
+func (p *Processor) ForwardQKVWithMask(qs []ag.Node, ks []ag.Node, vs []ag.Node, mask mat.Matrix) []ag.Node {
+	m := p.Model.(*Model)
+	headsAttention := make([][]ag.Node, m.h)
 	for h, proc := range p.HeadAttentionProc {
-		headsAttention[h] = proc.ForwardQKV(qs, ks, vs)
+		queries, keys, values := proc.Project(qs, ks, vs)
+		if m.rotary != nil {
+			queries = m.rotary.Encode(p.Graph, queries...)
+			keys = m.rotary.Encode(p.Graph, keys...)
+		}
+		headMask := mask
+		if m.alibiSlopes != nil {
+			headMask = nn.CombineMasks(mask, alibiMask(m.alibiSlopes[h], len(qs), len(ks)))
+		}
+		var bias []ag.Node
+		if p.relativePositionBias != nil {
+			bias = p.relativePositionBias.Bias(h, len(qs), len(ks))
+		}
+		headsAttention[h] = proc.Attend(queries, keys, values, headMask, bias)
 	}
-	concatHeads := make([]ag.Node, len(qs))
-	for i := 0; i < len(qs); i++ {
+	return p.mergeHeads(headsAttention, len(qs))
+}
+
+// alibiMask returns the additive mask with the linear biases of ALiBi for the given slope.
+func alibiMask(slope float64, numOfQueries, numOfKeys int) mat.Matrix {
+	mask := mat.NewEmptyDense(numOfQueries, numOfKeys)
+	for i := 0; i < numOfQueries; i++ {
+		for j := 0; j < numOfKeys; j++ {
+			mask.Set(i, j, -slope*math.Abs(float64(i-j)))
+		}
+	}
+	return mask
+}
+
+func (p *Processor) mergeHeads(headsAttention [][]ag.Node, length int) []ag.Node {
+	h := len(headsAttention)
+	concatHeads := make([]ag.Node, length)
+	for i := 0; i < length; i++ {
 		buf := make([]ag.Node, h)
 		for j := 0; j < h; j++ {
 			buf[j] = headsAttention[j][i]
@@ -0,0 +1,79 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package multiheadattention
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/internal/nntest"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestModel_RelativePositionBias(t *testing.T) {
+	model := New(4, 2, false, WithRelativePositionBias(RelativePositionBiasConfig{
+		NumOfBuckets:  8,
+		MaxDistance:   16,
+		Bidirectional: true,
+	}))
+	nntest.InitRandom(model, 1)
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+	ys := proc.Forward(nntest.NewInputs(g, 3, 4, 2)...)
+	g.Backward(g.ReduceSum(g.Add(g.Add(g.Square(ys[0]), g.Square(ys[1])), g.Square(ys[2]))))
+
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if !param.HasGrad() {
+			t.Errorf("Param %s has no gradients", param.Name())
+		}
+	})
+	// the relative positions range in [-2, 2], so only 5 buckets are used
+	for _, head := range model.RelativePositionBias.Heads {
+		used := 0
+		for _, v := range head.Grad().Data() {
+			if v != 0 {
+				used++
+			}
+		}
+		if used != 5 {
+			t.Errorf("Expected gradients for 5 buckets, got %d", used)
+		}
+	}
+}
+
+func TestModel_RotaryEmbeddings(t *testing.T) {
+	plain := New(4, 2, true)
+	nntest.InitRandom(plain, 1)
+	rotary := New(4, 2, true, WithRotaryEmbeddings(10))
+	nntest.InitRandom(rotary, 1)
+
+	g := ag.NewGraph()
+	xs := nntest.NewInputs(g, 3, 4, 2)
+	ys1 := plain.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(xs...)
+	ys2 := rotary.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(xs...)
+
+	// with the causal mask, the first position attends only to itself, regardless of the rotation
+	if !floats.EqualApprox(ys1[0].Value().Data(), ys2[0].Value().Data(), 1.0e-9) {
+		t.Error("The first position doesn't match the expected values")
+	}
+	if floats.EqualApprox(ys1[2].Value().Data(), ys2[2].Value().Data(), 1.0e-9) {
+		t.Error("The rotary embeddings don't affect the output")
+	}
+}
+
+func TestAlibiMask(t *testing.T) {
+	mask := alibiMask(0.5, 2, 3)
+	if !floats.Equal(mask.Data(), []float64{
+		0, -0.5, -1.0,
+		-0.5, 0, -0.5,
+	}) {
+		t.Error("The ALiBi mask doesn't match the expected values")
+	}
+	model := New(4, 2, false, WithALiBi())
+	if !floats.Equal(model.alibiSlopes, []float64{0.0625, 0.00390625}) {
+		t.Error("The ALiBi slopes don't match the expected values")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package multiheadattention
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/encoding/pe"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.Model     = &RelativePositionBias{}
+	_ nn.Processor = &RelativePositionBiasProcessor{}
+)
+
+// RelativePositionBias is the learned relative position bias of T5 (Raffel et al., 2019).
+// Each head learns a scalar bias for each bucket of relative positions (see pe.RelativePositionBucket),
+// which is added to the attention score of a query for a key.
+type RelativePositionBias struct {
+	RelativePositionBiasConfig
+	// Heads contains the biases of each head, one for each bucket.
+	Heads []*nn.Param `type:"weights"`
+}
+
+// RelativePositionBiasConfig provides configuration settings for a RelativePositionBias.
+type RelativePositionBiasConfig struct {
+	NumOfBuckets  int
+	MaxDistance   int
+	Bidirectional bool
+}
+
+// NewRelativePositionBias returns a new RelativePositionBias for the given number of heads,
+// with parameters initialized to zeros.
+func NewRelativePositionBias(numOfHeads int, config RelativePositionBiasConfig) *RelativePositionBias {
+	heads := make([]*nn.Param, numOfHeads)
+	for i := range heads {
+		heads[i] = nn.NewParam(mat.NewEmptyVecDense(config.NumOfBuckets))
+	}
+	return &RelativePositionBias{
+		RelativePositionBiasConfig: config,
+		Heads:                      heads,
+	}
+}
+
+// RelativePositionBiasProcessor implements the nn.Processor interface for a RelativePositionBias.
+type RelativePositionBiasProcessor struct {
+	nn.BaseProcessor
+	heads []ag.Node
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *RelativePositionBias) NewProc(ctx nn.Context) nn.Processor {
+	heads := make([]ag.Node, len(m.Heads))
+	for i, param := range m.Heads {
+		heads[i] = ctx.Graph.NewWrap(param)
+	}
+	return &RelativePositionBiasProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		heads: heads,
+	}
+}
+
+// Bias returns the attention bias of the given head (see nn.ScaledDotProductAttentionWithBias):
+// a node for each query, which is a vector with the bias for each key.
+func (p *RelativePositionBiasProcessor) Bias(head, numOfQueries, numOfKeys int) []ag.Node {
+	config := p.Model.(*RelativePositionBias).RelativePositionBiasConfig
+	bias := make([]ag.Node, numOfQueries)
+	for i := range bias {
+		// the one-hot rows select the bucket of each key, keeping the bias differentiable
+		buckets := mat.NewEmptyDense(numOfKeys, config.NumOfBuckets)
+		for j := 0; j < numOfKeys; j++ {
+			buckets.Set(j, pe.RelativePositionBucket(j-i, config.Bidirectional, config.NumOfBuckets, config.MaxDistance), 1.0)
+		}
+		bias[i] = p.Graph.Mul(p.Graph.NewVariable(buckets, false), p.heads[head])
+	}
+	return bias
+}
+
+// Forward is not implemented for RelativePositionBiasProcessor (it always panics).
+// You should use Bias instead.
+func (p *RelativePositionBiasProcessor) Forward(_ ...ag.Node) []ag.Node {
+	panic("multiheadattention: Forward() not implemented for RelativePositionBias; use Bias() instead.")
+}
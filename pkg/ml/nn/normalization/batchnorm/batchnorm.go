@@ -2,9 +2,24 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+// Package batchnorm implements the Batch Normalization, as introduced by Sergey Ioffe and Christian Szegedy in
+// "Batch Normalization: Accelerating Deep Network Training by Reducing Internal Covariate Shift", 2015
+// (https://arxiv.org/pdf/1502.03167.pdf).
+//
+// In nn.Training mode, the inputs are normalized using the mean and the standard deviation of the current
+// sequence (the batch), and the running statistics of the Model are updated with an exponential moving average:
+//    running = momentum * running + (1 - momentum) * batch
+// In nn.Inference mode, the inputs are normalized using the running statistics, which are left unchanged.
+// In both modes, a small epsilon is added to the variance, so that constant features (e.g. in a batch
+// with a single input) are normalized to zeros:
+//    y = (x - mean) / sqrt(variance + eps) * w + b
+// The running statistics are parameters of the Model that don't require gradients, so they are saved
+// and loaded along with the other parameters.
 package batchnorm
 
 import (
+	"sync"
+
 	"github.com/nlpodyssey/spago/pkg/mat"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
@@ -22,18 +37,28 @@ type Model struct {
 	Mean     *nn.Param `type:"undefined"`
 	StdDev   *nn.Param `type:"undefined"`
 	Momentum *nn.Param `type:"undefined"`
+	// Eps is added to the variance for numerical stability.
+	Eps float64
+	// mu protects the running statistics from concurrent updates.
+	mu sync.Mutex
 }
 
-const defaultMomentum = 0.9
+const (
+	defaultMomentum = 0.9
+	defaultEps      = 1e-5
+)
 
 // NewWithMomentum returns a new model with supplied size and momentum.
+// The running mean is initialized to zeros and the running standard deviation to ones,
+// so that an untrained model normalizes its inputs with the identity function.
 func NewWithMomentum(size int, momentum float64) *Model {
 	return &Model{
 		W:        nn.NewParam(mat.NewInitVecDense(size, 1.0)),
 		B:        nn.NewParam(mat.NewEmptyVecDense(size)),
 		Mean:     nn.NewParam(mat.NewEmptyVecDense(size), nn.RequiresGrad(false)),
-		StdDev:   nn.NewParam(mat.NewEmptyVecDense(size), nn.RequiresGrad(false)),
+		StdDev:   nn.NewParam(mat.NewInitVecDense(size, 1.0), nn.RequiresGrad(false)),
 		Momentum: nn.NewParam(mat.NewScalar(momentum), nn.RequiresGrad(false)),
+		Eps:      defaultEps,
 	}
 }
 
@@ -66,6 +91,7 @@ func (m *Model) NewProc(ctx nn.Context) nn.Processor {
 }
 
 // Forward performs the forward step for each input and returns the result.
+// In nn.Training mode it also updates the running statistics of the model.
 func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
 	if p.Mode == nn.Training {
 		return p.forwardTraining(xs)
@@ -76,12 +102,13 @@ func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
 func (p *Processor) forwardTraining(xs []ag.Node) []ag.Node {
 	g := p.Graph
 	meanVector := p.Mean(xs)
-	devVector := p.StdDev(meanVector, xs)
-	p.updateBatchNormParameters(meanVector.Value(), devVector.Value())
-	return p.process(g, xs, devVector, meanVector)
+	varVector := p.Variance(meanVector, xs)
+	p.updateBatchNormParameters(meanVector.Value(), varVector.Value().Sqrt())
+	return p.process(g, xs, varVector, meanVector)
 }
 
-func (p *Processor) process(g *ag.Graph, xs []ag.Node, devVector ag.Node, meanVector ag.Node) []ag.Node {
+func (p *Processor) process(g *ag.Graph, xs []ag.Node, varVector ag.Node, meanVector ag.Node) []ag.Node {
+	devVector := g.Sqrt(g.AddScalar(varVector, g.Constant(p.model.Eps)))
 	devVector = g.Div(p.w, devVector)
 	ys := make([]ag.Node, len(xs))
 	for i, x := range xs {
@@ -90,7 +117,11 @@ func (p *Processor) process(g *ag.Graph, xs []ag.Node, devVector ag.Node, meanVe
 	return ys
 }
 
+// updateBatchNormParameters updates the running statistics with the ones of the current batch.
 func (p *Processor) updateBatchNormParameters(meanVector, devVector mat.Matrix) {
+	p.model.mu.Lock()
+	defer p.model.mu.Unlock()
+
 	momentum := p.model.Momentum.Value().Scalar()
 
 	p.model.Mean.ReplaceValue(
@@ -102,9 +133,18 @@ func (p *Processor) updateBatchNormParameters(meanVector, devVector mat.Matrix)
 
 func (p *Processor) forwardInference(xs []ag.Node) []ag.Node {
 	g := p.Graph
-	meanVector := g.NewWrapNoGrad(p.model.Mean)
-	devVector := g.NewWrapNoGrad(p.model.StdDev)
-	return p.process(g, xs, devVector, meanVector)
+	mean, dev := p.model.runningStats()
+	meanVector := g.NewVariable(mean, false)
+	varVector := g.NewVariable(dev.Prod(dev), false)
+	return p.process(g, xs, varVector, meanVector)
+}
+
+// runningStats returns the current running mean and standard deviation.
+// Their values are never modified in place, but replaced on each update.
+func (m *Model) runningStats() (mean, dev mat.Matrix) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Mean.Value(), m.StdDev.Value()
 }
 
 // Mean computes the mean of the input.
@@ -119,12 +159,16 @@ func (p *Processor) Mean(xs []ag.Node) ag.Node {
 
 // StdDev computes the standard deviation of the input.
 func (p *Processor) StdDev(meanVector ag.Node, xs []ag.Node) ag.Node {
+	return p.Graph.Sqrt(p.Variance(meanVector, xs))
+}
+
+// Variance computes the variance of the input.
+func (p *Processor) Variance(meanVector ag.Node, xs []ag.Node) ag.Node {
 	g := p.Graph
-	devVector := g.NewVariable(meanVector.Value().ZerosLike(), false)
+	varVector := g.NewVariable(meanVector.Value().ZerosLike(), false)
 	for _, x := range xs {
 		diffVector := g.Square(g.Sub(meanVector, x))
-		devVector = g.Add(devVector, diffVector)
+		varVector = g.Add(varVector, diffVector)
 	}
-	devVector = g.Sqrt(g.DivScalar(devVector, g.NewScalar(float64(len(xs))+1e-10)))
-	return devVector
+	return g.DivScalar(varVector, g.NewScalar(float64(len(xs))+1e-10))
 }
@@ -6,6 +6,7 @@ package batchnorm
 
 import (
 	"io/ioutil"
+	"math"
 	"math/rand"
 	"os"
 	"testing"
@@ -46,17 +47,18 @@ func TestModel_Forward_Params(t *testing.T) {
 			forwardSteps:   1,
 		},
 		{
-			multiplier:     1.0,
+			multiplier:     2.0,
 			momentum:       0.5,
 			expectedAvg:    0.0,
-			expectedStdDev: 0.5,
+			expectedStdDev: 1.5,
 			forwardSteps:   1,
 		},
 		{
-			multiplier:     1.0,
+			shift:          10.0,
+			multiplier:     2.0,
 			momentum:       1.0,
 			expectedAvg:    0.0,
-			expectedStdDev: 0.0,
+			expectedStdDev: 1.0,
 			forwardSteps:   1,
 		},
 		{
@@ -67,17 +69,18 @@ func TestModel_Forward_Params(t *testing.T) {
 			forwardSteps:   1,
 		},
 		{
+			shift:          10.0,
 			multiplier:     1.0,
 			momentum:       0.5,
-			expectedAvg:    0.0,
-			expectedStdDev: 0.5,
+			expectedAvg:    5.0,
+			expectedStdDev: 1.0,
 			forwardSteps:   1,
 		},
 		{
-			multiplier:     1.0,
+			multiplier:     2.0,
 			momentum:       0.5,
 			expectedAvg:    0.0,
-			expectedStdDev: 0.75,
+			expectedStdDev: 1.75,
 			forwardSteps:   2,
 		},
 	}
@@ -116,7 +119,7 @@ func TestModel_Forward_Params(t *testing.T) {
 
 		for i, v := range model.Mean.Value().Data() {
 			if !floats.EqualWithinAbs(v, tt.expectedAvg, 1e-1) {
-				t.Fatalf("Momentum %f Mean %d: expected zero, go %f", tt.momentum, i, v)
+				t.Fatalf("Momentum %f Mean %d: expected %f, got %f", tt.momentum, i, tt.expectedAvg, v)
 			}
 		}
 
@@ -145,6 +148,41 @@ func TestModel_Inference(t *testing.T) {
 
 }
 
+func TestModel_TrainingThenInference(t *testing.T) {
+	model := NewWithMomentum(2, 0.0)
+	data := [][]float64{{1.0, -2.0}, {3.0, 0.0}, {5.0, 2.0}}
+
+	g := ag.NewGraph()
+	xs := make([]ag.Node, len(data))
+	for i, d := range data {
+		xs[i] = g.NewVariable(mat.NewVecDense(d), false)
+	}
+	expected := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).Forward(xs...)
+
+	mean := model.Mean.Value().Clone()
+	stdDev := model.StdDev.Value().Clone()
+
+	g = ag.NewGraph()
+	for i, d := range data {
+		xs[i] = g.NewVariable(mat.NewVecDense(d), false)
+	}
+	// the first input alone would be normalized to zeros using its own statistics
+	y := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(xs[0])
+
+	require.True(t, floats.EqualApprox(y[0].Value().Data(), expected[0].Value().Data(), 1e-6))
+	require.Equal(t, mean.Data(), model.Mean.Value().Data(), "the inference must not update the running mean")
+	require.Equal(t, stdDev.Data(), model.StdDev.Value().Data(), "the inference must not update the running std dev")
+}
+
+func TestModel_InferenceBeforeTraining(t *testing.T) {
+	model := New(3)
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, -2.0, 3.0}), false)
+	y := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(x)
+	// the identity, except for the epsilon added to the variance
+	require.True(t, floats.EqualApprox(y[0].Value().Data(), []float64{1.0, -2.0, 3.0}, 1e-4))
+}
+
 func Test_Serialize(t *testing.T) {
 	model := NewWithMomentum(3, 0.777)
 	model.Mean = nn.NewParam(mat.NewVecDense([]float64{0.0, 0.0, 1.0}))
@@ -181,15 +219,15 @@ func TestModel_Forward(t *testing.T) {
 
 	y := rectify(g, model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).Forward(x1, x2, x3)) // TODO: rewrite tests without activation function
 
-	if !floats.EqualApprox(y[0].Value().Data(), []float64{1.1828427, 0.2, 0.0, 0.0}, 1.0e-06) {
+	if !floats.EqualApprox(y[0].Value().Data(), []float64{1.1828328, 0.2, 0.0, 0.0}, 1.0e-06) {
 		t.Error("The output at position 0 doesn't match the expected values")
 	}
 
-	if !floats.EqualApprox(y[1].Value().Data(), []float64{0.334314, 0.2, 0.0, 0.0}, 1.0e-06) {
+	if !floats.EqualApprox(y[1].Value().Data(), []float64{0.334334, 0.2, 0.0, 0.0}, 1.0e-06) {
 		t.Error("The output at position 1 doesn't match the expected values")
 	}
 
-	if !floats.EqualApprox(y[2].Value().Data(), []float64{1.1828427, 0.2, 0.0, 1.302356}, 1.0e-06) {
+	if !floats.EqualApprox(y[2].Value().Data(), []float64{1.1828328, 0.2, 0.0, 1.302346}, 1.0e-06) {
 		t.Error("The output at position 2 doesn't match the expected values")
 	}
 
@@ -200,15 +238,15 @@ func TestModel_Forward(t *testing.T) {
 	y[2].PropagateGrad(mat.NewVecDense([]float64{0.3, -0.4, 0.7, -0.8}))
 	g.BackwardAll()
 
-	if !floats.EqualApprox(x1.Grad().Data(), []float64{-0.6894291116772131, 0.0, 0.0, 0.1265151774227913}, 1.0e-06) {
+	if !floats.EqualApprox(x1.Grad().Data(), []float64{-0.6894061180431492, 0.0, 0.0, 0.12651715562092156}, 1.0e-06) {
 		t.Error("The x1-gradients don't match the expected values")
 	}
 
-	if !floats.EqualApprox(x2.Grad().Data(), []float64{-1.767774815419898e-11, 0.0, 0.0, -0.09674690039596812}, 1.0e-06) {
+	if !floats.EqualApprox(x2.Grad().Data(), []float64{2.4856424369890107e-06, 0.0, 0.0, -0.09673856361868921}, 1.0e-06) {
 		t.Error("The x2-gradients don't match the expected values")
 	}
 
-	if !floats.EqualApprox(x3.Grad().Data(), []float64{0.6894291116595355, 0.0, 0.0, -0.029768277056219317}, 1.0e-06) {
+	if !floats.EqualApprox(x3.Grad().Data(), []float64{0.6894036323653582, 0.0, 0.0, -0.029778592031628193}, 1.0e-06) {
 		t.Error("The x3-gradients don't match the expected values")
 	}
 
@@ -216,7 +254,7 @@ func TestModel_Forward(t *testing.T) {
 		t.Error("The biases B doesn't match the expected values")
 	}
 
-	if !floats.EqualApprox(model.W.Grad().Data(), []float64{-0.070710, -0.475556, 0.0, -1.102356}, 1.0e-06) {
+	if !floats.EqualApprox(model.W.Grad().Data(), []float64{-0.070708, -0.475549, 0.0, -1.102346}, 1.0e-06) {
 		t.Error("The weights W doesn't match the expected values")
 	}
 }
@@ -235,3 +273,49 @@ func newTestModel() *Model {
 	model.B.Value().SetData([]float64{0.9, 0.2, -0.9, 0.2})
 	return model
 }
+
+func TestModel_SingleInput(t *testing.T) {
+	model := NewWithMomentum(2, 0.0)
+	model.B.Value().SetData([]float64{0.5, -0.5})
+
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, -2.0}), true)
+	y := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).Forward(x)
+	require.True(t, floats.EqualApprox(y[0].Value().Data(), []float64{0.5, -0.5}, 1e-6))
+
+	g.Backward(y[0], ag.OutputGrad(mat.NewVecDense([]float64{1.0, 1.0})))
+	require.True(t, floats.EqualApprox(x.Grad().Data(), []float64{0.0, 0.0}, 1e-6))
+	require.True(t, floats.EqualApprox(model.W.Grad().Data(), []float64{0.0, 0.0}, 1e-6))
+	require.True(t, floats.EqualApprox(model.StdDev.Value().Data(), []float64{0.0, 0.0}, 1e-6))
+
+	// the running standard deviation is zero
+	g = ag.NewGraph()
+	x = g.NewVariable(mat.NewVecDense([]float64{1.001, -2.0}), false)
+	y = model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(x)
+	require.True(t, floats.EqualApprox(y[0].Value().Data(), []float64{0.5 + 0.001/math.Sqrt(1e-5), -0.5}, 1e-6))
+}
+
+func TestModel_ConstantFeature(t *testing.T) {
+	model := NewWithMomentum(2, 0.0)
+	data := [][]float64{{1.0, 3.0}, {2.0, 3.0}, {3.0, 3.0}}
+
+	g := ag.NewGraph()
+	xs := make([]ag.Node, len(data))
+	for i, d := range data {
+		xs[i] = g.NewVariable(mat.NewVecDense(d), true)
+	}
+	ys := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).Forward(xs...)
+	for i, y := range ys {
+		require.InDelta(t, 0.0, y.Value().AtVec(1), 1e-6, "the constant feature must be normalized to zero")
+		g.Backward(y, ag.OutputGrad(mat.NewVecDense([]float64{1.0, float64(i + 1)})))
+	}
+	for _, x := range xs {
+		require.False(t, math.IsNaN(x.Grad().AtVec(1)) || math.IsInf(x.Grad().AtVec(1), 0))
+	}
+	require.False(t, math.IsNaN(model.W.Grad().AtVec(1)))
+
+	g = ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]float64{2.0, 3.0}), false)
+	y := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(x)
+	require.True(t, floats.EqualApprox(y[0].Value().Data(), []float64{0.0, 0.0}, 1e-6))
+}
@@ -348,7 +348,7 @@ func NewPayloadUnmarshalBinaryFrom(r io.Reader) (*Payload, int, error) {
 	}
 	data := make([]mat.Matrix, h.Size)
 	nn, err := mat.NewUnmarshalBinarySlice(data, r)
-	n = +nn
+	n += nn
 	if err != nil {
 		return nil, n, err
 	}
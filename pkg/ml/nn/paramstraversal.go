@@ -15,9 +15,18 @@ import (
 // The given callback is invoked for each parameter of the Model.
 // If exploreSubModels is true, every nested Model and its parameters are
 // also visited.
+//
+// The traversal keeps track of the path of each parameter and of each nested Model, which is made of
+// the names of the fields separated by dots (e.g. "Layers[0].W"), with the indices of slices in
+// square brackets and the keys of maps after a dot. The paths are passed to the optional callbacks
+// pathCallback and modelCallback.
 type paramsTraversal struct {
 	callback         func(param *Param)
 	exploreSubModels bool
+	// pathCallback, if not nil, is invoked for each parameter with its path.
+	pathCallback func(param *Param, path string)
+	// modelCallback, if not nil, is invoked for each nested Model with its path, before visiting it.
+	modelCallback func(m Model, path string)
 }
 
 // newParamsTraversal returns a new paramsTraversal.
@@ -28,71 +37,101 @@ func newParamsTraversal(callback func(param *Param), exploreSubModels bool) para
 	}
 }
 
+// newParamsTraversalWithPath returns a new paramsTraversal which explores the sub-models, invoking the
+// callbacks with the path of each parameter and of each nested Model. The modelCallback can be nil.
+func newParamsTraversalWithPath(
+	callback func(param *Param, path string),
+	modelCallback func(m Model, path string),
+) paramsTraversal {
+	return paramsTraversal{
+		exploreSubModels: true,
+		pathCallback:     callback,
+		modelCallback:    modelCallback,
+	}
+}
+
 // walk iterates through all the parameters of m.
-// TODO: don't loop the field every time, use a lazy initialized "params list" instead
 func (pt paramsTraversal) walk(m interface{}) {
+	pt.walkWithPrefix(m, "")
+}
+
+// walkWithPrefix iterates through all the parameters of m, whose path starts with the given prefix.
+// TODO: don't loop the field every time, use a lazy initialized "params list" instead
+func (pt paramsTraversal) walkWithPrefix(m interface{}, prefix string) {
 	utils.ForEachField(m, func(field interface{}, name string, tag reflect.StructTag) {
+		path := prefix + name
 		switch item := field.(type) {
 		case *Param:
-			pt.walkParam(item, name, tag)
+			pt.walkParam(item, name, tag, path)
 		case Model:
-			pt.walkModel(item)
+			pt.walkModel(item, path)
 		case []*Param:
-			pt.walkParamSlice(item, name, tag)
+			pt.walkParamSlice(item, name, tag, path)
 		case []Model:
-			pt.walkModelSlice(item)
+			pt.walkModelSlice(item, path)
 		default:
 			v := reflect.ValueOf(item)
 			switch v.Kind() {
 			case reflect.Slice:
-				pt.walkGenericSlice(v, tag, item)
+				pt.walkGenericSlice(v, tag, path)
 			case reflect.Map:
-				pt.walkGenericMap(v, name, tag)
+				pt.walkGenericMap(v, name, tag, path)
 			case reflect.Struct, reflect.Ptr:
-				pt.walkGenericStructOrPtr(tag, item)
+				pt.walkGenericStructOrPtr(tag, item, path)
 			}
 		}
 	})
 }
 
-func (pt paramsTraversal) walkParam(item *Param, name string, tag reflect.StructTag) {
+func (pt paramsTraversal) visitParam(item *Param, path string) {
+	if pt.callback != nil {
+		pt.callback(item)
+	}
+	if pt.pathCallback != nil {
+		pt.pathCallback(item, path)
+	}
+}
+
+func (pt paramsTraversal) walkParam(item *Param, name string, tag reflect.StructTag, path string) {
 	if item.name == "" {
 		item.name = strings.ToLower(name)
 	}
 	item.pType = ToType(tag.Get("type"))
-	pt.callback(item)
+	pt.visitParam(item, path)
 }
 
-func (pt paramsTraversal) walkModel(item Model) {
+func (pt paramsTraversal) walkModel(item Model, path string) {
 	if pt.exploreSubModels {
-		pt.walk(item)
+		if pt.modelCallback != nil {
+			pt.modelCallback(item, path)
+		}
+		pt.walkWithPrefix(item, path+".")
 	}
 }
 
-func (pt paramsTraversal) walkParamSlice(item []*Param, name string, tag reflect.StructTag) {
-	for _, p := range item {
+func (pt paramsTraversal) walkParamSlice(item []*Param, name string, tag reflect.StructTag, path string) {
+	for i, p := range item {
 		if p.name == "" {
 			p.name = strings.ToLower(name)
 		}
 		p.pType = ToType(tag.Get("type"))
-		pt.callback(p)
+		pt.visitParam(p, fmt.Sprintf("%s[%d]", path, i))
 	}
 }
 
-func (pt paramsTraversal) walkModelSlice(item []Model) {
-	if pt.exploreSubModels {
-		for _, m := range item {
-			pt.walk(m)
-		}
+func (pt paramsTraversal) walkModelSlice(item []Model, path string) {
+	for i, m := range item {
+		pt.walkModel(m, fmt.Sprintf("%s[%d]", path, i))
 	}
 }
 
-func (pt paramsTraversal) walkGenericSlice(v reflect.Value, tag reflect.StructTag, item interface{}) {
+func (pt paramsTraversal) walkGenericSlice(v reflect.Value, tag reflect.StructTag, path string) {
 	length := v.Len()
 	for i := 0; i < length; i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
 		if m, ok := v.Index(i).Interface().(Model); ok {
 			if pt.exploreSubModels {
-				pt.walk(m)
+				pt.walkModel(m, itemPath)
 			} else {
 				return // skip
 			}
@@ -101,7 +140,7 @@ func (pt paramsTraversal) walkGenericSlice(v reflect.Value, tag reflect.StructTa
 			switch p.Kind() {
 			case reflect.Struct, reflect.Ptr:
 				if tag.Get("type") == "params" {
-					pt.walk(p.Interface())
+					pt.walkWithPrefix(p.Interface(), itemPath+".")
 				} else {
 					return // skip
 				}
@@ -112,7 +151,7 @@ func (pt paramsTraversal) walkGenericSlice(v reflect.Value, tag reflect.StructTa
 	}
 }
 
-func (pt paramsTraversal) walkGenericMap(v reflect.Value, name string, tag reflect.StructTag) {
+func (pt paramsTraversal) walkGenericMap(v reflect.Value, name string, tag reflect.StructTag, path string) {
 	mapRange := v.MapRange()
 	for mapRange.Next() {
 		key := ""
@@ -133,12 +172,12 @@ func (pt paramsTraversal) walkGenericMap(v reflect.Value, name string, tag refle
 			p.name = strings.ToLower(fmt.Sprintf("%s.%s", name, key))
 		}
 		p.pType = ToType(tag.Get("type"))
-		pt.callback(p)
+		pt.visitParam(p, fmt.Sprintf("%s.%s", path, key))
 	}
 }
 
-func (pt paramsTraversal) walkGenericStructOrPtr(tag reflect.StructTag, item interface{}) {
+func (pt paramsTraversal) walkGenericStructOrPtr(tag reflect.StructTag, item interface{}, path string) {
 	if tag.Get("type") == "params" {
-		pt.walk(item)
+		pt.walkWithPrefix(item, path+".")
 	}
 }
@@ -0,0 +1,117 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pooling
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.Model     = &GlobalMaxPooling{}
+	_ nn.Model     = &GlobalMeanPooling{}
+	_ nn.Processor = &GlobalProcessor{}
+)
+
+// GlobalMaxPooling is a parameter-free model used to instantiate a new GlobalProcessor.
+// It reduces a whole sequence of nodes to a single node, taking the element-wise max.
+type GlobalMaxPooling struct{}
+
+// NewGlobalMax returns a new model.
+func NewGlobalMax() *GlobalMaxPooling {
+	return &GlobalMaxPooling{}
+}
+
+// GlobalMeanPooling is a parameter-free model used to instantiate a new GlobalProcessor.
+// It reduces a whole sequence of nodes to a single node, taking the element-wise mean.
+type GlobalMeanPooling struct{}
+
+// NewGlobalMean returns a new model.
+func NewGlobalMean() *GlobalMeanPooling {
+	return &GlobalMeanPooling{}
+}
+
+// GlobalProcessor implements the nn.Processor interface for a global pooling Model.
+type GlobalProcessor struct {
+	nn.BaseProcessor
+	pooling func(g *ag.Graph, xs ...ag.Node) ag.Node
+}
+
+func newGlobalProcessor(m nn.Model, ctx nn.Context, pooling func(g *ag.Graph, xs ...ag.Node) ag.Node) *GlobalProcessor {
+	return &GlobalProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		pooling: pooling,
+	}
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *GlobalMaxPooling) NewProc(ctx nn.Context) nn.Processor {
+	return newGlobalProcessor(m, ctx, Max)
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *GlobalMeanPooling) NewProc(ctx nn.Context) nn.Processor {
+	return newGlobalProcessor(m, ctx, Mean)
+}
+
+// Forward performs the forward step over the whole sequence and returns a single pooled node.
+func (p *GlobalProcessor) Forward(xs ...ag.Node) []ag.Node {
+	return []ag.Node{p.pooling(p.Graph, xs...)}
+}
+
+// ForwardWithMask performs the forward step considering only the elements of the
+// sequence whose corresponding mask value is true (e.g. skipping the padding).
+func (p *GlobalProcessor) ForwardWithMask(mask []bool, xs ...ag.Node) []ag.Node {
+	return []ag.Node{p.pooling(p.Graph, selectMasked(mask, xs)...)}
+}
+
+// Max returns the element-wise max of a sequence of nodes of the same dimensions.
+// It panics if the sequence is empty.
+func Max(g *ag.Graph, xs ...ag.Node) ag.Node {
+	if len(xs) == 1 {
+		return xs[0]
+	}
+	return g.MaxSeq(xs...)
+}
+
+// Mean returns the element-wise mean of a sequence of nodes of the same dimensions.
+// It panics if the sequence is empty.
+func Mean(g *ag.Graph, xs ...ag.Node) ag.Node {
+	return g.Mean(xs)
+}
+
+// MaskedMean returns the element-wise mean of the nodes whose corresponding mask value is true.
+// It is useful to pool padded sequences, where the padding must not contribute to the result.
+// It panics if the mask and the sequence have different lengths, or if no element is selected.
+func MaskedMean(g *ag.Graph, xs []ag.Node, mask []bool) ag.Node {
+	return g.Mean(selectMasked(mask, xs))
+}
+
+// MaskedMax returns the element-wise max of the nodes whose corresponding mask value is true.
+// It panics if the mask and the sequence have different lengths, or if no element is selected.
+func MaskedMax(g *ag.Graph, xs []ag.Node, mask []bool) ag.Node {
+	return Max(g, selectMasked(mask, xs)...)
+}
+
+func selectMasked(mask []bool, xs []ag.Node) []ag.Node {
+	if len(mask) != len(xs) {
+		panic("pooling: the mask and the sequence must have the same length")
+	}
+	selected := make([]ag.Node, 0, len(xs))
+	for i, x := range xs {
+		if mask[i] {
+			selected = append(selected, x)
+		}
+	}
+	if len(selected) == 0 {
+		panic("pooling: the mask does not select any element")
+	}
+	return selected
+}
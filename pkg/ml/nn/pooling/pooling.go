@@ -11,6 +11,9 @@ import (
 
 var (
 	_ nn.Model     = &MaxPooling{}
+	_ nn.Model     = &AvgPooling{}
+	_ nn.Model     = &AdaptiveMaxPooling{}
+	_ nn.Model     = &AdaptiveAvgPooling{}
 	_ nn.Processor = &Processor{}
 )
 
@@ -28,13 +31,60 @@ func NewMax(rows, columns int) *MaxPooling {
 	}
 }
 
+// AvgPooling is a parameter-free model used to instantiate a new Processor.
+// It replaces each non-overlapping window of size Rows × Columns with the mean of its values.
+type AvgPooling struct {
+	Rows    int
+	Columns int
+}
+
+// NewAvg returns a new model.
+func NewAvg(rows, columns int) *AvgPooling {
+	return &AvgPooling{
+		Rows:    rows,
+		Columns: columns,
+	}
+}
+
+// AdaptiveMaxPooling is a parameter-free model used to instantiate a new Processor.
+// Differently from MaxPooling, the size of the windows is derived from each input,
+// so that every output has exactly Rows × Columns elements.
+type AdaptiveMaxPooling struct {
+	Rows    int
+	Columns int
+}
+
+// NewAdaptiveMax returns a new model.
+func NewAdaptiveMax(rows, columns int) *AdaptiveMaxPooling {
+	return &AdaptiveMaxPooling{
+		Rows:    rows,
+		Columns: columns,
+	}
+}
+
+// AdaptiveAvgPooling is a parameter-free model used to instantiate a new Processor.
+// Differently from AvgPooling, the size of the windows is derived from each input,
+// so that every output has exactly Rows × Columns elements.
+type AdaptiveAvgPooling struct {
+	Rows    int
+	Columns int
+}
+
+// NewAdaptiveAvg returns a new model.
+func NewAdaptiveAvg(rows, columns int) *AdaptiveAvgPooling {
+	return &AdaptiveAvgPooling{
+		Rows:    rows,
+		Columns: columns,
+	}
+}
+
 // Processor implements the nn.Processor interface for a pooling Model.
 type Processor struct {
 	nn.BaseProcessor
+	pooling func(x ag.Node) ag.Node
 }
 
-// NewProc returns a new processor to execute the forward step.
-func (m *MaxPooling) NewProc(ctx nn.Context) nn.Processor {
+func newProcessor(m nn.Model, ctx nn.Context, pooling func(x ag.Node) ag.Node) *Processor {
 	return &Processor{
 		BaseProcessor: nn.BaseProcessor{
 			Model:             m,
@@ -42,15 +92,40 @@ func (m *MaxPooling) NewProc(ctx nn.Context) nn.Processor {
 			Graph:             ctx.Graph,
 			FullSeqProcessing: false,
 		},
+		pooling: pooling,
 	}
 }
 
+// NewProc returns a new processor to execute the forward step.
+func (m *MaxPooling) NewProc(ctx nn.Context) nn.Processor {
+	return newProcessor(m, ctx, func(x ag.Node) ag.Node {
+		return ctx.Graph.MaxPooling(x, m.Rows, m.Columns)
+	})
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *AvgPooling) NewProc(ctx nn.Context) nn.Processor {
+	return newProcessor(m, ctx, func(x ag.Node) ag.Node {
+		return ctx.Graph.AvgPooling(x, m.Rows, m.Columns)
+	})
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *AdaptiveMaxPooling) NewProc(ctx nn.Context) nn.Processor {
+	return newProcessor(m, ctx, func(x ag.Node) ag.Node {
+		return ctx.Graph.AdaptiveMaxPooling(x, m.Rows, m.Columns)
+	})
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *AdaptiveAvgPooling) NewProc(ctx nn.Context) nn.Processor {
+	return newProcessor(m, ctx, func(x ag.Node) ag.Node {
+		return ctx.Graph.AdaptiveAvgPooling(x, m.Rows, m.Columns)
+	})
+}
+
 // Forward performs the forward step for each input and returns the result.
-// The max pooling is applied independently to each input.
+// The pooling is applied independently to each input.
 func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
-	m := p.BaseProcessor.Model.(*MaxPooling)
-	pooled := func(x ag.Node) ag.Node {
-		return p.Graph.MaxPooling(x, m.Rows, m.Columns)
-	}
-	return ag.Map(pooled, xs)
+	return ag.Map(p.pooling, xs)
 }
@@ -0,0 +1,96 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pooling
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestAvgPooling_Forward(t *testing.T) {
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+	x := g.NewVariable(mat.NewDense(2, 4, []float64{
+		0.4, 0.1, -0.9, -0.5,
+		-0.4, 0.3, 0.7, -0.3,
+	}), true)
+	y := NewAvg(2, 2).NewProc(ctx).Forward(x)[0]
+
+	if !floats.EqualApprox(y.Value().Data(), []float64{0.1, -0.25}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+}
+
+func TestAdaptiveAvgPooling_Forward(t *testing.T) {
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+	proc := NewAdaptiveAvg(1, 2).NewProc(ctx)
+	x1 := g.NewVariable(mat.NewDense(1, 4, []float64{0.1, 0.3, 0.5, 0.7}), true)
+	x2 := g.NewVariable(mat.NewDense(2, 2, []float64{0.1, 0.2, 0.3, 0.4}), true)
+	ys := proc.Forward(x1, x2)
+
+	if !floats.EqualApprox(ys[0].Value().Data(), []float64{0.2, 0.6}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+	if !floats.EqualApprox(ys[1].Value().Data(), []float64{0.2, 0.3}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+}
+
+func TestGlobalMaxPooling_Forward(t *testing.T) {
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+	x1 := g.NewVariable(mat.NewVecDense([]float64{0.1, 0.8, -0.3}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{0.5, -0.2, 0.0}), true)
+	x3 := g.NewVariable(mat.NewVecDense([]float64{-0.4, 0.1, 0.2}), true)
+	ys := NewGlobalMax().NewProc(ctx).Forward(x1, x2, x3)
+
+	if len(ys) != 1 {
+		t.Fatal("Expected a single output node")
+	}
+	if !floats.EqualApprox(ys[0].Value().Data(), []float64{0.5, 0.8, 0.2}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	g.Backward(ys[0], ag.OutputGrad(mat.NewVecDense([]float64{1.0, 2.0, 3.0})))
+
+	if !floats.EqualApprox(x1.Grad().Data(), []float64{0.0, 2.0, 0.0}, 1.0e-6) {
+		t.Error("The x1-gradients don't match the expected values")
+	}
+	if !floats.EqualApprox(x2.Grad().Data(), []float64{1.0, 0.0, 0.0}, 1.0e-6) {
+		t.Error("The x2-gradients don't match the expected values")
+	}
+	if !floats.EqualApprox(x3.Grad().Data(), []float64{0.0, 0.0, 3.0}, 1.0e-6) {
+		t.Error("The x3-gradients don't match the expected values")
+	}
+}
+
+func TestMaskedMean(t *testing.T) {
+	g := ag.NewGraph()
+
+	x1 := g.NewVariable(mat.NewVecDense([]float64{0.1, 0.8}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{0.5, -0.2}), true)
+	pad := g.NewVariable(mat.NewVecDense([]float64{9.0, 9.0}), true)
+	y := MaskedMean(g, []ag.Node{x1, x2, pad}, []bool{true, true, false})
+
+	if !floats.EqualApprox(y.Value().Data(), []float64{0.3, 0.3}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	g.Backward(y, ag.OutputGrad(mat.NewVecDense([]float64{1.0, 2.0})))
+
+	if !floats.EqualApprox(x1.Grad().Data(), []float64{0.5, 1.0}, 1.0e-6) {
+		t.Error("The x1-gradients don't match the expected values")
+	}
+	if pad.HasGrad() {
+		t.Error("The masked element should not receive gradients")
+	}
+}
@@ -9,13 +9,14 @@ import "github.com/nlpodyssey/spago/pkg/ml/ag"
 
 // PreNorm performs pre-norm residual connections:
 //     y = x + F(Norm(x))
+// The normalization is applied to the input of F, while the residual path is left unnormalized.
 func PreNorm(
 	g *ag.Graph,
 	f func(...ag.Node) []ag.Node,
 	norm func(...ag.Node) []ag.Node,
 	xs ...ag.Node,
 ) []ag.Node {
-	return add(g, xs, norm(f(xs...)...))
+	return add(g, xs, f(norm(xs...)...))
 }
 
 // PostNorm performs post-norm residual connections:
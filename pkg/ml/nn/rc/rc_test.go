@@ -0,0 +1,47 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rc
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+// newTestFunctions returns F(x) = 2x and Norm(x) = x + 1, which allow to tell apart the order of the operations.
+func newTestFunctions(g *ag.Graph) (f, norm func(...ag.Node) []ag.Node) {
+	f = func(xs ...ag.Node) []ag.Node {
+		return ag.Map(func(x ag.Node) ag.Node { return g.ProdScalar(x, g.Constant(2.0)) }, xs)
+	}
+	norm = func(xs ...ag.Node) []ag.Node {
+		return ag.Map(func(x ag.Node) ag.Node { return g.AddScalar(x, g.Constant(1.0)) }, xs)
+	}
+	return
+}
+
+func TestPreNorm(t *testing.T) {
+	g := ag.NewGraph()
+	f, norm := newTestFunctions(g)
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, -2.0}), false)
+
+	// x + F(Norm(x)) = x + 2(x + 1), which differs from x + Norm(F(x)) = x + 2x + 1
+	y := PreNorm(g, f, norm, x)[0]
+	if !floats.EqualApprox(y.Value().Data(), []float64{5.0, -4.0}, 1.0e-12) {
+		t.Errorf("Unexpected output %v", y.Value().Data())
+	}
+}
+
+func TestPostNorm(t *testing.T) {
+	g := ag.NewGraph()
+	f, norm := newTestFunctions(g)
+	x := g.NewVariable(mat.NewVecDense([]float64{1.0, -2.0}), false)
+
+	// Norm(x + F(x)) = 3x + 1
+	y := PostNorm(g, f, norm, x)[0]
+	if !floats.EqualApprox(y.Value().Data(), []float64{4.0, -5.0}, 1.0e-12) {
+		t.Errorf("Unexpected output %v", y.Value().Data())
+	}
+}
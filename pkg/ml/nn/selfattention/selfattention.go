@@ -84,23 +84,44 @@ func (m *Model) NewProc(ctx nn.Context) nn.Processor {
 // Forward performs the forward step for each input and returns the result.
 // It generates the queries, keys and values from the same input xs.
 func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
-	qs := p.query.Forward(xs...)
-	ks := p.key.Forward(xs...)
-	vs := p.value.Forward(xs...)
-	context, prob := nn.ScaledDotProductAttention(p.Graph, qs, ks, vs, p.scaleFactor, p.useCasualMask)
-	p.Attention = &ContextProb{
-		Context: context,
-		Prob:    prob,
-	}
-	return context
+	return p.ForwardWithMask(nil, xs...)
+}
+
+// ForwardWithMask performs the forward step for each input and returns the result,
+// applying the given additive attention mask (see nn.ScaledDotProductAttentionWithMask).
+// If the Model uses the causal mask, it is combined with the given one.
+func (p *Processor) ForwardWithMask(mask mat.Matrix, xs ...ag.Node) []ag.Node {
+	return p.ForwardQKVWithMask(xs, xs, xs, mask)
 }
 
 // ForwardQKV performs the forward step for each input and returns the result.
 func (p *Processor) ForwardQKV(qs []ag.Node, ks []ag.Node, vs []ag.Node) []ag.Node {
-	qsProj := p.query.Forward(qs...)
-	ksProj := p.key.Forward(ks...)
-	vsProj := p.value.Forward(vs...)
-	context, prob := nn.ScaledDotProductAttention(p.Graph, qsProj, ksProj, vsProj, p.scaleFactor, p.useCasualMask)
+	return p.ForwardQKVWithMask(qs, ks, vs, nil)
+}
+
+// ForwardQKVWithMask performs the forward step for each input and returns the result,
+// applying the given additive attention mask (see nn.ScaledDotProductAttentionWithMask).
+// If the Model uses the causal mask, it is combined with the given one.
+func (p *Processor) ForwardQKVWithMask(qs []ag.Node, ks []ag.Node, vs []ag.Node, mask mat.Matrix) []ag.Node {
+	projectedQs, projectedKs, projectedVs := p.Project(qs, ks, vs)
+	return p.Attend(projectedQs, projectedKs, projectedVs, mask, nil)
+}
+
+// Project returns the queries, the keys and the values obtained from the linear projections of qs, ks and vs.
+// Together with Attend, it allows the caller to transform the projections before the attention,
+// such as when applying rotary position embeddings.
+func (p *Processor) Project(qs []ag.Node, ks []ag.Node, vs []ag.Node) (queries, keys, values []ag.Node) {
+	return p.query.Forward(qs...), p.key.Forward(ks...), p.value.Forward(vs...)
+}
+
+// Attend performs the scaled dot-product attention of the already projected queries over the keys
+// and the values, applying the given mask and bias (see nn.ScaledDotProductAttentionWithBias).
+// If the Model uses the causal mask, it is combined with the given one.
+func (p *Processor) Attend(queries, keys, values []ag.Node, mask mat.Matrix, bias []ag.Node) []ag.Node {
+	if p.useCasualMask {
+		mask = nn.CombineMasks(nn.CausalMask(len(queries), len(keys)), mask)
+	}
+	context, prob := nn.ScaledDotProductAttentionWithBias(p.Graph, queries, keys, values, p.scaleFactor, mask, bias)
 	p.Attention = &ContextProb{
 		Context: context,
 		Prob:    prob,
@@ -88,6 +88,28 @@ func TestModel_SelfAttention(t *testing.T) {
 	}
 }
 
+func TestModel_ForwardWithMask(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+
+	x1 := g.NewVariable(mat.NewVecDense([]float64{-0.8, -0.9, -0.9, 1.0}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]float64{0.8, -0.3, 0.5, 0.3}), true)
+	x3 := g.NewVariable(mat.NewVecDense([]float64{-0.2, 0.7, 0.2, 0.4}), true)
+
+	expected := proc.Forward(x1, x2)
+	output := proc.ForwardWithMask(nn.KeyPaddingMask(3, []bool{false, false, true}), x1, x2, x3)
+
+	for i := range expected {
+		if !floats.EqualApprox(output[i].Value().Data(), expected[i].Value().Data(), 1.0e-9) {
+			t.Errorf("The padding affects the output at position %d", i)
+		}
+		if proc.Attention.Prob[i].Data()[2] != 0 {
+			t.Errorf("Position %d attends to the padding", i)
+		}
+	}
+}
+
 func newTestModel() *Model {
 	model := New(Config{
 		InputSize:   4,
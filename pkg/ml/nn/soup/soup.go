@@ -0,0 +1,142 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package soup provides the averaging of the parameters of several models with the same architecture,
+// such as checkpoints of the same training or fine-tunings of the same pre-trained model, as described by
+// Mitchell Wortsman et al. in "Model soups: averaging weights of multiple fine-tuned models improves accuracy
+// without increasing inference time", 2022 (https://arxiv.org/pdf/2203.05482.pdf).
+package soup
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+// Uniform sets the parameters of dst to the average of the parameters of the given models.
+// All the models must have the same architecture of dst, which can also be one of them.
+func Uniform(dst nn.Model, models ...nn.Model) {
+	vectors := make([]*mat.Dense, len(models))
+	for i, m := range models {
+		vectors[i] = nn.DumpParamsVector(m)
+	}
+	nn.LoadParamsVector(dst, average(vectors))
+}
+
+// Greedy sets the parameters of dst to the greedy soup of the given models and returns the indices
+// of the models which are part of it.
+//
+// The models are sorted by their score, given by the evaluate callback (the higher the better, e.g. the accuracy
+// on a held-out set), then each model is added to the soup only if it doesn't decrease the score of the soup.
+// The callback receives dst, whose parameters are set to the soup to evaluate.
+func Greedy(dst nn.Model, models []nn.Model, evaluate func(m nn.Model) float64) []int {
+	if len(models) == 0 {
+		return nil
+	}
+	vectors := make([]*mat.Dense, len(models))
+	for i, m := range models {
+		vectors[i] = nn.DumpParamsVector(m)
+	}
+	scores := make([]float64, len(models))
+	indices := make([]int, len(models))
+	for i, v := range vectors {
+		nn.LoadParamsVector(dst, v)
+		scores[i] = evaluate(dst)
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return scores[indices[i]] > scores[indices[j]]
+	})
+
+	ingredients := []int{indices[0]}
+	best := scores[indices[0]]
+	for _, i := range indices[1:] {
+		nn.LoadParamsVector(dst, average(selectVectors(vectors, append(ingredients, i))))
+		if score := evaluate(dst); score >= best {
+			best = score
+			ingredients = append(ingredients, i)
+		}
+	}
+	nn.LoadParamsVector(dst, average(selectVectors(vectors, ingredients)))
+	return ingredients
+}
+
+func selectVectors(vectors []*mat.Dense, indices []int) []*mat.Dense {
+	selected := make([]*mat.Dense, len(indices))
+	for i, index := range indices {
+		selected[i] = vectors[index]
+	}
+	return selected
+}
+
+func average(vectors []*mat.Dense) *mat.Dense {
+	sum := vectors[0].Clone().(*mat.Dense)
+	for _, v := range vectors[1:] {
+		sum.AddInPlace(v)
+	}
+	return sum.ProdScalarInPlace(1.0 / float64(len(vectors))).(*mat.Dense)
+}
+
+// AverageFiles writes into the dst file the average of the parameters stored in the src files,
+// which are serialized with nn.ParamsSerializer from models with the same architecture.
+// The files are processed one parameter at a time, so the models don't need to fit in memory.
+func AverageFiles(dst string, src ...string) (err error) {
+	if len(src) == 0 {
+		return fmt.Errorf("soup: no files to average")
+	}
+	readers := make([]*bufio.Reader, len(src))
+	for i, filename := range src {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers[i] = bufio.NewReader(f)
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	w := bufio.NewWriter(f)
+
+	for index := 0; ; index++ {
+		matrices := make([]*mat.Dense, len(readers))
+		ended := 0
+		for i, r := range readers {
+			m, n, err := mat.NewUnmarshalBinaryFrom(r)
+			switch {
+			case n == 0 && err != nil:
+				ended++
+			case err != nil:
+				return fmt.Errorf("soup: error reading param %d of %s: %w", index, src[i], err)
+			default:
+				matrices[i] = m
+			}
+		}
+		if ended == len(readers) {
+			break
+		}
+		if ended > 0 {
+			return fmt.Errorf("soup: the files contain a different number of params")
+		}
+		for i, m := range matrices[1:] {
+			if !mat.SameDims(m, matrices[0]) {
+				return fmt.Errorf("soup: param %d of %s has a different shape", index, src[i+1])
+			}
+		}
+		if _, err := mat.MarshalBinaryTo(average(matrices), w); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package soup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestUniform(t *testing.T) {
+	models := []nn.Model{newTestModel(1.0), newTestModel(2.0), newTestModel(6.0)}
+	dst := linear.New(2, 1)
+	Uniform(dst, models...)
+
+	if !floats.EqualApprox(dst.W.Value().Data(), []float64{3.0, -3.0}, 1.0e-9) {
+		t.Error("W doesn't match the expected values")
+	}
+	if !floats.EqualApprox(dst.B.Value().Data(), []float64{0.3}, 1.0e-9) {
+		t.Error("B doesn't match the expected values")
+	}
+}
+
+func TestGreedy(t *testing.T) {
+	models := []nn.Model{newTestModel(2.0), newTestModel(4.0), newTestModel(3.5), newTestModel(100.0)}
+	dst := linear.New(2, 1)
+	// the best soup is the one whose first weight is closer to 3
+	evaluate := func(m nn.Model) float64 {
+		w := m.(*linear.Model).W.Value().Data()[0]
+		return -(w - 3.0) * (w - 3.0)
+	}
+
+	ingredients := Greedy(dst, models, evaluate)
+
+	// 3.5 is the best model; 2 and 4 improve the soup (2.75, then 3.1667), while 100 doesn't
+	if len(ingredients) != 3 || ingredients[0] != 2 || ingredients[1] != 0 || ingredients[2] != 1 {
+		t.Fatalf("unexpected ingredients %v", ingredients)
+	}
+	if !floats.EqualApprox(dst.W.Value().Data(), []float64{9.5 / 3, -9.5 / 3}, 1.0e-9) {
+		t.Error("The soup doesn't match the expected values")
+	}
+}
+
+func TestAverageFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "soup_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var files []string
+	for i, v := range []float64{1.0, 2.0, 6.0} {
+		filename := filepath.Join(dir, string(rune('a'+i)))
+		if err := utils.SerializeToFile(filename, nn.NewParamsSerializer(newTestModel(v))); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, filename)
+	}
+
+	output := filepath.Join(dir, "soup")
+	if err := AverageFiles(output, files...); err != nil {
+		t.Fatal(err)
+	}
+	dst := linear.New(2, 1)
+	if err := utils.DeserializeFromFile(output, nn.NewParamsSerializer(dst)); err != nil {
+		t.Fatal(err)
+	}
+	if !floats.EqualApprox(dst.W.Value().Data(), []float64{3.0, -3.0}, 1.0e-9) {
+		t.Error("W doesn't match the expected values")
+	}
+
+	other := filepath.Join(dir, "other")
+	if err := utils.SerializeToFile(other, nn.NewParamsSerializer(linear.New(3, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := AverageFiles(output, files[0], other); err == nil {
+		t.Error("An error is expected averaging models with different architectures")
+	}
+}
+
+func newTestModel(v float64) *linear.Model {
+	m := linear.New(2, 1)
+	m.W.Value().SetData([]float64{v, -v})
+	m.B.Value().SetData([]float64{v / 10})
+	return m
+}
@@ -0,0 +1,235 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// ModelSummary describes a Model as a tree of sub-models and parameters.
+// It is built by Summary.
+type ModelSummary struct {
+	// Name is the name of the field containing the model ("" for the root).
+	Name string
+	// Type is the type name of the model (e.g. "linear.Model").
+	Type string
+	// Params contains the summaries of the parameters which belong directly to the model.
+	Params []*ParamSummary
+	// SubModels contains the summaries of the nested models.
+	SubModels []*ModelSummary
+	// Count is the number of scalar values of all the parameters of the model, including sub-models.
+	// The parameters shared among several sub-models are counted once.
+	Count int
+	// Memory is the size in bytes of the values of all the parameters of the model, including sub-models.
+	// The parameters shared among several sub-models are counted once.
+	Memory int
+}
+
+// ParamSummary describes a Param and the statistics of its values.
+type ParamSummary struct {
+	// Name is the name of the field containing the parameter.
+	Name         string
+	Rows         int
+	Columns      int
+	Type         ParamsType
+	RequiresGrad bool
+	// Count is the number of scalar values.
+	Count int
+	// Memory is the size in bytes of the values.
+	Memory int
+	// Mean, Std, Min and Max are computed ignoring the NaN values.
+	Mean float64
+	Std  float64
+	Min  float64
+	Max  float64
+	// NaNs is the number of NaN values.
+	NaNs int
+
+	param *Param
+}
+
+// bytesPerValue is the size of a single scalar value of a parameter.
+const bytesPerValue = 8
+
+// Summary walks the given Model and returns the tree of its sub-models and parameters.
+// The traversal follows the same rules of ForEachParam.
+func Summary(m Model) *ModelSummary {
+	root := &ModelSummary{Type: typeName(m)}
+	stack := []modelSummaryEntry{{summary: root}}
+	// owner returns the innermost model on the stack whose path is a prefix of the given path,
+	// discarding the models which have been completely visited.
+	owner := func(path string) modelSummaryEntry {
+		for len(stack) > 1 && !strings.HasPrefix(path, stack[len(stack)-1].prefix) {
+			stack = stack[:len(stack)-1]
+		}
+		return stack[len(stack)-1]
+	}
+	newParamsTraversalWithPath(
+		func(param *Param, path string) {
+			e := owner(path)
+			e.summary.Params = append(e.summary.Params,
+				summarizeParam(strings.TrimPrefix(path, e.prefix), param.Type(), param))
+		},
+		func(m Model, path string) {
+			e := owner(path)
+			sub := &ModelSummary{Name: strings.TrimPrefix(path, e.prefix), Type: typeName(m)}
+			e.summary.SubModels = append(e.summary.SubModels, sub)
+			stack = append(stack, modelSummaryEntry{summary: sub, prefix: path + "."})
+		},
+	).walk(m)
+	root.computeStats()
+	root.computeTotals()
+	return root
+}
+
+// modelSummaryEntry associates a ModelSummary with the prefix of the paths of its parameters and sub-models.
+type modelSummaryEntry struct {
+	summary *ModelSummary
+	prefix  string
+}
+
+func typeName(m interface{}) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "*")
+}
+
+// ForEachParamWithPath iterates all the parameters of a model (including sub-params), passing to the callback
+// also the path of each parameter, which is made of the names of the fields separated by dots
+// (e.g. "Encoder.Layers[0].FFN.Layers[0].W"), as shown by Summary.
+// The parameters shared among several sub-models are visited once for each path.
+func ForEachParamWithPath(m Model, callback func(param *Param, path string)) {
+	newParamsTraversalWithPath(callback, nil).walk(m)
+}
+
+func summarizeParam(name string, pType ParamsType, p *Param) *ParamSummary {
+	rows, cols := p.Value().Dims()
+	s := &ParamSummary{
+		Name:         name,
+		Rows:         rows,
+		Columns:      cols,
+		Type:         pType,
+		RequiresGrad: p.RequiresGrad(),
+		Count:        p.Value().Size(),
+		Memory:       p.Value().Size() * bytesPerValue,
+		param:        p,
+	}
+	return s
+}
+
+// computeStats sets the statistics of the values of all the parameters of the model and of its sub-models.
+func (s *ModelSummary) computeStats() {
+	for _, p := range s.Params {
+		p.computeStats()
+	}
+	for _, sub := range s.SubModels {
+		sub.computeStats()
+	}
+}
+
+func (s *ParamSummary) computeStats() {
+	s.Min, s.Max, s.Mean, s.Std = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+	var sum, sumSquares float64
+	n := 0
+	for _, v := range s.param.Value().Data() {
+		if math.IsNaN(v) {
+			s.NaNs++
+			continue
+		}
+		if n == 0 || v < s.Min {
+			s.Min = v
+		}
+		if n == 0 || v > s.Max {
+			s.Max = v
+		}
+		sum += v
+		sumSquares += v * v
+		n++
+	}
+	if n > 0 {
+		s.Mean = sum / float64(n)
+		s.Std = math.Sqrt(math.Max(sumSquares/float64(n)-s.Mean*s.Mean, 0))
+	}
+}
+
+// computeTotals sets Count and Memory of the model and of its sub-models, returning the set of visited params.
+func (s *ModelSummary) computeTotals() map[*Param]*ParamSummary {
+	visited := make(map[*Param]*ParamSummary)
+	for _, p := range s.Params {
+		visited[p.param] = p
+	}
+	for _, sub := range s.SubModels {
+		for p, ps := range sub.computeTotals() {
+			visited[p] = ps
+		}
+	}
+	s.Count, s.Memory = 0, 0
+	for _, ps := range visited {
+		s.Count += ps.Count
+		s.Memory += ps.Memory
+	}
+	return visited
+}
+
+// WriteTo writes a human-readable representation of the summary to w, one line for each model and parameter.
+func (s *ModelSummary) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tSHAPE\tGRAD\tCOUNT\tMEMORY\tMEAN\tSTD\tMIN\tMAX\tNAN")
+	s.writeTo(tw, 0)
+	fmt.Fprintf(tw, "Total params: %d (%s)\n", s.Count, formatBytes(s.Memory))
+	err := tw.Flush()
+	return cw.n, err
+}
+
+func (s *ModelSummary) writeTo(w io.Writer, depth int) {
+	indent := strings.Repeat("  ", depth)
+	name := s.Name
+	if name == "" {
+		name = "."
+	}
+	fmt.Fprintf(w, "%s%s\t%s\t\t\t%d\t%s\t\t\t\t\t\n", indent, name, s.Type, s.Count, formatBytes(s.Memory))
+	for _, p := range s.Params {
+		fmt.Fprintf(w, "%s  %s\t%s\t%dx%d\t%t\t%d\t%s\t%.4g\t%.4g\t%.4g\t%.4g\t%d\n",
+			indent, p.Name, p.Type, p.Rows, p.Columns, p.RequiresGrad, p.Count, formatBytes(p.Memory),
+			p.Mean, p.Std, p.Min, p.Max, p.NaNs)
+	}
+	for _, sub := range s.SubModels {
+		sub.writeTo(w, depth+1)
+	}
+}
+
+// String returns the human-readable representation of the summary (see WriteTo).
+func (s *ModelSummary) String() string {
+	var sb strings.Builder
+	_, _ = s.WriteTo(&sb)
+	return sb.String()
+}
+
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
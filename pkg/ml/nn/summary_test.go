@@ -0,0 +1,139 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+)
+
+type summaryTestLayer struct {
+	ParamsTraversalBaseModel
+	W *Param `type:"weights"`
+	B *Param `type:"biases"`
+}
+
+type summaryTestModel struct {
+	ParamsTraversalBaseModel
+	Layers []Model
+	Scale  *Param
+}
+
+func TestSummary(t *testing.T) {
+	shared := &summaryTestLayer{
+		W: NewParam(mat.NewDense(2, 2, []float64{1, 2, 3, math.NaN()})),
+		B: NewParam(mat.NewVecDense([]float64{-1, 1}), RequiresGrad(false)),
+	}
+	m := &summaryTestModel{
+		Layers: []Model{shared, shared},
+		Scale:  NewParam(mat.NewScalar(0.5)),
+	}
+
+	s := Summary(m)
+
+	if s.Type != "nn.summaryTestModel" {
+		t.Errorf("unexpected type %q", s.Type)
+	}
+	if s.Count != 7 || s.Memory != 7*bytesPerValue {
+		t.Errorf("the shared params are expected to be counted once, got %d values", s.Count)
+	}
+	if len(s.Params) != 1 || s.Params[0].Name != "Scale" {
+		t.Fatalf("unexpected params %v", s.Params)
+	}
+
+	layers := s.SubModels[1:] // the first one is the embedded base model
+	if len(layers) != 2 || layers[0].Name != "Layers[0]" || layers[1].Name != "Layers[1]" {
+		t.Fatalf("unexpected sub-models %v", s.SubModels)
+	}
+	if layers[0].Count != 6 {
+		t.Errorf("expected 6 values in the layer, got %d", layers[0].Count)
+	}
+
+	w := layers[0].Params[0]
+	if w.Name != "W" || w.Type != Weights || w.Rows != 2 || w.Columns != 2 || !w.RequiresGrad {
+		t.Errorf("unexpected summary of W %+v", w)
+	}
+	if w.NaNs != 1 || w.Mean != 2 || w.Min != 1 || w.Max != 3 || math.Abs(w.Std-math.Sqrt(2.0/3.0)) > 1e-12 {
+		t.Errorf("unexpected statistics of W %+v", w)
+	}
+
+	b := layers[0].Params[1]
+	if b.Type != Biases || b.RequiresGrad {
+		t.Errorf("unexpected summary of B %+v", b)
+	}
+
+	out := s.String()
+	for _, expected := range []string{"Layers[1]", "2x2", "Total params: 7 (56 B)"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the output:\n%s", expected, out)
+		}
+	}
+}
+
+func TestForEachParamWithPath(t *testing.T) {
+	layer := &summaryTestLayer{
+		W: NewParam(mat.NewEmptyDense(2, 2)),
+		B: NewParam(mat.NewEmptyVecDense(2)),
+	}
+	m := &summaryTestModel{
+		Layers: []Model{layer},
+		Scale:  NewParam(mat.NewScalar(0.5)),
+	}
+
+	paths := make(map[string]*Param)
+	ForEachParamWithPath(m, func(param *Param, path string) {
+		paths[path] = param
+	})
+
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 paths, got %v", paths)
+	}
+	if paths["Scale"] != m.Scale || paths["Layers[0].W"] != layer.W || paths["Layers[0].B"] != layer.B {
+		t.Errorf("unexpected paths %v", paths)
+	}
+}
+
+type summaryTestParams struct {
+	W *Param
+}
+
+type summaryTestParamsModel struct {
+	ParamsTraversalBaseModel
+	Gates  []summaryTestParams `type:"params"`
+	Output *summaryTestParams  `type:"params"`
+}
+
+func TestForEachParamWithPath_Params(t *testing.T) {
+	m := &summaryTestParamsModel{
+		Gates: []summaryTestParams{
+			{W: NewParam(mat.NewScalar(1))},
+			{W: NewParam(mat.NewScalar(2))},
+		},
+		Output: &summaryTestParams{W: NewParam(mat.NewScalar(3))},
+	}
+
+	paths := make(map[string]*Param)
+	ForEachParamWithPath(m, func(param *Param, path string) {
+		paths[path] = param
+	})
+
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 paths, got %v", paths)
+	}
+	if paths["Gates[0].W"] != m.Gates[0].W || paths["Gates[1].W"] != m.Gates[1].W || paths["Output.W"] != m.Output.W {
+		t.Errorf("unexpected paths %v", paths)
+	}
+
+	s := Summary(m)
+	if len(s.Params) != 3 || len(s.SubModels) != 1 {
+		t.Fatalf("unexpected summary %v", s)
+	}
+	if s.Params[0].Name != "Gates[0].W" || s.Params[2].Name != "Output.W" || s.Count != 3 {
+		t.Errorf("unexpected summary %v", s)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+var (
+	_ nn.Model     = &DecoderLayer{}
+	_ nn.Processor = &DecoderLayerProcessor{}
+	_ nn.Model     = &Decoder{}
+	_ nn.Processor = &DecoderProcessor{}
+)
+
+// DecoderLayer is a Transformer decoder layer, made of a causal self-attention sub-layer,
+// a cross-attention sub-layer over the encoder output (memory), and a position-wise
+// feed-forward sub-layer.
+type DecoderLayer struct {
+	Config
+	SelfAttention      *multiheadattention.Model
+	SelfAttentionNorm  *layernorm.Model
+	CrossAttention     *multiheadattention.Model
+	CrossAttentionNorm *layernorm.Model
+	FFN                *stack.Model
+	FFNNorm            *layernorm.Model
+}
+
+// NewDecoderLayer returns a new DecoderLayer.
+func NewDecoderLayer(config Config) *DecoderLayer {
+	return &DecoderLayer{
+		Config: config,
+		SelfAttention: multiheadattention.New(
+			config.Size,
+			config.NumOfAttentionHeads,
+			true, // use causal mask
+			config.SelfAttentionOptions...,
+		),
+		SelfAttentionNorm: layernorm.New(config.Size),
+		CrossAttention: multiheadattention.New(
+			config.Size,
+			config.NumOfAttentionHeads,
+			false, // don't use causal mask
+		),
+		CrossAttentionNorm: layernorm.New(config.Size),
+		FFN:                newFFN(config),
+		FFNNorm:            layernorm.New(config.Size),
+	}
+}
+
+// DecoderLayerProcessor implements the nn.Processor interface for a DecoderLayer.
+type DecoderLayerProcessor struct {
+	nn.BaseProcessor
+	SelfAttention      *multiheadattention.Processor
+	SelfAttentionNorm  *layernorm.Processor
+	CrossAttention     *multiheadattention.Processor
+	CrossAttentionNorm *layernorm.Processor
+	FFN                *stack.Processor
+	FFNNorm            *layernorm.Processor
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *DecoderLayer) NewProc(ctx nn.Context) nn.Processor {
+	return &DecoderLayerProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		SelfAttention:      m.SelfAttention.NewProc(ctx).(*multiheadattention.Processor),
+		SelfAttentionNorm:  m.SelfAttentionNorm.NewProc(ctx).(*layernorm.Processor),
+		CrossAttention:     m.CrossAttention.NewProc(ctx).(*multiheadattention.Processor),
+		CrossAttentionNorm: m.CrossAttentionNorm.NewProc(ctx).(*layernorm.Processor),
+		FFN:                m.FFN.NewProc(ctx).(*stack.Processor),
+		FFNNorm:            m.FFNNorm.NewProc(ctx).(*layernorm.Processor),
+	}
+}
+
+// Decode performs the forward step for each input xs, attending to the memory
+// (usually the output of an encoder), and returns the result.
+// The elements of xs and memory marked as padding (true) respectively in the paddingMask
+// and in the memoryPaddingMask are not attended to. Both masks can be nil, meaning that
+// no element is padding.
+func (p *DecoderLayerProcessor) Decode(xs, memory []ag.Node, paddingMask, memoryPaddingMask []bool) []ag.Node {
+	normalizeBefore := p.Model.(*DecoderLayer).NormalizeBefore
+	selfAttention := func(xs ...ag.Node) []ag.Node {
+		return attention(p.SelfAttention, xs, xs, paddingMask)
+	}
+	crossAttention := func(xs ...ag.Node) []ag.Node {
+		return attention(p.CrossAttention, xs, memory, memoryPaddingMask)
+	}
+	ys := residual(p.Graph, normalizeBefore, selfAttention, p.SelfAttentionNorm.Forward, xs)
+	ys = residual(p.Graph, normalizeBefore, crossAttention, p.CrossAttentionNorm.Forward, ys)
+	return residual(p.Graph, normalizeBefore, p.FFN.Forward, p.FFNNorm.Forward, ys)
+}
+
+// Forward is not implemented for DecoderLayerProcessor (it always panics).
+// You should use Decode instead.
+func (p *DecoderLayerProcessor) Forward(_ ...ag.Node) []ag.Node {
+	panic("transformer: Forward() not implemented; use Decode() instead.")
+}
+
+// Decoder is a stack of Transformer decoder layers.
+type Decoder struct {
+	Config
+	Layers []*DecoderLayer
+	// Norm is the optional final normalization, usually required by pre-norm architectures.
+	Norm *layernorm.Model
+}
+
+// DecoderOption allows to configure a new Decoder with your specific needs.
+type DecoderOption func(*Decoder)
+
+// DecoderFinalNorm adds a layer normalization on top of the last layer.
+func DecoderFinalNorm() DecoderOption {
+	return func(m *Decoder) {
+		m.Norm = layernorm.New(m.Size)
+	}
+}
+
+// NewDecoder returns a new Decoder composed of a stack of N identical decoder layers.
+func NewDecoder(config Config, numOfLayers int, opts ...DecoderOption) *Decoder {
+	layers := make([]*DecoderLayer, numOfLayers)
+	for i := range layers {
+		layers[i] = NewDecoderLayer(config)
+	}
+	m := &Decoder{
+		Config: config,
+		Layers: layers,
+		Norm:   nil,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// DecoderProcessor implements the nn.Processor interface for a Decoder.
+type DecoderProcessor struct {
+	nn.BaseProcessor
+	Layers []*DecoderLayerProcessor
+	Norm   *layernorm.Processor
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Decoder) NewProc(ctx nn.Context) nn.Processor {
+	layers := make([]*DecoderLayerProcessor, len(m.Layers))
+	for i, layer := range m.Layers {
+		layers[i] = layer.NewProc(ctx).(*DecoderLayerProcessor)
+	}
+	var norm *layernorm.Processor
+	if m.Norm != nil {
+		norm = m.Norm.NewProc(ctx).(*layernorm.Processor)
+	}
+	return &DecoderProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		Layers: layers,
+		Norm:   norm,
+	}
+}
+
+// Decode performs the forward step for each input xs, attending to the memory
+// (usually the output of an encoder), and returns the result.
+// See DecoderLayerProcessor.Decode for the meaning of the padding masks.
+func (p *DecoderProcessor) Decode(xs, memory []ag.Node, paddingMask, memoryPaddingMask []bool) []ag.Node {
+	ys := xs
+	for _, layer := range p.Layers {
+		ys = layer.Decode(ys, memory, paddingMask, memoryPaddingMask)
+	}
+	if p.Norm != nil {
+		ys = p.Norm.Forward(ys...)
+	}
+	return ys
+}
+
+// Forward is not implemented for DecoderProcessor (it always panics).
+// You should use Decode instead.
+func (p *DecoderProcessor) Forward(_ ...ag.Node) []ag.Node {
+	panic("transformer: Forward() not implemented; use Decode() instead.")
+}
+
+// AuxLoss returns the sum of the load-balancing losses of the mixtures of experts of each layer
+// after the last forward step, or nil if the layers don't use them (see Config.NumOfExperts).
+func (p *DecoderProcessor) AuxLoss() ag.Node {
+	ffns := make([]*stack.Processor, len(p.Layers))
+	for i, layer := range p.Layers {
+		ffns[i] = layer.FFN
+	}
+	return auxLoss(p.Graph, ffns...)
+}
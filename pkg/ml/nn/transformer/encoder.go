@@ -0,0 +1,181 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+var (
+	_ nn.Model     = &EncoderLayer{}
+	_ nn.Processor = &EncoderLayerProcessor{}
+	_ nn.Model     = &Encoder{}
+	_ nn.Processor = &EncoderProcessor{}
+)
+
+// EncoderLayer is a Transformer encoder layer, made of a self-attention sub-layer
+// followed by a position-wise feed-forward sub-layer.
+type EncoderLayer struct {
+	Config
+	SelfAttention     *multiheadattention.Model
+	SelfAttentionNorm *layernorm.Model
+	FFN               *stack.Model
+	FFNNorm           *layernorm.Model
+}
+
+// NewEncoderLayer returns a new EncoderLayer.
+func NewEncoderLayer(config Config) *EncoderLayer {
+	return &EncoderLayer{
+		Config: config,
+		SelfAttention: multiheadattention.New(
+			config.Size,
+			config.NumOfAttentionHeads,
+			false, // don't use causal mask
+			config.SelfAttentionOptions...,
+		),
+		SelfAttentionNorm: layernorm.New(config.Size),
+		FFN:               newFFN(config),
+		FFNNorm:           layernorm.New(config.Size),
+	}
+}
+
+// EncoderLayerProcessor implements the nn.Processor interface for an EncoderLayer.
+type EncoderLayerProcessor struct {
+	nn.BaseProcessor
+	SelfAttention     *multiheadattention.Processor
+	SelfAttentionNorm *layernorm.Processor
+	FFN               *stack.Processor
+	FFNNorm           *layernorm.Processor
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *EncoderLayer) NewProc(ctx nn.Context) nn.Processor {
+	return &EncoderLayerProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		SelfAttention:     m.SelfAttention.NewProc(ctx).(*multiheadattention.Processor),
+		SelfAttentionNorm: m.SelfAttentionNorm.NewProc(ctx).(*layernorm.Processor),
+		FFN:               m.FFN.NewProc(ctx).(*stack.Processor),
+		FFNNorm:           m.FFNNorm.NewProc(ctx).(*layernorm.Processor),
+	}
+}
+
+// Forward performs the forward step for each input and returns the result.
+func (p *EncoderLayerProcessor) Forward(xs ...ag.Node) []ag.Node {
+	return p.Encode(xs, nil)
+}
+
+// Encode performs the forward step for each input and returns the result.
+// The elements marked as padding (true) in the paddingMask are not attended to.
+// The paddingMask can be nil, meaning that no element is padding.
+func (p *EncoderLayerProcessor) Encode(xs []ag.Node, paddingMask []bool) []ag.Node {
+	normalizeBefore := p.Model.(*EncoderLayer).NormalizeBefore
+	selfAttention := func(xs ...ag.Node) []ag.Node {
+		return attention(p.SelfAttention, xs, xs, paddingMask)
+	}
+	ys := residual(p.Graph, normalizeBefore, selfAttention, p.SelfAttentionNorm.Forward, xs)
+	return residual(p.Graph, normalizeBefore, p.FFN.Forward, p.FFNNorm.Forward, ys)
+}
+
+// Encoder is a stack of Transformer encoder layers.
+type Encoder struct {
+	Config
+	Layers []*EncoderLayer
+	// Norm is the optional final normalization, usually required by pre-norm architectures.
+	Norm *layernorm.Model
+}
+
+// EncoderOption allows to configure a new Encoder with your specific needs.
+type EncoderOption func(*Encoder)
+
+// EncoderFinalNorm adds a layer normalization on top of the last layer.
+func EncoderFinalNorm() EncoderOption {
+	return func(m *Encoder) {
+		m.Norm = layernorm.New(m.Size)
+	}
+}
+
+// NewEncoder returns a new Encoder composed of a stack of N identical encoder layers.
+func NewEncoder(config Config, numOfLayers int, opts ...EncoderOption) *Encoder {
+	layers := make([]*EncoderLayer, numOfLayers)
+	for i := range layers {
+		layers[i] = NewEncoderLayer(config)
+	}
+	m := &Encoder{
+		Config: config,
+		Layers: layers,
+		Norm:   nil,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// EncoderProcessor implements the nn.Processor interface for an Encoder.
+type EncoderProcessor struct {
+	nn.BaseProcessor
+	Layers []*EncoderLayerProcessor
+	Norm   *layernorm.Processor
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Encoder) NewProc(ctx nn.Context) nn.Processor {
+	layers := make([]*EncoderLayerProcessor, len(m.Layers))
+	for i, layer := range m.Layers {
+		layers[i] = layer.NewProc(ctx).(*EncoderLayerProcessor)
+	}
+	var norm *layernorm.Processor
+	if m.Norm != nil {
+		norm = m.Norm.NewProc(ctx).(*layernorm.Processor)
+	}
+	return &EncoderProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		Layers: layers,
+		Norm:   norm,
+	}
+}
+
+// Forward performs the forward step for each input and returns the result.
+func (p *EncoderProcessor) Forward(xs ...ag.Node) []ag.Node {
+	return p.Encode(xs, nil)
+}
+
+// Encode performs the forward step for each input and returns the result.
+// The elements marked as padding (true) in the paddingMask are not attended to.
+// The paddingMask can be nil, meaning that no element is padding.
+func (p *EncoderProcessor) Encode(xs []ag.Node, paddingMask []bool) []ag.Node {
+	ys := xs
+	for _, layer := range p.Layers {
+		ys = layer.Encode(ys, paddingMask)
+	}
+	if p.Norm != nil {
+		ys = p.Norm.Forward(ys...)
+	}
+	return ys
+}
+
+// AuxLoss returns the sum of the load-balancing losses of the mixtures of experts of each layer
+// after the last forward step, or nil if the layers don't use them (see Config.NumOfExperts).
+func (p *EncoderProcessor) AuxLoss() ag.Node {
+	ffns := make([]*stack.Processor, len(p.Layers))
+	for i, layer := range p.Layers {
+		ffns[i] = layer.FFN
+	}
+	return auxLoss(p.Graph, ffns...)
+}
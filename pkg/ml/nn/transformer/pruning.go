@@ -0,0 +1,103 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+)
+
+// PruneHeads removes the self-attention heads at the given indices (see multiheadattention.Model.PruneHeads).
+func (m *EncoderLayer) PruneHeads(heads []int) {
+	m.SelfAttention.PruneHeads(heads)
+	m.NumOfAttentionHeads = m.SelfAttention.NumOfHeads()
+}
+
+// PruneFFN removes the hidden neurons of the feed-forward network at the given indices,
+// together with the corresponding rows of the first linear layer and columns of the second one.
+// It returns an error if the feed-forward network is not made of two linear layers (e.g. a mixture of experts).
+func (m *EncoderLayer) PruneFFN(neurons []int) error {
+	kept, err := m.keptNeurons(neurons)
+	if err != nil {
+		return err
+	}
+	in, out, _ := m.ffnLinearLayers()
+	in.SelectOutputs(kept)
+	out.SelectInputs(kept)
+	m.IntermediateSize = len(kept)
+	return nil
+}
+
+// CheckFFNPruning returns the error that PruneFFN would return for the given neurons, without
+// modifying the layer.
+func (m *EncoderLayer) CheckFFNPruning(neurons []int) error {
+	_, err := m.keptNeurons(neurons)
+	return err
+}
+
+// keptNeurons returns the indices of the hidden neurons which are not removed by the pruning of the given ones.
+func (m *EncoderLayer) keptNeurons(neurons []int) ([]int, error) {
+	if _, _, err := m.ffnLinearLayers(); err != nil {
+		return nil, err
+	}
+	removed := make(map[int]bool, len(neurons))
+	for _, i := range neurons {
+		if i < 0 || i >= m.IntermediateSize {
+			return nil, fmt.Errorf("transformer: neuron index %d out of range", i)
+		}
+		removed[i] = true
+	}
+	if len(removed) == m.IntermediateSize {
+		return nil, fmt.Errorf("transformer: cannot prune all the neurons of the feed-forward network")
+	}
+	kept := make([]int, 0, m.IntermediateSize-len(removed))
+	for i := 0; i < m.IntermediateSize; i++ {
+		if !removed[i] {
+			kept = append(kept, i)
+		}
+	}
+	return kept, nil
+}
+
+// NeuronImportance returns the importance of each hidden neuron of the feed-forward network, estimated from
+// the gradients accumulated by the second linear layer, as the absolute value of the first-order change of
+// the loss if the neuron is removed:
+//    I(i) = |Σ W2[:,i] ∘ ∂L/∂W2[:,i]|
+// It returns zeros if the layer has no gradients, and an error if the feed-forward network is not made
+// of two linear layers.
+func (m *EncoderLayer) NeuronImportance() ([]float64, error) {
+	_, out, err := m.ffnLinearLayers()
+	if err != nil {
+		return nil, err
+	}
+	importance := make([]float64, m.IntermediateSize)
+	if !out.W.HasGrad() {
+		return importance, nil
+	}
+	w, gw := out.W.Value(), out.W.Grad()
+	for j := range importance {
+		sum := 0.0
+		for i := 0; i < w.Rows(); i++ {
+			sum += w.At(i, j) * gw.At(i, j)
+		}
+		importance[j] = math.Abs(sum)
+	}
+	return importance, nil
+}
+
+// ffnLinearLayers returns the two linear layers of the feed-forward network.
+func (m *EncoderLayer) ffnLinearLayers() (in, out *linear.Model, err error) {
+	if len(m.FFN.Layers) < 3 {
+		return nil, nil, fmt.Errorf("transformer: unexpected feed-forward network")
+	}
+	in, ok1 := m.FFN.Layers[0].(*linear.Model)
+	out, ok2 := m.FFN.Layers[2].(*linear.Model)
+	if !ok1 || !ok2 {
+		return nil, nil, fmt.Errorf("transformer: unexpected feed-forward network")
+	}
+	return in, out, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/internal/nntest"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"gonum.org/v1/gonum/floats"
+	"math"
+	"testing"
+)
+
+func TestEncoderLayer_PruneHeads(t *testing.T) {
+	model := NewEncoderLayer(newTestConfig(false))
+	nntest.InitRandom(model, 1)
+
+	// zeroing the output columns of a head is equivalent to removing it
+	w := model.SelfAttention.OutputMerge.W.Value()
+	for i := 0; i < w.Rows(); i++ {
+		for j := 0; j < 2; j++ {
+			w.Set(i, j, 0)
+		}
+	}
+	expected := forwardEncoderLayer(model)
+
+	model.PruneHeads([]int{0})
+	if model.NumOfAttentionHeads != 1 || len(model.SelfAttention.Attention) != 1 {
+		t.Fatalf("Expected 1 head, got %d", model.NumOfAttentionHeads)
+	}
+	if rows, cols := model.SelfAttention.OutputMerge.W.Value().Dims(); rows != 4 || cols != 2 {
+		t.Fatalf("Unexpected output merge shape %dx%d", rows, cols)
+	}
+	assertEqualOutputs(t, expected, forwardEncoderLayer(model))
+}
+
+func TestEncoderLayer_PruneFFN(t *testing.T) {
+	model := NewEncoderLayer(newTestConfig(true))
+	nntest.InitRandom(model, 1)
+
+	w2 := model.FFN.Layers[2].(*linear.Model).W.Value()
+	for i := 0; i < w2.Rows(); i++ {
+		w2.Set(i, 1, 0)
+		w2.Set(i, 4, 0)
+	}
+	expected := forwardEncoderLayer(model)
+
+	if err := model.PruneFFN([]int{4, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if model.IntermediateSize != 4 {
+		t.Fatalf("Expected intermediate size 4, got %d", model.IntermediateSize)
+	}
+	if rows, cols := model.FFN.Layers[0].(*linear.Model).W.Value().Dims(); rows != 4 || cols != 4 {
+		t.Fatalf("Unexpected first layer shape %dx%d", rows, cols)
+	}
+	assertEqualOutputs(t, expected, forwardEncoderLayer(model))
+
+	if err := model.PruneFFN([]int{4}); err == nil {
+		t.Error("Expected an error for an index out of range")
+	}
+	if err := model.CheckFFNPruning([]int{0, 1, 2, 3}); err == nil {
+		t.Error("Expected an error pruning all the neurons")
+	}
+	if err := model.CheckFFNPruning([]int{0}); err != nil || model.IntermediateSize != 4 {
+		t.Error("Expected the check to leave the layer unchanged")
+	}
+}
+
+func TestEncoderLayer_Importance(t *testing.T) {
+	model := NewEncoderLayer(newTestConfig(false))
+	nntest.InitRandom(model, 1)
+
+	importance, err := model.NeuronImportance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floats.Equal(importance, make([]float64, 6)) {
+		t.Errorf("Expected zero importance without gradients, got %v", importance)
+	}
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training})
+	ys := proc.Forward(nntest.NewInputs(g, 3, 4, 2)...)
+	g.Backward(g.ReduceSum(g.Square(ys[0])))
+
+	heads := model.SelfAttention.HeadImportance()
+	if len(heads) != 2 || floats.Max(heads) == 0 {
+		t.Errorf("Unexpected head importance %v", heads)
+	}
+	importance, _ = model.NeuronImportance()
+	if len(importance) != 6 || floats.Max(importance) == 0 {
+		t.Errorf("Unexpected neuron importance %v", importance)
+	}
+	// the importance is the first-order estimate of the change of the loss when removing a neuron
+	w2 := model.FFN.Layers[2].(*linear.Model).W
+	expected := 0.0
+	for i := 0; i < w2.Value().Rows(); i++ {
+		expected += w2.Value().At(i, 3) * w2.Grad().At(i, 3)
+	}
+	if !floats.EqualWithinAbs(importance[3], math.Abs(expected), 1.0e-12) {
+		t.Errorf("Expected importance %g, got %g", math.Abs(expected), importance[3])
+	}
+}
+
+func forwardEncoderLayer(model *EncoderLayer) []ag.Node {
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference})
+	return proc.Forward(nntest.NewInputs(g, 3, 4, 2)...)
+}
+
+func assertEqualOutputs(t *testing.T, expected, actual []ag.Node) {
+	t.Helper()
+	for i := range expected {
+		if !floats.EqualApprox(expected[i].Value().Data(), actual[i].Value().Data(), 1.0e-9) {
+			t.Errorf("The output at position %d changed after pruning", i)
+		}
+	}
+}
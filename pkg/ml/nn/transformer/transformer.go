@@ -0,0 +1,118 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package transformer provides reusable building blocks to assemble Transformer architectures:
+encoder and decoder layers made of multi-head attention, position-wise feed-forward networks,
+residual connections and layer normalization, plus the stacks of such layers.
+
+Reference: "Attention Is All You Need" by Ashish Vaswani, Noam Shazeer, Niki Parmar,
+Jakob Uszkoreit, Llion Jones, Aidan N. Gomez, Lukasz Kaiser and Illia Polosukhin (2017)
+(http://papers.nips.cc/paper/7181-attention-is-all-you-need.pdf)
+*/
+package transformer
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/moe"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/rc"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+// Config provides configuration settings for the Transformer layers.
+type Config struct {
+	// Size is the dimension of the input and output vectors.
+	Size int
+	// NumOfAttentionHeads is the number of heads of each multi-head attention.
+	NumOfAttentionHeads int
+	// IntermediateSize is the dimension of the hidden layer of the feed-forward network.
+	IntermediateSize int
+	// IntermediateActivation is the activation function of the feed-forward network.
+	IntermediateActivation ag.OpName
+	// NormalizeBefore sets whether to apply the layer normalization before each sub-layer (pre-norm)
+	// or after the residual connection (post-norm, as in the original Transformer).
+	NormalizeBefore bool
+	// SelfAttentionOptions configures the self-attention of each layer, e.g. to use relative
+	// position encodings (see multiheadattention.WithRelativePositionBias, WithRotaryEmbeddings
+	// and WithALiBi).
+	SelfAttentionOptions []multiheadattention.Option
+	// NumOfExperts, if greater than zero, replaces the feed-forward network of each layer with a sparse
+	// mixture of NumOfExperts feed-forward networks of the same size (see moe.Model).
+	NumOfExperts int
+	// NumOfExpertsPerToken is the number of experts each input is routed to (top-k).
+	NumOfExpertsPerToken int
+	// ExpertCapacityFactor limits the number of inputs each expert can process (see moe.Config).
+	ExpertCapacityFactor float64
+}
+
+// newFFN returns a new position-wise feed-forward network:
+//    FFN(x) = W2 Activation(W1 x + b1) + b2
+// or a stack made of a single mixture of experts of such networks, if config.NumOfExperts > 0.
+func newFFN(config Config) *stack.Model {
+	if config.NumOfExperts > 0 {
+		return stack.New(moe.New(moe.Config{
+			Size:             config.Size,
+			HiddenSize:       config.IntermediateSize,
+			HiddenActivation: config.IntermediateActivation,
+			NumOfExperts:     config.NumOfExperts,
+			TopK:             config.NumOfExpertsPerToken,
+			CapacityFactor:   config.ExpertCapacityFactor,
+		}))
+	}
+	return stack.New(
+		linear.New(config.Size, config.IntermediateSize),
+		activation.New(config.IntermediateActivation),
+		linear.New(config.IntermediateSize, config.Size),
+	)
+}
+
+// residual performs a residual connection around f, using either the pre-norm
+// or the post-norm arrangement.
+func residual(
+	g *ag.Graph,
+	normalizeBefore bool,
+	f func(...ag.Node) []ag.Node,
+	norm func(...ag.Node) []ag.Node,
+	xs []ag.Node,
+) []ag.Node {
+	if normalizeBefore {
+		return rc.PreNorm(g, f, norm, xs...)
+	}
+	return rc.PostNorm(g, f, norm, xs...)
+}
+
+// attention performs the multi-head attention of the queries qs over the keys and values kvs,
+// preventing the queries from attending to the elements of kvs marked as padding (true) in
+// the paddingMask. The paddingMask can be nil, meaning that no element is padding.
+func attention(p *multiheadattention.Processor, qs, kvs []ag.Node, paddingMask []bool) []ag.Node {
+	if paddingMask == nil {
+		return p.ForwardQKV(qs, kvs, kvs)
+	}
+	if len(paddingMask) != len(kvs) {
+		panic("transformer: the padding mask and the sequence must have the same length")
+	}
+	return p.ForwardQKVWithMask(qs, kvs, kvs, nn.KeyPaddingMask(len(qs), paddingMask))
+}
+
+// auxLoss returns the sum of the load-balancing losses of the mixtures of experts among the given
+// feed-forward networks after the last forward step, or nil if there are none.
+func auxLoss(g *ag.Graph, ffns ...*stack.Processor) ag.Node {
+	var loss ag.Node
+	for _, ffn := range ffns {
+		for _, layer := range ffn.Layers {
+			if p, ok := layer.(*moe.Processor); ok && p.AuxLoss != nil {
+				if loss == nil {
+					loss = p.AuxLoss
+				} else {
+					loss = g.Add(loss, p.AuxLoss)
+				}
+			}
+		}
+	}
+	return loss
+}
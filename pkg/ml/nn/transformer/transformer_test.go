@@ -0,0 +1,118 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/internal/nntest"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/moe"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestEncoder_PaddingMask(t *testing.T) {
+	for _, normalizeBefore := range []bool{false, true} {
+		model := NewEncoder(newTestConfig(normalizeBefore), 2, EncoderFinalNorm())
+		nntest.InitRandom(model, 1)
+
+		g := ag.NewGraph()
+		proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*EncoderProcessor)
+		xs := nntest.NewInputs(g, 3, 4, 2)
+
+		unpadded := proc.Forward(xs[:2]...)
+		padded := proc.Encode(xs, []bool{false, false, true})
+
+		if len(padded) != 3 {
+			t.Fatal("Expected an output for each input")
+		}
+		for i := range unpadded {
+			if !floats.EqualApprox(unpadded[i].Value().Data(), padded[i].Value().Data(), 1.0e-9) {
+				t.Errorf("The padding affects the output at position %d (normalizeBefore=%v)", i, normalizeBefore)
+			}
+		}
+	}
+}
+
+func TestDecoder_CausalAttention(t *testing.T) {
+	model := NewDecoder(newTestConfig(false), 2)
+	nntest.InitRandom(model, 1)
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*DecoderProcessor)
+	memory := nntest.NewInputs(g, 3, 4, 2)
+	xs := nntest.NewInputs(g, 3, 4, 3)
+
+	full := proc.Decode(xs, memory, nil, nil)
+	prefix := proc.Decode(xs[:2], memory, nil, nil)
+
+	for i := range prefix {
+		if !floats.EqualApprox(full[i].Value().Data(), prefix[i].Value().Data(), 1.0e-9) {
+			t.Errorf("The output at position %d depends on subsequent positions", i)
+		}
+	}
+
+	paddedMemory := append(append([]ag.Node{}, memory...), nntest.NewInputs(g, 1, 4, 4)...)
+	masked := proc.Decode(xs, paddedMemory, nil, []bool{false, false, false, true})
+	for i := range full {
+		if !floats.EqualApprox(full[i].Value().Data(), masked[i].Value().Data(), 1.0e-9) {
+			t.Errorf("The memory padding affects the output at position %d", i)
+		}
+	}
+}
+
+func TestDecoder_Backward(t *testing.T) {
+	model := NewDecoder(newTestConfig(true), 1, DecoderFinalNorm())
+	nntest.InitRandom(model, 1)
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*DecoderProcessor)
+	memory := nntest.NewInputs(g, 2, 4, 2)
+	ys := proc.Decode(nntest.NewInputs(g, 2, 4, 3), memory, nil, nil)
+	g.Backward(g.ReduceSum(g.Add(g.Square(ys[0]), g.Square(ys[1]))))
+
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if !param.HasGrad() {
+			t.Errorf("Param %s has no gradients", param.Name())
+		}
+	})
+	for _, x := range memory {
+		if !x.HasGrad() {
+			t.Error("The memory has no gradients")
+		}
+	}
+}
+
+func newTestConfig(normalizeBefore bool) Config {
+	return Config{
+		Size:                   4,
+		NumOfAttentionHeads:    2,
+		IntermediateSize:       6,
+		IntermediateActivation: ag.OpGELU,
+		NormalizeBefore:        normalizeBefore,
+	}
+}
+
+func TestEncoder_MixtureOfExperts(t *testing.T) {
+	config := newTestConfig(false)
+	config.NumOfExperts = 3
+	config.NumOfExpertsPerToken = 2
+	model := NewEncoder(config, 2)
+	nntest.InitRandom(model, 1)
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*EncoderProcessor)
+	ys := proc.Forward(nntest.NewInputs(g, 3, 4, 2)...)
+	if proc.AuxLoss() == nil {
+		t.Fatal("Expected an auxiliary loss")
+	}
+	g.Backward(g.Add(g.ReduceSum(g.Square(ys[0])), proc.AuxLoss()))
+
+	for i, layer := range model.Layers {
+		if !layer.FFN.Layers[0].(*moe.Model).Gate.W.HasGrad() {
+			t.Errorf("The gate of layer %d has no gradients", i)
+		}
+	}
+}
@@ -74,24 +74,44 @@ func Conv2D(g *ag.Graph, w, x ag.Node, xStride, yStride int) ag.Node {
 // This method requires that the query, the key and the value vectors have already been obtained from the input sequence.
 // The scaled factor is the square root of the dimension of the key vectors.
 func ScaledDotProductAttention(g *ag.Graph, qs, ks, vs []ag.Node, scaleFactor float64, useCausalMask bool) (context []ag.Node, prob []mat.Matrix) {
+	var mask mat.Matrix
+	if useCausalMask {
+		mask = CausalMask(len(qs), len(ks))
+	}
+	return ScaledDotProductAttentionWithMask(g, qs, ks, vs, scaleFactor, mask)
+}
+
+// ScaledDotProductAttentionWithMask does the same thing as ScaledDotProductAttention, but it sums the given additive
+// attention mask to the attention scores before the softmax (see CausalMask, KeyPaddingMask and BooleanMask).
+// The mask has a row for each query and a column for each key; it can be nil.
+func ScaledDotProductAttentionWithMask(g *ag.Graph, qs, ks, vs []ag.Node, scaleFactor float64, mask mat.Matrix) (context []ag.Node, prob []mat.Matrix) {
+	return ScaledDotProductAttentionWithBias(g, qs, ks, vs, scaleFactor, mask, nil)
+}
+
+// ScaledDotProductAttentionWithBias does the same thing as ScaledDotProductAttentionWithMask, but it also sums
+// the given bias to the attention scores before the softmax. Unlike the mask, the bias can be the result of a
+// computation involving trainable parameters (e.g. a learned relative position bias).
+// The bias has a node for each query, which is a vector with an element for each key; it can be nil.
+func ScaledDotProductAttentionWithBias(g *ag.Graph, qs, ks, vs []ag.Node, scaleFactor float64, mask mat.Matrix, bias []ag.Node) (context []ag.Node, prob []mat.Matrix) {
+	if mask != nil && (mask.Rows() != len(qs) || mask.Columns() != len(ks)) {
+		panic("nn: the attention mask must have a row for each query and a column for each key")
+	}
+	if bias != nil && len(bias) != len(qs) {
+		panic("nn: the attention bias must have a node for each query")
+	}
 	context = make([]ag.Node, len(qs))
 	prob = make([]mat.Matrix, len(qs))
 	keys := g.Stack(ks...)
 	values := g.T(g.Stack(vs...))
 	factor := g.NewScalar(scaleFactor)
-	seqLen := len(qs)
 	for i, q := range qs {
 		attScores := g.ProdScalar(g.Mul(keys, q), factor)
-
-		if useCausalMask {
-			// TODO: use external cache for causal mask?
-			causalMask := make([]float64, seqLen)
-			for k := i + 1; k < len(causalMask); k++ {
-				causalMask[k] = math.Inf(-1)
-			}
-			attScores = g.Add(attScores, g.NewVariable(mat.NewVecDense(causalMask), false))
+		if bias != nil {
+			attScores = g.Add(attScores, bias[i])
+		}
+		if mask != nil {
+			attScores = g.Add(attScores, g.NewVariable(maskRow(mask, i), false))
 		}
-
 		attProb := g.Softmax(attScores)
 		context[i] = g.Mul(values, attProb)
 		prob[i] = attProb.Value()
@@ -99,6 +119,15 @@ func ScaledDotProductAttention(g *ag.Graph, qs, ks, vs []ag.Node, scaleFactor fl
 	return
 }
 
+// maskRow returns the i-th row of the attention mask as a column vector.
+func maskRow(mask mat.Matrix, i int) mat.Matrix {
+	row := make([]float64, mask.Columns())
+	for j := range row {
+		row[j] = mask.At(i, j)
+	}
+	return mat.NewVecDense(row)
+}
+
 // ScaledDotProductAttentionConcurrent does the same thing as ScaledDotProductAttention but processes input concurrently.
 func ScaledDotProductAttentionConcurrent(g *ag.Graph, qs, ks, vs []ag.Node, scaleFactor float64) (context []ag.Node, prob []mat.Matrix) {
 	context = make([]ag.Node, len(qs))
@@ -0,0 +1,113 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vae_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/vae"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/nlp/charlm"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+)
+
+// Example_textVAE trains a VAE on sentence encodings obtained by averaging the hidden states of a
+// character-level language model, then compares the reconstructions of a training sentence and
+// of two random latent vectors with it. A pre-trained charlm model would be loaded from file in practice.
+func Example_textVAE() {
+	sentences := []string{
+		"the cat sat on the mat",
+		"the dog sat on the log",
+		"a cat and a dog",
+		"the mat and the log",
+	}
+	charLM := newCharLM(sentences)
+
+	// the sentence encodings are fixed: only the VAE is trained
+	encodings := make([]mat.Matrix, len(sentences))
+	for i, sentence := range sentences {
+		encodings[i] = encode(charLM, sentence)
+	}
+
+	rndGen := rand.NewLockedRand(42)
+	model := vae.New(vae.Config{
+		InputSize:        charLM.HiddenSize,
+		HiddenSize:       16,
+		LatentSize:       2,
+		HiddenActivation: ag.OpTanh,
+	})
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, rndGen)
+		}
+	})
+	optimizer := gd.NewOptimizer(adam.New(adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8)), nn.NewDefaultParamsIterator(model))
+
+	for epoch := 0; epoch < 300; epoch++ {
+		g := ag.NewGraph(ag.Rand(rand.NewLockedRand(uint64(epoch))))
+		proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*vae.Processor)
+		xs := make([]ag.Node, len(encodings))
+		for i, encoding := range encodings {
+			xs[i] = g.NewVariable(encoding, false)
+		}
+		reconstruction := losses.MSESeq(g, proc.Forward(xs...), xs, false)
+		g.Backward(g.Add(reconstruction, g.ProdScalar(proc.KLDivergence(), g.Constant(0.01))))
+		optimizer.Optimize()
+		optimizer.IncExample()
+	}
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*vae.Processor)
+	x := g.NewVariable(encodings[0], false)
+	reconstructed := proc.Forward(x)[0]
+	generated := proc.Decode(
+		g.NewVariable(mat.NewVecDense([]float64{3.0, 3.0}), false),
+		g.NewVariable(mat.NewVecDense([]float64{-3.0, -3.0}), false),
+	)
+	errorOf := func(y ag.Node) float64 {
+		return losses.MSE(g, y, x, false).ScalarValue()
+	}
+	fmt.Println("reconstruction closer than the generated samples:",
+		errorOf(reconstructed) < errorOf(generated[0]) && errorOf(reconstructed) < errorOf(generated[1]))
+	// Output: reconstruction closer than the generated samples: true
+}
+
+// newCharLM returns a small randomly initialized character-level language model.
+func newCharLM(sentences []string) *charlm.Model {
+	chars := []string{charlm.DefaultUnknownToken, charlm.DefaultSequenceSeparator}
+	seen := make(map[string]bool)
+	for _, sentence := range sentences {
+		for _, c := range strings.Split(sentence, "") {
+			if !seen[c] {
+				seen[c] = true
+				chars = append(chars, c)
+			}
+		}
+	}
+	model := charlm.New(charlm.Config{
+		VocabularySize: len(chars),
+		EmbeddingSize:  8,
+		HiddenSize:     12,
+	})
+	model.Vocabulary = vocabulary.New(chars)
+	charlm.Initialize(model, rand.NewLockedRand(1))
+	return model
+}
+
+// encode returns the average of the recurrent hidden states of the character-level language model.
+func encode(model *charlm.Model, sentence string) mat.Matrix {
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*charlm.Processor)
+	hs := proc.RNN.Forward(proc.GetEmbeddings(strings.Split(sentence, ""))...)
+	return g.Mean(hs).Value()
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vae provides a Variational Autoencoder (VAE), as introduced by Diederik P. Kingma and Max Welling in
+// "Auto-Encoding Variational Bayes", 2014 (https://arxiv.org/pdf/1312.6114.pdf).
+//
+// The encoder maps each input to the mean and the log-variance of a Gaussian distribution over the latent space;
+// during the training a latent vector is sampled from that distribution by means of the reparameterization trick
+// (see ag.Graph.Reparameterize), while in inference the mean is used. The decoder reconstructs the input from
+// the latent vector.
+//
+// The training loss is the sum of the reconstruction loss (e.g. losses.MSE) and of the Kullback-Leibler divergence
+// between the latent distributions and the standard normal prior (see Processor.KLDivergence).
+package vae
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+var (
+	_ nn.Model     = &Model{}
+	_ nn.Processor = &Processor{}
+)
+
+// Config provides configuration settings for a VAE Model.
+type Config struct {
+	InputSize        int
+	HiddenSize       int
+	LatentSize       int
+	HiddenActivation ag.OpName
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	Config
+	Encoder *stack.Model
+	Mean    *linear.Model
+	LogVar  *linear.Model
+	Decoder *stack.Model
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	return &Model{
+		Config: config,
+		Encoder: stack.New(
+			linear.New(config.InputSize, config.HiddenSize),
+			activation.New(config.HiddenActivation),
+		),
+		Mean:   linear.New(config.HiddenSize, config.LatentSize),
+		LogVar: linear.New(config.HiddenSize, config.LatentSize),
+		Decoder: stack.New(
+			linear.New(config.LatentSize, config.HiddenSize),
+			activation.New(config.HiddenActivation),
+			linear.New(config.HiddenSize, config.InputSize),
+		),
+	}
+}
+
+// Processor implements the nn.Processor interface for a VAE Model.
+type Processor struct {
+	nn.BaseProcessor
+	encoder *stack.Processor
+	mean    *linear.Processor
+	logVar  *linear.Processor
+	decoder *stack.Processor
+	// Means contains the means of the latent distributions of the inputs of the last forward step.
+	Means []ag.Node
+	// LogVars contains the log-variances of the latent distributions of the inputs of the last forward step.
+	LogVars []ag.Node
+	// Latents contains the latent vectors of the inputs of the last forward step.
+	Latents []ag.Node
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *Model) NewProc(ctx nn.Context) nn.Processor {
+	return &Processor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: false,
+		},
+		encoder: m.Encoder.NewProc(ctx).(*stack.Processor),
+		mean:    m.Mean.NewProc(ctx).(*linear.Processor),
+		logVar:  m.LogVar.NewProc(ctx).(*linear.Processor),
+		decoder: m.Decoder.NewProc(ctx).(*stack.Processor),
+	}
+}
+
+// Forward performs the forward step for each input and returns its reconstruction.
+func (p *Processor) Forward(xs ...ag.Node) []ag.Node {
+	p.Means, p.LogVars = p.Encode(xs...)
+	p.Latents = make([]ag.Node, len(xs))
+	for i := range xs {
+		if p.Mode == nn.Training {
+			p.Latents[i] = p.Graph.Reparameterize(p.Means[i], p.LogVars[i])
+		} else {
+			p.Latents[i] = p.Means[i]
+		}
+	}
+	return p.Decode(p.Latents...)
+}
+
+// Encode returns the means and the log-variances of the latent distributions of the inputs.
+func (p *Processor) Encode(xs ...ag.Node) (means, logVars []ag.Node) {
+	hs := p.encoder.Forward(xs...)
+	return p.mean.Forward(hs...), p.logVar.Forward(hs...)
+}
+
+// Decode returns the reconstructions of the given latent vectors.
+// It can be used to generate new data from latent vectors sampled from the standard normal distribution.
+func (p *Processor) Decode(zs ...ag.Node) []ag.Node {
+	return p.decoder.Forward(zs...)
+}
+
+// KLDivergence returns the sum of the Kullback-Leibler divergences between the latent distributions
+// of the inputs of the last forward step and the standard normal distribution.
+func (p *Processor) KLDivergence() ag.Node {
+	var kl ag.Node
+	for i, mean := range p.Means {
+		loss := losses.GaussianKLDivergence(p.Graph, mean, p.LogVars[i])
+		if kl == nil {
+			kl = loss
+		} else {
+			kl = p.Graph.Add(kl, loss)
+		}
+	}
+	return kl
+}
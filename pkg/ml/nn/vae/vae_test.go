@@ -0,0 +1,59 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vae
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/internal/nntest"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestModel_Forward(t *testing.T) {
+	model := New(Config{InputSize: 4, HiddenSize: 5, LatentSize: 2, HiddenActivation: ag.OpTanh})
+	nntest.InitRandom(model, 1)
+	x := mat.NewVecDense([]float64{0.3, -0.6, 0.1, 0.8})
+
+	// in inference the latent vector is the mean, so the output is deterministic
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	y := proc.Forward(g.NewVariable(x, false))[0]
+	means, _ := proc.Encode(g.NewVariable(x, false))
+	if !floats.EqualApprox(y.Value().Data(), proc.Decode(means[0])[0].Value().Data(), 1.0e-9) {
+		t.Error("The inference output doesn't match the decoding of the mean")
+	}
+
+	// in training the latent vector is sampled, and every parameter gets gradients
+	g = ag.NewGraph(ag.Rand(rand.NewLockedRand(42)))
+	proc = model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+	input := g.NewVariable(x, false)
+	y = proc.Forward(input)[0]
+	if floats.EqualApprox(proc.Latents[0].Value().Data(), proc.Means[0].Value().Data(), 1.0e-9) {
+		t.Error("Expected the latent vector to be sampled")
+	}
+	g.Backward(g.Add(g.ReduceSum(g.Square(g.Sub(y, input))), proc.KLDivergence()))
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if !param.HasGrad() {
+			t.Errorf("Param %s has no gradients", param.Name())
+		}
+	})
+}
+
+func TestProcessor_KLDivergence(t *testing.T) {
+	model := New(Config{InputSize: 3, HiddenSize: 3, LatentSize: 2, HiddenActivation: ag.OpReLU})
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	proc.Forward(
+		g.NewVariable(mat.NewVecDense([]float64{0.1, 0.2, 0.3}), false),
+		g.NewVariable(mat.NewVecDense([]float64{0.4, 0.5, 0.6}), false),
+	)
+	// with zero parameters the latent distributions are standard normal
+	if kl := proc.KLDivergence().ScalarValue(); kl != 0.0 {
+		t.Errorf("Expected zero divergence, got %f", kl)
+	}
+}
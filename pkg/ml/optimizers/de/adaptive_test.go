@@ -0,0 +1,151 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"gonum.org/v1/gonum/floats"
+	"math"
+	"testing"
+)
+
+func newTestSuccesses() []Success {
+	return []Success{
+		{
+			MemberHyperParams: MemberHyperParams{MutationFactor: 0.6, CrossoverRate: 0.2},
+			Parent:            mat.NewVecDense([]float64{1.0, 2.0}),
+			Improvement:       1.0,
+		},
+		{
+			MemberHyperParams: MemberHyperParams{MutationFactor: 0.8, CrossoverRate: 0.4},
+			Parent:            mat.NewVecDense([]float64{3.0, 4.0}),
+			Improvement:       3.0,
+		},
+	}
+}
+
+func TestCurrentToPBestMutation(t *testing.T) {
+	population := newTestPopulation()
+	mutation := NewCurrentToPBestMutation(0.05, 0.5, 0, rand.NewLockedRand(1))
+	mutation.Mutate(population)
+	for _, member := range population.Members {
+		if member.DonorVector.Size() != member.TargetVector.Size() {
+			t.Fatal("The donor vector doesn't match the size of the target vector")
+		}
+		if member.DonorVector.Max() > 0.5 || member.DonorVector.Abs().Max() > 0.5 {
+			t.Fatal("The donor vector exceeds the bound")
+		}
+	}
+
+	// with identical members the donors are identical too
+	for _, member := range population.Members {
+		member.TargetVector = mat.NewVecDense([]float64{0.1, 0.2, 0.3})
+	}
+	mutation.Archive = &Archive{Size: 2}
+	mutation.Adapt(newTestSuccesses()[:1])
+	mutation.Archive.Vectors[0] = mat.NewVecDense([]float64{0.1, 0.2, 0.3})
+	mutation.Mutate(population)
+	for _, member := range population.Members {
+		if !floats.EqualApprox(member.DonorVector.Data(), []float64{0.1, 0.2, 0.3}, 1.0e-12) {
+			t.Fatalf("Unexpected donor vector %v", member.DonorVector.Data())
+		}
+	}
+}
+
+func TestArchive(t *testing.T) {
+	archive := &Archive{Size: 2}
+	rndGen := rand.NewLockedRand(1)
+	for i := 0; i < 5; i++ {
+		archive.add(mat.NewScalar(float64(i)), rndGen)
+	}
+	if len(archive.Vectors) != 2 {
+		t.Errorf("Expected 2 archived vectors, got %d", len(archive.Vectors))
+	}
+	disabled := &Archive{}
+	disabled.add(mat.NewScalar(1.0), rndGen)
+	if len(disabled.Vectors) != 0 {
+		t.Error("Expected the archive to be disabled")
+	}
+}
+
+func TestJADE_Adapt(t *testing.T) {
+	mutation := NewJADE(0.05, 0.1, 1.0, 10, rand.NewLockedRand(1))
+	mutation.Adapt(newTestSuccesses())
+
+	// Lehmer mean of the mutation factors: (0.36 + 0.64) / 1.4
+	if math.Abs(mutation.MeanMutationFactor-(0.45+0.1/1.4)) > 1.0e-12 {
+		t.Errorf("Unexpected mean mutation factor %f", mutation.MeanMutationFactor)
+	}
+	if math.Abs(mutation.MeanCrossoverRate-0.48) > 1.0e-12 {
+		t.Errorf("Unexpected mean crossover rate %f", mutation.MeanCrossoverRate)
+	}
+	if len(mutation.Archive.Vectors) != 2 {
+		t.Errorf("Expected the parents to be archived")
+	}
+
+	mutation.Adapt(nil)
+	if math.Abs(mutation.MeanCrossoverRate-0.48) > 1.0e-12 {
+		t.Errorf("Expected no adaptation without successes")
+	}
+}
+
+func TestSHADE_Adapt(t *testing.T) {
+	mutation := NewSHADE(2, 1.0, 0, rand.NewLockedRand(1))
+	mutation.Adapt(newTestSuccesses())
+
+	// weights: 0.25, 0.75
+	if !floats.EqualApprox(mutation.MemoryMutationFactors, []float64{0.57 / 0.75, 0.5}, 1.0e-12) {
+		t.Errorf("Unexpected memory of the mutation factors %v", mutation.MemoryMutationFactors)
+	}
+	if !floats.EqualApprox(mutation.MemoryCrossoverRates, []float64{0.35, 0.5}, 1.0e-12) {
+		t.Errorf("Unexpected memory of the crossover rates %v", mutation.MemoryCrossoverRates)
+	}
+	mutation.Adapt(newTestSuccesses()[:1])
+	mutation.Adapt(newTestSuccesses()[1:])
+	if !floats.EqualApprox(mutation.MemoryMutationFactors, []float64{0.8, 0.6}, 1.0e-12) {
+		t.Errorf("Expected the memory to be updated cyclically, got %v", mutation.MemoryMutationFactors)
+	}
+}
+
+func TestSHADE_Mutate(t *testing.T) {
+	population := newTestPopulation()
+	mutation := NewSHADE(5, 6.0, 20, rand.NewLockedRand(1))
+	mutation.Mutate(population)
+	for _, member := range population.Members {
+		if member.MutationFactor <= 0 || member.MutationFactor > 1 {
+			t.Errorf("Mutation factor out of range: %f", member.MutationFactor)
+		}
+		if member.CrossoverRate < 0 || member.CrossoverRate > 1 {
+			t.Errorf("Crossover rate out of range: %f", member.CrossoverRate)
+		}
+		if member.DonorVector.Abs().Max() > 6.0 {
+			t.Error("The donor vector exceeds the bound")
+		}
+	}
+}
+
+func TestSHADE_MarshalBinary(t *testing.T) {
+	mutation := NewSHADE(2, 1.0, 5, rand.NewLockedRand(1))
+	mutation.Adapt(newTestSuccesses())
+	data, err := mutation.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewSHADE(2, 1.0, 5, rand.NewLockedRand(2))
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !floats.Equal(restored.MemoryMutationFactors, mutation.MemoryMutationFactors) ||
+		!floats.Equal(restored.MemoryCrossoverRates, mutation.MemoryCrossoverRates) ||
+		restored.next != mutation.next ||
+		len(restored.Archive.Vectors) != 2 ||
+		!floats.Equal(restored.Archive.Vectors[1].Data(), []float64{3.0, 4.0}) {
+		t.Error("The restored state doesn't match the original one")
+	}
+	if restored.rndGen.Uint64() != mutation.rndGen.Uint64() {
+		t.Error("The restored random generator doesn't match the original one")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"io"
+	"io/ioutil"
+)
+
+var (
+	_ utils.Serializer   = &DifferentialEvolution{}
+	_ utils.Deserializer = &DifferentialEvolution{}
+)
+
+// checkpoint is the serializable state of a DifferentialEvolution.
+type checkpoint struct {
+	CurGeneration           int
+	CurBatch                int
+	CountBestScoreUnchanged int
+	TargetsEvaluated        bool
+	Members                 []memberState
+	Best                    *bestState
+	RandState               []byte
+	MutationState           []byte
+	CrossoverState          []byte
+}
+
+// memberState is the serializable state of a Member.
+type memberState struct {
+	MemberHyperParams
+	TargetVector    []float64
+	TargetScore     float64
+	ValidationScore float64
+}
+
+// bestState is the serializable state of the best solution.
+type bestState struct {
+	Vector []float64
+	Score  float64
+}
+
+// Serialize writes the state of the optimization process to w: the progress, the population, the best
+// solution and the random generator. The state of the mutation and crossover strategies is included
+// if they implement encoding.BinaryMarshaler.
+func (o *DifferentialEvolution) Serialize(w io.Writer) (int, error) {
+	randState, err := o.rndGen.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	state := checkpoint{
+		CurGeneration:           o.state.CurGeneration,
+		CurBatch:                o.state.CurBatch,
+		CountBestScoreUnchanged: o.state.countBestScoreUnchanged,
+		TargetsEvaluated:        o.targetsEvaluated,
+		Members:                 make([]memberState, len(o.population.Members)),
+		RandState:               randState,
+	}
+	for i, member := range o.population.Members {
+		state.Members[i] = memberState{
+			MemberHyperParams: member.MemberHyperParams,
+			TargetVector:      member.TargetVector.Data(),
+			TargetScore:       member.TargetScore,
+			ValidationScore:   member.ValidationScore,
+		}
+	}
+	if o.bestSolution != nil {
+		state.Best = &bestState{Vector: o.bestSolution.Vector.Data(), Score: o.bestSolution.Score}
+	}
+	if state.MutationState, err = marshalStrategy(o.mutation); err != nil {
+		return 0, err
+	}
+	if state.CrossoverState, err = marshalStrategy(o.crossover); err != nil {
+		return 0, err
+	}
+	data, err := encodeGob(state)
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(data)
+}
+
+// Deserialize restores the state of the optimization process written by Serialize.
+// It returns an error if the population doesn't match the configuration.
+func (o *DifferentialEvolution) Deserialize(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return len(data), err
+	}
+	var state checkpoint
+	if err := decodeGob(data, &state); err != nil {
+		return len(data), err
+	}
+	if len(state.Members) != o.PopulationSize {
+		return len(data), fmt.Errorf("de: population size mismatch (expected %d, found %d)",
+			o.PopulationSize, len(state.Members))
+	}
+	members := make([]*Member, len(state.Members))
+	for i, m := range state.Members {
+		if len(m.TargetVector) != o.VectorSize {
+			return len(data), fmt.Errorf("de: vector size mismatch (expected %d, found %d)",
+				o.VectorSize, len(m.TargetVector))
+		}
+		members[i] = NewMember(mat.NewVecDense(m.TargetVector), m.MemberHyperParams)
+		members[i].TargetScore = m.TargetScore
+		members[i].ValidationScore = m.ValidationScore
+	}
+	if err := o.rndGen.UnmarshalBinary(state.RandState); err != nil {
+		return len(data), err
+	}
+	if err := unmarshalStrategy(o.mutation, state.MutationState); err != nil {
+		return len(data), err
+	}
+	if err := unmarshalStrategy(o.crossover, state.CrossoverState); err != nil {
+		return len(data), err
+	}
+	o.population = &Population{Members: members}
+	o.targetsEvaluated = state.TargetsEvaluated
+	o.bestSolution = nil
+	if state.Best != nil {
+		o.bestSolution = &ScoredVector{Vector: mat.NewVecDense(state.Best.Vector), Score: state.Best.Score}
+	}
+	o.state.CurGeneration = state.CurGeneration
+	o.state.CurBatch = state.CurBatch
+	o.state.CurOptimizationStep = 0
+	o.state.countBestScoreUnchanged = state.CountBestScoreUnchanged
+	return len(data), nil
+}
+
+// saveCheckpoint saves the state of the optimization process to the CheckpointPath, if defined.
+func (o *DifferentialEvolution) saveCheckpoint() error {
+	if o.CheckpointPath == "" {
+		return nil
+	}
+	return utils.SerializeToFile(o.CheckpointPath, o)
+}
+
+// Resume restores the state of the optimization process from the CheckpointPath, so that the next call
+// to Optimize continues from the first batch not yet processed.
+func (o *DifferentialEvolution) Resume() error {
+	return utils.DeserializeFromFile(o.CheckpointPath, o)
+}
+
+// marshalStrategy returns the binary representation of the strategy, or nil if it is stateless.
+func marshalStrategy(strategy interface{}) ([]byte, error) {
+	if m, ok := strategy.(encoding.BinaryMarshaler); ok {
+		return m.MarshalBinary()
+	}
+	return nil, nil
+}
+
+// unmarshalStrategy restores the state of the strategy, if any.
+func unmarshalStrategy(strategy interface{}, data []byte) error {
+	if m, ok := strategy.(encoding.BinaryUnmarshaler); ok && data != nil {
+		return m.UnmarshalBinary(data)
+	}
+	return nil
+}
@@ -44,3 +44,13 @@ func (c *BinomialCrossover) Crossover(p *Population) {
 		}
 	}
 }
+
+// MarshalBinary returns the binary representation of the state of the random generator.
+func (c *BinomialCrossover) MarshalBinary() ([]byte, error) {
+	return c.rndGen.MarshalBinary()
+}
+
+// UnmarshalBinary restores the state of the random generator from the representation returned by MarshalBinary.
+func (c *BinomialCrossover) UnmarshalBinary(data []byte) error {
+	return c.rndGen.UnmarshalBinary(data)
+}
@@ -5,9 +5,12 @@
 package de
 
 import (
+	"fmt"
 	"github.com/nlpodyssey/spago/pkg/mat"
 	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/utils/workerpool"
 	"math"
+	"sync"
 )
 
 // DifferentialEvolution implements a simple and efficient heuristic for global optimization over continuous spaces.
@@ -23,7 +26,7 @@ type DifferentialEvolution struct {
 	crossover Crossover
 	// The fitness function to minimize
 	fitnessFunc func(solution *mat.Dense, batch int) float64
-	// The validation function to maximize
+	// The validation function to maximize (can be nil)
 	validate func(solution *mat.Dense) float64
 	// Method to call after finding a new best solution
 	onNewBest func(solution *ScoredVector)
@@ -31,6 +34,12 @@ type DifferentialEvolution struct {
 	bestSolution *ScoredVector
 	// Optimization state
 	state *State
+	// The random generator of the population
+	rndGen *rand.LockedRand
+	// The pool of workers evaluating the members (nil if the evaluation is sequential)
+	pool *workerpool.WorkerPool
+	// Whether the target scores of the population refer to the current batch
+	targetsEvaluated bool
 }
 
 // State represents a status of the differential evolution process.
@@ -67,10 +76,21 @@ type Config struct {
 	Bound float64
 	// Whether to alter the mutation factor and the crossover rate on the Trial evaluation
 	Adaptive bool
-	// Reset the population if the best solution remains unchanged after for this long
+	// Reset the population if the best solution remains unchanged for this number of consecutive batches
+	// (0 disables the reset)
 	ResetAfter int
-	// The random seed.
+	// Whether the target vectors are evaluated again at each batch even if the fitness doesn't depend on it
+	// (i.e. BatchSize is 1), as required by noisy fitness functions. Otherwise, the target scores are reused,
+	// being updated on selection.
+	ReevaluateTargets bool
+	// The random seed of the population, which is drawn from the same generator at each reset.
 	Seed uint64
+	// The number of members evaluated concurrently. If it is greater than 1, the fitness and the validation
+	// functions must be safe for concurrent use.
+	Workers int
+	// The file where the state of the optimization is saved at the end of each batch, so that it can be
+	// resumed (see Resume). If empty, it is not saved.
+	CheckpointPath string
 }
 
 // ScoredVector is a pair which associates a Score to a Vector corresponding to a specific solution.
@@ -80,6 +100,7 @@ type ScoredVector struct {
 }
 
 // NewOptimizer returns a new DifferentialEvolution ready to optimize your problem.
+// If validate is nil, the validation score of a solution is the opposite of its fitness.
 func NewOptimizer(
 	config Config,
 	mutation Mutator,
@@ -88,18 +109,20 @@ func NewOptimizer(
 	validate func(solution *mat.Dense) float64,
 	onNewBest func(solution *ScoredVector),
 ) *DifferentialEvolution {
+	rndGen := rand.NewLockedRand(config.Seed)
 	return &DifferentialEvolution{
 		Config: config,
 		population: NewRandomPopulation(
 			config.PopulationSize,
 			config.VectorSize,
 			config.Bound,
-			rand.NewLockedRand(config.Seed),
+			rndGen,
 			MemberHyperParams{
 				MutationFactor: config.MutationFactor,
 				CrossoverRate:  config.CrossoverRate,
 				WeightFactor:   config.WeightFactor,
 			}),
+		rndGen:       rndGen,
 		mutation:     mutation,
 		crossover:    crossover,
 		fitnessFunc:  score,
@@ -116,14 +139,79 @@ func NewOptimizer(
 }
 
 // Optimize performs the Differential Evolution optimization process.
+// The process continues from the current generation and batch, e.g. after a call to Resume.
+// Once all the generations have been performed, a further call starts again from generation 0,
+// evolving the current population.
+// If the CheckpointPath is defined, the state of the optimization is saved at the end of each batch.
 func (o *DifferentialEvolution) Optimize() {
-	for g := 0; g < o.MaxGenerations; g++ {
-		o.state.CurGeneration = g
-		for batch := 0; batch < o.BatchSize; batch++ {
-			o.state.CurBatch = batch
-			o.optimizeBatch()
+	if o.state.CurGeneration >= o.MaxGenerations {
+		o.state.CurGeneration = 0
+		o.state.CurBatch = 0
+	}
+	o.startWorkers()
+	defer o.stopWorkers()
+	for o.state.CurGeneration < o.MaxGenerations {
+		o.optimizeBatch()
+		o.state.CurBatch++
+		if o.state.CurBatch == o.BatchSize {
+			o.state.CurBatch = 0
+			o.state.CurGeneration++
+		}
+		if err := o.saveCheckpoint(); err != nil {
+			panic(fmt.Sprintf("de: error during checkpoint serialization (%s)", err.Error()))
+		}
+	}
+}
+
+// BestSolution returns the best solution found so far (can be nil).
+func (o *DifferentialEvolution) BestSolution() *ScoredVector {
+	return o.bestSolution
+}
+
+// State returns the current state of the optimization process.
+func (o *DifferentialEvolution) State() State {
+	return *o.state
+}
+
+// startWorkers runs the pool of workers evaluating the members, if more than one is required.
+func (o *DifferentialEvolution) startWorkers() {
+	if o.Workers <= 1 {
+		return
+	}
+	o.pool = workerpool.New(o.Workers)
+	go o.pool.RunUntilStopped(func(_ int, jobData interface{}) {
+		jobData.(func())()
+	})
+}
+
+// stopWorkers stops the pool of workers, if any.
+func (o *DifferentialEvolution) stopWorkers() {
+	if o.pool == nil {
+		return
+	}
+	o.pool.Stop()
+	o.pool = nil
+}
+
+// forEachMember calls f for each member of the population, concurrently if the evaluation is spread
+// over a pool of workers. It returns when all the calls are completed.
+func (o *DifferentialEvolution) forEachMember(f func(member *Member)) {
+	if o.pool == nil {
+		for _, member := range o.population.Members {
+			f(member)
 		}
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(o.population.Members))
+	for _, member := range o.population.Members {
+		member := member
+		o.pool.PublishJobData(func() {
+			defer wg.Done()
+			f(member)
+		})
 	}
+	wg.Wait()
 }
 
 // optimizeBatch optimize the current generation against the current batch.
@@ -132,8 +220,9 @@ func (o *DifferentialEvolution) optimizeBatch() {
 	o.optimizeGeneration()
 	o.validateTargets()
 	o.checkForBetterSolution()
-	if o.ResetAfter > o.state.countBestScoreUnchanged {
+	if o.ResetAfter > 0 && o.state.countBestScoreUnchanged >= o.ResetAfter {
 		o.resetPopulation()
+		o.state.countBestScoreUnchanged = 0
 	}
 }
 
@@ -153,18 +242,32 @@ func (o *DifferentialEvolution) optimizationStep() {
 }
 
 // evaluateTargets evaluate the fitness of the target vectors against the current batch for each member of the population.
+// The evaluation is skipped if the target scores already refer to the current batch, unless ReevaluateTargets is set.
 func (o *DifferentialEvolution) evaluateTargets() {
-	for _, member := range o.population.Members {
-		member.TargetScore = o.fitnessFunc(member.TargetVector, o.state.CurBatch)
+	if o.targetsEvaluated && o.BatchSize == 1 && !o.ReevaluateTargets {
+		return
 	}
+	o.forEachMember(func(member *Member) {
+		member.TargetScore = o.fitnessFunc(member.TargetVector, o.state.CurBatch)
+	})
+	o.targetsEvaluated = true
 }
 
 // evaluateTrials evaluate the fitness of the donor vectors against the current batch for each member of the population.
 // If the fitness is better than the current one, assign the value of the donor vector to the target vector.
+// The successful trials are reported to the mutation strategy, if adaptive.
 func (o *DifferentialEvolution) evaluateTrials() {
-	for _, member := range o.population.Members {
+	o.forEachMember(func(member *Member) {
 		member.TrialScore = o.fitnessFunc(member.DonorVector, o.state.CurBatch)
+	})
+	var successes []Success
+	for _, member := range o.population.Members {
 		if member.TrialScore < member.TargetScore {
+			successes = append(successes, Success{
+				MemberHyperParams: member.MemberHyperParams,
+				Parent:            member.TargetVector,
+				Improvement:       member.TargetScore - member.TrialScore,
+			})
 			member.TargetScore = member.TrialScore
 			member.TargetVector = member.DonorVector.Clone().(*mat.Dense)
 			if o.Adaptive {
@@ -172,13 +275,23 @@ func (o *DifferentialEvolution) evaluateTrials() {
 			}
 		}
 	}
+	if mutation, ok := o.mutation.(AdaptiveMutator); ok {
+		mutation.Adapt(successes)
+	}
 }
 
 // validateTargets test the entire population against the validation dataset.
+// Without a validation function, the validation score is the opposite of the target score.
 func (o *DifferentialEvolution) validateTargets() {
-	for _, member := range o.population.Members {
-		member.ValidationScore = o.validate(member.TargetVector)
+	if o.validate == nil {
+		for _, member := range o.population.Members {
+			member.ValidationScore = -member.TargetScore
+		}
+		return
 	}
+	o.forEachMember(func(member *Member) {
+		member.ValidationScore = o.validate(member.TargetVector)
+	})
 }
 
 // checkForBetterSolution compares the overall best solution with all current solutions, updating it if a new best is found.
@@ -197,19 +310,23 @@ func (o *DifferentialEvolution) checkForBetterSolution() {
 			Vector: o.population.Members[bestIndex].TargetVector.Clone().(*mat.Dense),
 			Score:  bestValidationScore,
 		}
-		o.onNewBest(o.bestSolution)
+		if o.onNewBest != nil {
+			o.onNewBest(o.bestSolution)
+		}
 	} else {
 		o.state.countBestScoreUnchanged++
 	}
 }
 
-// resetPopulation resets the population retaining the best solution.
+// resetPopulation replaces the population with new random members, retaining the best solution.
+// The members are drawn from the random generator of the optimizer, so that each reset explores
+// a different population.
 func (o *DifferentialEvolution) resetPopulation() {
 	o.population = NewRandomPopulation(
 		o.PopulationSize,
 		o.VectorSize,
 		o.Bound,
-		rand.NewLockedRand(o.Seed),
+		o.rndGen,
 		MemberHyperParams{
 			MutationFactor: o.MutationFactor,
 			CrossoverRate:  o.CrossoverRate,
@@ -219,5 +336,5 @@ func (o *DifferentialEvolution) resetPopulation() {
 	// retain the best solution
 	members := o.population.Members
 	members[0].TargetVector = o.bestSolution.Vector.Clone().(*mat.Dense)
-	members[0].TargetScore = o.bestSolution.Score
+	o.targetsEvaluated = false
 }
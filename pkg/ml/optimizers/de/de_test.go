@@ -0,0 +1,253 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"gonum.org/v1/gonum/floats"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestConfig() Config {
+	return Config{
+		PopulationSize:    20,
+		VectorSize:        5,
+		MaxGenerations:    20,
+		BatchSize:         2,
+		OptimizationSteps: 5,
+		MutationFactor:    0.5,
+		CrossoverRate:     0.9,
+		WeightFactor:      0.5,
+		Bound:             6.0,
+		Seed:              42,
+	}
+}
+
+// sphere returns the sum of the squares of the solution, which has its minimum in zero.
+func sphere(solution *mat.Dense, _ int) float64 {
+	return solution.Prod(solution).Sum()
+}
+
+func newTestOptimizer(config Config) *DifferentialEvolution {
+	return NewOptimizer(
+		config,
+		NewSHADE(config.PopulationSize, config.Bound, config.PopulationSize, rand.NewLockedRand(1)),
+		NewBinomialCrossover(rand.NewLockedRand(2)),
+		sphere,
+		nil,
+		nil,
+	)
+}
+
+func TestDifferentialEvolution_Workers(t *testing.T) {
+	config := newTestConfig()
+	config.Workers = 4
+	var evaluations int64
+	optimizer := NewOptimizer(
+		config,
+		NewJADE(0.1, 0.1, config.Bound, config.PopulationSize, rand.NewLockedRand(1)),
+		NewBinomialCrossover(rand.NewLockedRand(2)),
+		func(solution *mat.Dense, batch int) float64 {
+			atomic.AddInt64(&evaluations, 1)
+			return sphere(solution, batch)
+		},
+		nil,
+		nil,
+	)
+	optimizer.Optimize()
+
+	// for each batch, the targets and the trials of each optimization step
+	expected := int64(config.MaxGenerations * config.BatchSize * config.PopulationSize * (1 + config.OptimizationSteps))
+	if evaluations != expected {
+		t.Errorf("Expected %d evaluations, got %d", expected, evaluations)
+	}
+	if best := optimizer.BestSolution(); best == nil || -best.Score > 1.0e-2 {
+		t.Errorf("Expected the optimization to converge towards zero, got %v", best)
+	}
+	if optimizer.pool != nil {
+		t.Error("Expected the workers to be stopped at the end of the optimization")
+	}
+}
+
+func TestDifferentialEvolution_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "de_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := newTestConfig()
+	config.MaxGenerations = 6
+	expected := newTestOptimizer(config)
+	expected.Optimize()
+
+	// interrupted after 3 generations
+	config.CheckpointPath = filepath.Join(dir, "checkpoint")
+	config.MaxGenerations = 3
+	newTestOptimizer(config).Optimize()
+
+	config.MaxGenerations = 6
+	resumed := newTestOptimizer(config)
+	if err := resumed.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if state := resumed.State(); state.CurGeneration != 3 || state.CurBatch != 0 {
+		t.Fatalf("Unexpected resumed state %+v", state)
+	}
+	resumed.Optimize()
+
+	if resumed.BestSolution().Score != expected.BestSolution().Score ||
+		!floats.Equal(resumed.BestSolution().Vector.Data(), expected.BestSolution().Vector.Data()) {
+		t.Error("The best solution of the resumed optimization doesn't match the uninterrupted one")
+	}
+	for i, member := range resumed.population.Members {
+		if !floats.Equal(member.TargetVector.Data(), expected.population.Members[i].TargetVector.Data()) {
+			t.Fatal("The population of the resumed optimization doesn't match the uninterrupted one")
+		}
+	}
+}
+
+func TestDifferentialEvolution_ResumeMismatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "de_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := newTestConfig()
+	config.MaxGenerations = 1
+	config.CheckpointPath = filepath.Join(dir, "checkpoint")
+	newTestOptimizer(config).Optimize()
+
+	config.PopulationSize = 10
+	if err := newTestOptimizer(config).Resume(); err == nil {
+		t.Error("Expected an error resuming a population of different size")
+	}
+}
+
+// constant is a fitness function which never improves the best solution.
+func constant(_ *mat.Dense, _ int) float64 {
+	return 0
+}
+
+func TestDifferentialEvolution_ResetAfter(t *testing.T) {
+	config := newTestConfig()
+	config.ResetAfter = 2
+	optimizer := newTestOptimizer(config)
+	optimizer.fitnessFunc = constant
+
+	// the first batch finds the best solution, which then remains unchanged
+	var populations []*Population
+	for i := 0; i < 6; i++ {
+		population := optimizer.population
+		optimizer.optimizeBatch()
+		if optimizer.population != population {
+			populations = append(populations, optimizer.population)
+			if i != 2 && i != 4 {
+				t.Errorf("Unexpected reset at batch %d", i)
+			}
+		}
+	}
+	if len(populations) != 2 {
+		t.Fatalf("Expected 2 resets, got %d", len(populations))
+	}
+	// the best solution is retained, while the other members are drawn anew at each reset
+	for _, population := range populations {
+		if !floats.Equal(population.Members[0].TargetVector.Data(), optimizer.BestSolution().Vector.Data()) {
+			t.Error("Expected the best solution to be retained")
+		}
+	}
+	if floats.Equal(populations[0].Members[1].TargetVector.Data(), populations[1].Members[1].TargetVector.Data()) {
+		t.Error("Expected a different population at each reset")
+	}
+}
+
+func TestDifferentialEvolution_NoReset(t *testing.T) {
+	config := newTestConfig()
+	optimizer := newTestOptimizer(config)
+	optimizer.fitnessFunc = constant
+	population := optimizer.population
+	for i := 0; i < 6; i++ {
+		optimizer.optimizeBatch()
+	}
+	if optimizer.population != population {
+		t.Error("Expected no reset with ResetAfter 0")
+	}
+}
+
+func TestDifferentialEvolution_OptimizeAgain(t *testing.T) {
+	config := newTestConfig()
+	config.MaxGenerations = 2
+	var evaluations int
+	optimizer := newTestOptimizer(config)
+	optimizer.fitnessFunc = func(solution *mat.Dense, batch int) float64 {
+		evaluations++
+		return sphere(solution, batch)
+	}
+
+	optimizer.Optimize()
+	first := evaluations
+	if first == 0 {
+		t.Fatal("Expected some evaluations")
+	}
+	optimizer.Optimize()
+	if evaluations != 2*first {
+		t.Errorf("Expected the second optimization to start again from generation 0, got %d evaluations", evaluations-first)
+	}
+	if state := optimizer.State(); state.CurGeneration != config.MaxGenerations || state.CurBatch != 0 {
+		t.Errorf("Unexpected state %+v", state)
+	}
+}
+
+func TestDifferentialEvolution_ReuseTargetScores(t *testing.T) {
+	for _, reevaluate := range []bool{false, true} {
+		config := newTestConfig()
+		config.BatchSize = 1
+		config.ReevaluateTargets = reevaluate
+		var evaluations int
+		optimizer := newTestOptimizer(config)
+		optimizer.fitnessFunc = func(solution *mat.Dense, batch int) float64 {
+			evaluations++
+			return sphere(solution, batch)
+		}
+		optimizer.Optimize()
+
+		// the trials of each optimization step, and the targets once or at each generation
+		expected := config.PopulationSize * (1 + config.MaxGenerations*config.OptimizationSteps)
+		if reevaluate {
+			expected = config.PopulationSize * config.MaxGenerations * (1 + config.OptimizationSteps)
+		}
+		if evaluations != expected {
+			t.Errorf("Expected %d evaluations with ReevaluateTargets %t, got %d", expected, reevaluate, evaluations)
+		}
+		for _, member := range optimizer.population.Members {
+			if member.TargetScore != sphere(member.TargetVector, 0) {
+				t.Fatal("Expected the target scores to match the target vectors")
+			}
+		}
+	}
+}
+
+func TestDifferentialEvolution_EvaluateTargetsAfterReset(t *testing.T) {
+	config := newTestConfig()
+	config.BatchSize = 1
+	config.ResetAfter = 1
+	optimizer := newTestOptimizer(config)
+	optimizer.fitnessFunc = constant
+	optimizer.optimizeBatch()
+	optimizer.optimizeBatch() // the best solution is unchanged, so the population is reset
+	optimizer.fitnessFunc = sphere
+	optimizer.evaluateTargets()
+	for _, member := range optimizer.population.Members {
+		if member.TargetScore != sphere(member.TargetVector, 0) {
+			t.Fatal("Expected the targets of the new population to be evaluated")
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"math"
+)
+
+var _ AdaptiveMutator = &JADE{}
+
+// JADE implements the current-to-pbest mutation strategy with adaptive hyper-parameters.
+// Before each mutation, the mutation factor and the crossover rate of each member are sampled respectively
+// from a Cauchy and a normal distribution, whose locations are moved towards the values of the successful trials.
+//
+// Reference:
+//   "JADE: Adaptive Differential Evolution With Optional External Archive"
+//   Authors: Jingqiao Zhang, Arthur C. Sanderson (2009)
+//   (https://ieeexplore.ieee.org/document/5208221)
+type JADE struct {
+	*CurrentToPBestMutation
+	// The adaptation rate of the locations (e.g. 0.1)
+	C float64
+	// The location of the Cauchy distribution of the mutation factors (initially 0.5)
+	MeanMutationFactor float64
+	// The mean of the normal distribution of the crossover rates (initially 0.5)
+	MeanCrossoverRate float64
+}
+
+// NewJADE returns a new JADE mutation strategy.
+// The archive is disabled if archiveSize is zero (a common choice is the population size).
+func NewJADE(p, c, bound float64, archiveSize int, rndGen *rand.LockedRand) *JADE {
+	return &JADE{
+		CurrentToPBestMutation: NewCurrentToPBestMutation(p, bound, archiveSize, rndGen),
+		C:                      c,
+		MeanMutationFactor:     0.5,
+		MeanCrossoverRate:      0.5,
+	}
+}
+
+// Mutate samples the hyper-parameters of each member and calculates the donor vectors with the
+// current-to-pbest mutation.
+func (m *JADE) Mutate(p *Population) {
+	for _, member := range p.Members {
+		member.MutationFactor = sampleMutationFactor(m.MeanMutationFactor, m.rndGen)
+		member.CrossoverRate = sampleCrossoverRate(m.MeanCrossoverRate, m.rndGen)
+	}
+	m.CurrentToPBestMutation.Mutate(p)
+}
+
+// Adapt archives the replaced parents and updates the locations of the distributions as:
+//    MeanCrossoverRate = (1 - c) MeanCrossoverRate + c mean(successful crossover rates)
+//    MeanMutationFactor = (1 - c) MeanMutationFactor + c lehmerMean(successful mutation factors)
+func (m *JADE) Adapt(successes []Success) {
+	m.CurrentToPBestMutation.Adapt(successes)
+	if len(successes) == 0 {
+		return
+	}
+	weights := make([]float64, len(successes))
+	for i := range weights {
+		weights[i] = 1.0 / float64(len(successes))
+	}
+	meanF, meanCR := weightedMeans(successes, weights)
+	m.MeanCrossoverRate = (1.0-m.C)*m.MeanCrossoverRate + m.C*meanCR
+	m.MeanMutationFactor = (1.0-m.C)*m.MeanMutationFactor + m.C*meanF
+}
+
+// jadeState is the serializable state of a JADE mutation strategy.
+type jadeState struct {
+	PBest              []byte
+	MeanMutationFactor float64
+	MeanCrossoverRate  float64
+}
+
+// MarshalBinary returns the binary representation of the state of the strategy.
+func (m *JADE) MarshalBinary() ([]byte, error) {
+	pBest, err := m.CurrentToPBestMutation.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeGob(jadeState{
+		PBest:              pBest,
+		MeanMutationFactor: m.MeanMutationFactor,
+		MeanCrossoverRate:  m.MeanCrossoverRate,
+	})
+}
+
+// UnmarshalBinary restores the state of the strategy from the representation returned by MarshalBinary.
+func (m *JADE) UnmarshalBinary(data []byte) error {
+	var state jadeState
+	if err := decodeGob(data, &state); err != nil {
+		return err
+	}
+	m.MeanMutationFactor = state.MeanMutationFactor
+	m.MeanCrossoverRate = state.MeanCrossoverRate
+	return m.CurrentToPBestMutation.UnmarshalBinary(state.PBest)
+}
+
+// sampleMutationFactor samples a mutation factor from the Cauchy distribution with scale 0.1 and the given
+// location, truncated to 1 if greater and sampled again if not positive.
+func sampleMutationFactor(location float64, rndGen *rand.LockedRand) float64 {
+	for {
+		f := location + 0.1*math.Tan(math.Pi*(rndGen.Float64()-0.5))
+		if f > 0 {
+			return math.Min(f, 1.0)
+		}
+	}
+}
+
+// sampleCrossoverRate samples a crossover rate from the normal distribution with standard deviation 0.1
+// and the given mean, truncated to [0, 1].
+func sampleCrossoverRate(mean float64, rndGen *rand.LockedRand) float64 {
+	return math.Max(0.0, math.Min(1.0, mean+0.1*rndGen.NormFloat64()))
+}
+
+// weightedMeans returns the weighted Lehmer mean of the mutation factors and the weighted arithmetic mean
+// of the crossover rates of the successful trials.
+func weightedMeans(successes []Success, weights []float64) (meanF, meanCR float64) {
+	sumF, sumF2 := 0.0, 0.0
+	for i, success := range successes {
+		sumF += weights[i] * success.MutationFactor
+		sumF2 += weights[i] * success.MutationFactor * success.MutationFactor
+		meanCR += weights[i] * success.CrossoverRate
+	}
+	if sumF > 0 {
+		meanF = sumF2 / sumF
+	}
+	return
+}
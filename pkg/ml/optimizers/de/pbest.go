@@ -0,0 +1,185 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"math"
+	"sort"
+)
+
+// Success describes a trial vector which replaced the target vector of a member during the selection.
+type Success struct {
+	// The hyper-params used to generate the trial vector
+	MemberHyperParams
+	// The replaced target vector
+	Parent *mat.Dense
+	// The improvement of the fitness (always positive)
+	Improvement float64
+}
+
+// AdaptiveMutator is implemented by the mutation strategies which adapt themselves according to the
+// trial vectors that replaced the target vectors (e.g. JADE and SHADE).
+type AdaptiveMutator interface {
+	Mutator
+	// Adapt is called after each selection with the successful trials, which can be empty.
+	Adapt(successes []Success)
+}
+
+// Archive is a set of the parent vectors recently replaced by better trial vectors. It is used as an
+// additional source of diversity by the current-to-pbest mutation.
+type Archive struct {
+	// The maximum number of vectors
+	Size int
+	// The archived vectors
+	Vectors []*mat.Dense
+}
+
+// add adds the vector to the archive, replacing a random one if the archive is full.
+func (a *Archive) add(v *mat.Dense, rndGen *rand.LockedRand) {
+	if a.Size <= 0 {
+		return
+	}
+	if len(a.Vectors) < a.Size {
+		a.Vectors = append(a.Vectors, v)
+		return
+	}
+	a.Vectors[rndGen.Intn(a.Size)] = v
+}
+
+var _ AdaptiveMutator = &CurrentToPBestMutation{}
+
+// CurrentToPBestMutation implements the DE/current-to-pbest/1 mutation strategy with an optional archive.
+//
+// Reference:
+//   "JADE: Adaptive Differential Evolution With Optional External Archive"
+//   Authors: Jingqiao Zhang, Arthur C. Sanderson (2009)
+//   (https://ieeexplore.ieee.org/document/5208221)
+type CurrentToPBestMutation struct {
+	// The fraction of the best members from which pbest is chosen (e.g. 0.05)
+	P float64
+	// The (positive) bound
+	Bound float64
+	// The archive of the replaced parents
+	Archive *Archive
+	rndGen  *rand.LockedRand
+}
+
+// NewCurrentToPBestMutation returns a new CurrentToPBestMutation.
+// The archive is disabled if archiveSize is zero (a common choice is the population size).
+func NewCurrentToPBestMutation(p, bound float64, archiveSize int, rndGen *rand.LockedRand) *CurrentToPBestMutation {
+	return &CurrentToPBestMutation{
+		P:       p,
+		Bound:   bound,
+		Archive: &Archive{Size: archiveSize},
+		rndGen:  rndGen,
+	}
+}
+
+// Mutate calculate the mutated vector (donor vector) as:
+//    yi = clip(xi + MutationFactor (xpbest − xi) + MutationFactor (xa − xb))
+// where xpbest is one of the 100p% best members, xa is a member of the population and xb is a member of
+// the union of the population and the archive.
+func (m *CurrentToPBestMutation) Mutate(p *Population) {
+	ranking := rankMembers(p)
+	for i, member := range p.Members {
+		member.DonorVector = m.mutate(p, ranking, i, m.P, member.MutationFactor)
+	}
+}
+
+// Adapt adds the replaced parents to the archive.
+func (m *CurrentToPBestMutation) Adapt(successes []Success) {
+	for _, success := range successes {
+		m.Archive.add(success.Parent, m.rndGen)
+	}
+}
+
+// mutate returns the donor vector of the i-th member, choosing pbest among the 100p% best members of the ranking.
+func (m *CurrentToPBestMutation) mutate(p *Population, ranking []int, i int, pBest, f float64) *mat.Dense {
+	size := len(p.Members)
+	top := int(math.Round(pBest * float64(size)))
+	if top < 1 {
+		top = 1
+	}
+	best := p.Members[ranking[m.rndGen.Intn(top)]].TargetVector
+	a := m.rndGen.Intn(size)
+	for a == i && size > 1 {
+		a = m.rndGen.Intn(size)
+	}
+	b := m.rndGen.Intn(size + len(m.Archive.Vectors))
+	for (b == i || b == a) && size > 2 {
+		b = m.rndGen.Intn(size + len(m.Archive.Vectors))
+	}
+	xi := p.Members[i].TargetVector
+	xa := p.Members[a].TargetVector
+	var xb *mat.Dense
+	if b < size {
+		xb = p.Members[b].TargetVector
+	} else {
+		xb = m.Archive.Vectors[b-size]
+	}
+	donor := xi.Add(best.Sub(xi).ProdScalarInPlace(f)).AddInPlace(xa.Sub(xb).ProdScalarInPlace(f))
+	donor.ClipInPlace(-m.Bound, +m.Bound)
+	return donor.(*mat.Dense)
+}
+
+// rankMembers returns the indices of the members sorted by increasing target score.
+func rankMembers(p *Population) []int {
+	ranking := make([]int, len(p.Members))
+	for i := range ranking {
+		ranking[i] = i
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return p.Members[ranking[i]].TargetScore < p.Members[ranking[j]].TargetScore
+	})
+	return ranking
+}
+
+// pBestState is the serializable state of a CurrentToPBestMutation.
+type pBestState struct {
+	Archive   [][]float64
+	RandState []byte
+}
+
+// MarshalBinary returns the binary representation of the archive and of the random generator.
+func (m *CurrentToPBestMutation) MarshalBinary() ([]byte, error) {
+	randState, err := m.rndGen.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	state := pBestState{RandState: randState}
+	for _, v := range m.Archive.Vectors {
+		state.Archive = append(state.Archive, v.Data())
+	}
+	return encodeGob(state)
+}
+
+// UnmarshalBinary restores the archive and the random generator from the representation returned by MarshalBinary.
+func (m *CurrentToPBestMutation) UnmarshalBinary(data []byte) error {
+	var state pBestState
+	if err := decodeGob(data, &state); err != nil {
+		return err
+	}
+	m.Archive.Vectors = make([]*mat.Dense, len(state.Archive))
+	for i, v := range state.Archive {
+		m.Archive.Vectors[i] = mat.NewVecDense(v)
+	}
+	return m.rndGen.UnmarshalBinary(state.RandState)
+}
+
+func encodeGob(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeGob(data []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// HyperParam defines the search range of a hyper-parameter.
+type HyperParam struct {
+	// The name of the hyper-parameter, which is the path of the field to set in the configurations
+	// (e.g. "GradientClipping" or "UpdateMethod.StepSize", see HyperParams.Apply)
+	Name string
+	// The minimum value
+	Min float64
+	// The maximum value
+	Max float64
+	// Whether the values are searched on a logarithmic scale, as usual for the learning rates (Min must be positive)
+	LogScale bool
+	// Whether the values are rounded to integers (e.g. the batch size)
+	Integer bool
+}
+
+// value maps a component of a solution, in [-1, 1], to the value of the hyper-parameter.
+func (h HyperParam) value(x float64) float64 {
+	u := (math.Max(-1.0, math.Min(1.0, x)) + 1.0) / 2.0
+	var value float64
+	if h.LogScale {
+		value = h.Min * math.Pow(h.Max/h.Min, u)
+	} else {
+		value = h.Min + u*(h.Max-h.Min)
+	}
+	if h.Integer {
+		value = math.Round(value)
+	}
+	return value
+}
+
+// HyperParams associates the names of the hyper-parameters with their values.
+type HyperParams map[string]float64
+
+// Apply sets the values of the hyper-parameters into the fields of the configuration, which must be a pointer
+// to a struct (e.g. a trainer configuration). The names are the paths of the fields separated by dots, which
+// can traverse nested structs, pointers and interfaces holding structs (e.g. the method configuration of
+// an optimizer). The fields can be of any integer or floating-point type; the integers are rounded.
+func (h HyperParams) Apply(config interface{}) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("de: the configuration must be a pointer to a struct, found %T", config)
+	}
+	for name, value := range h {
+		if err := setField(v.Elem(), name, strings.Split(name, "."), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField sets the value into the field at the given path of v.
+func setField(v reflect.Value, name string, path []string, value float64) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return fmt.Errorf("de: nil pointer on the path of `%s`", name)
+		}
+		return setField(v.Elem(), name, path, value)
+	case reflect.Interface:
+		if v.IsNil() {
+			return fmt.Errorf("de: nil interface on the path of `%s`", name)
+		}
+		// the value held by an interface is not addressable, so a copy is modified and set back
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if err := setField(elem, name, path, value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if len(path) > 0 {
+		if v.Kind() != reflect.Struct {
+			return fmt.Errorf("de: `%s` is not a field of a struct", name)
+		}
+		field := v.FieldByName(path[0])
+		if !field.IsValid() || !field.CanSet() {
+			return fmt.Errorf("de: unknown field `%s`", name)
+		}
+		return setField(field, name, path[1:], value)
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(math.Round(value)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value < 0 {
+			return fmt.Errorf("de: negative value %f for the unsigned field `%s`", value, name)
+		}
+		v.SetUint(uint64(math.Round(value)))
+	default:
+		return fmt.Errorf("de: the field `%s` is not numeric", name)
+	}
+	return nil
+}
+
+// HyperParamSearch searches the hyper-parameters which minimize an objective (e.g. the validation loss of a
+// model trained with a given configuration), by means of a DifferentialEvolution with the SHADE mutation
+// strategy. Each solution is a vector in [-1, 1] with a component for each hyper-parameter.
+// The objective of the target vectors is evaluated once, unless ReevaluateTargets is set in the configuration,
+// which makes the search robust to noisy objectives (e.g. due to random initializations) at the cost of an
+// evaluation of the whole population at each generation.
+type HyperParamSearch struct {
+	*DifferentialEvolution
+	space []HyperParam
+}
+
+// NewHyperParamSearch returns a new HyperParamSearch of the hyper-parameters in the given space.
+// The VectorSize and the Bound of the configuration are set according to the space, and the BatchSize
+// is 1 if not defined. If Workers is greater than 1, the objective must be safe for concurrent use.
+// The optional onNewBest is called each time a better configuration is found.
+func NewHyperParamSearch(
+	config Config,
+	space []HyperParam,
+	objective func(params HyperParams) float64,
+	onNewBest func(params HyperParams, score float64),
+) *HyperParamSearch {
+	config.VectorSize = len(space)
+	config.Bound = 1.0
+	if config.BatchSize == 0 {
+		config.BatchSize = 1
+	}
+	s := &HyperParamSearch{space: space}
+	rndGen := rand.NewLockedRand(config.Seed + 1) // independent of the population
+	s.DifferentialEvolution = NewOptimizer(
+		config,
+		NewSHADE(config.PopulationSize, config.Bound, config.PopulationSize, rndGen),
+		NewBinomialCrossover(rndGen),
+		func(solution *mat.Dense, _ int) float64 {
+			return objective(s.HyperParams(solution))
+		},
+		nil,
+		func(solution *ScoredVector) {
+			if onNewBest != nil {
+				onNewBest(s.HyperParams(solution.Vector), -solution.Score)
+			}
+		},
+	)
+	// the whole space is sampled uniformly
+	for _, member := range s.population.Members {
+		initializers.Uniform(member.TargetVector, -1.0, 1.0, s.rndGen)
+	}
+	return s
+}
+
+// HyperParams returns the hyper-parameters corresponding to the solution.
+func (s *HyperParamSearch) HyperParams(solution *mat.Dense) HyperParams {
+	params := make(HyperParams, len(s.space))
+	for i, h := range s.space {
+		params[h.Name] = h.value(solution.AtVec(i))
+	}
+	return params
+}
+
+// Best returns the best hyper-parameters found so far, together with their objective.
+// It returns nil if the search has not started yet.
+func (s *HyperParamSearch) Best() (HyperParams, float64) {
+	best := s.BestSolution()
+	if best == nil {
+		return nil, math.Inf(1)
+	}
+	return s.HyperParams(best.Vector), -best.Score
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"math"
+	"testing"
+)
+
+type testTrainingConfig struct {
+	BatchSize        int
+	GradientClipping float64
+	UpdateMethod     gd.MethodConfig
+}
+
+func TestHyperParams_Apply(t *testing.T) {
+	config := &testTrainingConfig{UpdateMethod: adam.NewDefaultConfig()}
+	err := HyperParams{
+		"BatchSize":             31.6,
+		"GradientClipping":      0.5,
+		"UpdateMethod.StepSize": 0.01,
+	}.Apply(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.BatchSize != 32 || config.GradientClipping != 0.5 {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+	if stepSize := config.UpdateMethod.(adam.Config).StepSize; stepSize != 0.01 {
+		t.Errorf("Expected the step size of the method to be 0.01, got %f", stepSize)
+	}
+
+	for _, name := range []string{"Unknown", "UpdateMethod.Unknown", "BatchSize.Value"} {
+		if err := (HyperParams{name: 1.0}).Apply(config); err == nil {
+			t.Errorf("Expected an error applying %s", name)
+		}
+	}
+	if err := (HyperParams{"BatchSize": 1.0}).Apply(*config); err == nil {
+		t.Error("Expected an error applying to a struct which is not a pointer")
+	}
+}
+
+func TestHyperParam_Value(t *testing.T) {
+	lr := HyperParam{Name: "LR", Min: 1e-4, Max: 1e-1, LogScale: true}
+	if v := lr.value(-1.0); math.Abs(v-1e-4) > 1e-12 {
+		t.Errorf("Expected the minimum, got %f", v)
+	}
+	if v := lr.value(1.0); math.Abs(v-1e-1) > 1e-12 {
+		t.Errorf("Expected the maximum, got %f", v)
+	}
+	if v := lr.value(0.0); math.Abs(v-math.Sqrt(1e-5)) > 1e-12 {
+		t.Errorf("Expected the geometric mean, got %f", v)
+	}
+	steps := HyperParam{Name: "Steps", Min: 1, Max: 10, Integer: true}
+	if v := steps.value(0.1); v != 6 {
+		t.Errorf("Expected 6, got %f", v)
+	}
+}
+
+func TestHyperParamSearch(t *testing.T) {
+	space := []HyperParam{
+		{Name: "GradientClipping", Min: -5.0, Max: 5.0},
+		{Name: "BatchSize", Min: 1, Max: 64, Integer: true},
+		{Name: "UpdateMethod.StepSize", Min: 1e-5, Max: 1.0, LogScale: true},
+	}
+	objective := func(params HyperParams) float64 {
+		config := &testTrainingConfig{UpdateMethod: adam.NewDefaultConfig()}
+		if err := params.Apply(config); err != nil {
+			panic(err) // t.Fatal can't be called by the workers
+		}
+		stepSize := config.UpdateMethod.(adam.Config).StepSize
+		return math.Pow(config.GradientClipping-1.0, 2) +
+			math.Pow(float64(config.BatchSize-16)/64, 2) +
+			math.Pow(math.Log10(stepSize)+3, 2)
+	}
+	countNewBest := 0
+	search := NewHyperParamSearch(Config{
+		PopulationSize:    20,
+		MaxGenerations:    50,
+		OptimizationSteps: 2,
+		Workers:           2,
+		Seed:              1,
+	}, space, objective, func(_ HyperParams, _ float64) {
+		countNewBest++
+	})
+	if best, _ := search.Best(); best != nil {
+		t.Error("Expected no best hyper-parameters before the search")
+	}
+	search.Optimize()
+
+	best, score := search.Best()
+	if score > 1e-3 {
+		t.Errorf("Expected the search to converge, got objective %f with %v", score, best)
+	}
+	if math.Abs(best["GradientClipping"]-1.0) > 0.05 || best["BatchSize"] != 16 ||
+		math.Abs(math.Log10(best["UpdateMethod.StepSize"])+3) > 0.05 {
+		t.Errorf("Unexpected best hyper-parameters %v", best)
+	}
+	if countNewBest == 0 {
+		t.Error("Expected onNewBest to be called")
+	}
+}
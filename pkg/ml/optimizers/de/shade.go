@@ -0,0 +1,116 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package de
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"math"
+)
+
+var _ AdaptiveMutator = &SHADE{}
+
+// SHADE implements the Success-History based Adaptive Differential Evolution mutation strategy.
+// It differs from JADE in that the mutation factor and the crossover rate of each member are sampled
+// around a random entry of a memory of the past successful values, and that the fraction of the best
+// members of the current-to-pbest mutation is sampled for each member in [2/PopulationSize, 0.2].
+//
+// Reference:
+//   "Success-History Based Parameter Adaptation for Differential Evolution"
+//   Authors: Ryoji Tanabe, Alex Fukunaga (2013)
+//   (https://ieeexplore.ieee.org/document/6557555)
+type SHADE struct {
+	*CurrentToPBestMutation
+	// The memory of the locations of the mutation factors (initially 0.5)
+	MemoryMutationFactors []float64
+	// The memory of the means of the crossover rates (initially 0.5)
+	MemoryCrossoverRates []float64
+	// The index of the next memory entry to update
+	next int
+}
+
+// NewSHADE returns a new SHADE mutation strategy with the given size of the memory (e.g. the population size).
+// The archive is disabled if archiveSize is zero (a common choice is the population size).
+func NewSHADE(memorySize int, bound float64, archiveSize int, rndGen *rand.LockedRand) *SHADE {
+	memoryF := make([]float64, memorySize)
+	memoryCR := make([]float64, memorySize)
+	for i := range memoryF {
+		memoryF[i] = 0.5
+		memoryCR[i] = 0.5
+	}
+	return &SHADE{
+		CurrentToPBestMutation: NewCurrentToPBestMutation(0.2, bound, archiveSize, rndGen),
+		MemoryMutationFactors:  memoryF,
+		MemoryCrossoverRates:   memoryCR,
+	}
+}
+
+// Mutate samples the hyper-parameters of each member and calculates the donor vectors with the
+// current-to-pbest mutation.
+func (m *SHADE) Mutate(p *Population) {
+	ranking := rankMembers(p)
+	minP := math.Min(2.0/float64(len(p.Members)), m.P)
+	for i, member := range p.Members {
+		r := m.rndGen.Intn(len(m.MemoryMutationFactors))
+		member.MutationFactor = sampleMutationFactor(m.MemoryMutationFactors[r], m.rndGen)
+		member.CrossoverRate = sampleCrossoverRate(m.MemoryCrossoverRates[r], m.rndGen)
+		pBest := minP + m.rndGen.Float64()*(m.P-minP)
+		member.DonorVector = m.mutate(p, ranking, i, pBest, member.MutationFactor)
+	}
+}
+
+// Adapt archives the replaced parents and updates the next entry of the memory with the means of the
+// successful values, weighted by the improvements of the fitness.
+func (m *SHADE) Adapt(successes []Success) {
+	m.CurrentToPBestMutation.Adapt(successes)
+	if len(successes) == 0 {
+		return
+	}
+	sum := 0.0
+	for _, success := range successes {
+		sum += success.Improvement
+	}
+	weights := make([]float64, len(successes))
+	for i, success := range successes {
+		weights[i] = success.Improvement / sum
+	}
+	meanF, meanCR := weightedMeans(successes, weights)
+	m.MemoryMutationFactors[m.next] = meanF
+	m.MemoryCrossoverRates[m.next] = meanCR
+	m.next = (m.next + 1) % len(m.MemoryMutationFactors)
+}
+
+// shadeState is the serializable state of a SHADE mutation strategy.
+type shadeState struct {
+	PBest                 []byte
+	MemoryMutationFactors []float64
+	MemoryCrossoverRates  []float64
+	Next                  int
+}
+
+// MarshalBinary returns the binary representation of the state of the strategy.
+func (m *SHADE) MarshalBinary() ([]byte, error) {
+	pBest, err := m.CurrentToPBestMutation.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeGob(shadeState{
+		PBest:                 pBest,
+		MemoryMutationFactors: m.MemoryMutationFactors,
+		MemoryCrossoverRates:  m.MemoryCrossoverRates,
+		Next:                  m.next,
+	})
+}
+
+// UnmarshalBinary restores the state of the strategy from the representation returned by MarshalBinary.
+func (m *SHADE) UnmarshalBinary(data []byte) error {
+	var state shadeState
+	if err := decodeGob(data, &state); err != nil {
+		return err
+	}
+	m.MemoryMutationFactors = state.MemoryMutationFactors
+	m.MemoryCrossoverRates = state.MemoryCrossoverRates
+	m.next = state.Next
+	return m.CurrentToPBestMutation.UnmarshalBinary(state.PBest)
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+// accumulation keeps track of the micro-batches whose gradients are accumulated before an update.
+type accumulation struct {
+	steps       int
+	accumulated int
+	// beaten contains the events already forwarded to the method in the current cycle.
+	beaten map[scheduleEvent]bool
+}
+
+// AccumulateGrads is an option to accumulate the gradients of the given number of consecutive micro-batches,
+// i.e. calls to Optimize, and to update the params once at the end of each cycle. This allows large
+// effective batches when the memory is limited.
+//
+// Before the update, the sum of the gradients is divided by the number of micro-batches, so that it is
+// the mean of the gradients of the micro-batches (assuming the loss of each micro-batch is a mean too).
+// IncExample and IncBatch are forwarded to the method and to the learning rate schedule once per cycle,
+// so that their time steps count the updates. Use Flush to apply the gradients of an incomplete cycle.
+// The state of the optimizer can be serialized only between cycles (see StateSerializer).
+// It panics if steps is not positive.
+func AccumulateGrads(steps int) Option {
+	if steps <= 0 {
+		panic("gd: the number of accumulation steps must be positive")
+	}
+	return func(f *GradientDescent) {
+		f.accumulation = &accumulation{
+			steps:  steps,
+			beaten: make(map[scheduleEvent]bool),
+		}
+	}
+}
+
+// next records a new micro-batch, and reports whether the cycle is complete.
+func (a *accumulation) next() bool {
+	a.accumulated++
+	return a.accumulated >= a.steps
+}
+
+// beat reports whether the event has not been forwarded yet in the current cycle, marking it as forwarded.
+func (a *accumulation) beat(event scheduleEvent) bool {
+	if a.beaten[event] {
+		return false
+	}
+	a.beaten[event] = true
+	return true
+}
+
+// reset starts a new cycle, returning the number of micro-batches of the previous one.
+func (a *accumulation) reset() int {
+	n := a.accumulated
+	a.accumulated = 0
+	a.beaten = make(map[scheduleEvent]bool)
+	return n
+}
+
+// Flush updates the params with the gradients accumulated so far, even if the current cycle is not complete
+// (see AccumulateGrads). It has no effect if there are no pending micro-batches.
+func (o *GradientDescent) Flush() {
+	if o.accumulation == nil || o.accumulation.accumulated == 0 {
+		return
+	}
+	o.optimize()
+}
+
+// PendingMicroBatches returns the number of micro-batches accumulated since the last update.
+func (o *GradientDescent) PendingMicroBatches() int {
+	if o.accumulation == nil {
+		return 0
+	}
+	return o.accumulation.accumulated
+}
+
+// normalizeGrads divides the accumulated gradients by the number of micro-batches, and starts a new cycle.
+func (o *GradientDescent) normalizeGrads() {
+	if o.accumulation == nil {
+		return
+	}
+	n := o.accumulation.reset()
+	if n <= 1 {
+		return
+	}
+	for _, param := range o.paramsToOptimize {
+		if param.HasGrad() {
+			param.Grad().ProdScalarInPlace(1.0 / float64(n))
+		}
+	}
+}
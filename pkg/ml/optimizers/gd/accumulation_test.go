@@ -0,0 +1,84 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd_test
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lrschedule"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func propagateGrads(m *testModel, value float64) {
+	nn.ForEachParam(m, func(param *nn.Param) {
+		param.PropagateGrad(param.Value().OnesLike().ProdScalar(value))
+	})
+}
+
+func TestAccumulateGrads(t *testing.T) {
+	m := newTestModel()
+	o := gd.NewOptimizer(sgd.New(sgd.NewConfig(1.0, 0.0, false)), nn.NewDefaultParamsIterator(m), gd.AccumulateGrads(3))
+
+	for i, grad := range []float64{1.0, 2.0} {
+		propagateGrads(m, grad)
+		o.Optimize()
+		if o.PendingMicroBatches() != i+1 {
+			t.Errorf("Expected %d pending micro-batches, got %d", i+1, o.PendingMicroBatches())
+		}
+	}
+	if !floats.Equal(layer(m, 0).W.Value().Data(), []float64{1.0, 2.0}) {
+		t.Fatal("The params must not change before the end of the cycle")
+	}
+
+	propagateGrads(m, 6.0)
+	o.Optimize()
+
+	// mean of the gradients: (1 + 2 + 6) / 3 = 3
+	if !floats.EqualApprox(layer(m, 0).W.Value().Data(), []float64{-2.0, -1.0}, 1.0e-12) {
+		t.Errorf("The updated params don't match the expected values: %v", layer(m, 0).W.Value().Data())
+	}
+	if o.PendingMicroBatches() != 0 || layer(m, 0).W.HasGrad() {
+		t.Error("A new cycle must start after the update")
+	}
+
+	// incomplete cycle
+	propagateGrads(m, 1.0)
+	o.Optimize()
+	propagateGrads(m, 3.0)
+	o.Optimize()
+	o.Flush()
+	if !floats.EqualApprox(layer(m, 0).W.Value().Data(), []float64{-4.0, -3.0}, 1.0e-12) {
+		t.Errorf("The flushed params don't match the expected values: %v", layer(m, 0).W.Value().Data())
+	}
+	o.Flush() // no effect
+	if !floats.EqualApprox(layer(m, 0).W.Value().Data(), []float64{-4.0, -3.0}, 1.0e-12) {
+		t.Error("Flush must have no effect without pending micro-batches")
+	}
+}
+
+func TestAccumulateGradsTimeSteps(t *testing.T) {
+	m := newTestModel()
+	method := adam.New(adam.NewDefaultConfig())
+	o := gd.NewOptimizer(method, nn.NewDefaultParamsIterator(m),
+		gd.AccumulateGrads(4),
+		gd.ScheduleLRByBatch(lrschedule.NewStep(1.0, 1, 0.5)))
+
+	for i := 0; i < 8; i++ {
+		o.IncBatch()
+		o.IncExample()
+		propagateGrads(m, 1.0)
+		o.Optimize()
+	}
+	// Adam is initialized with the first time step
+	if method.TimeStep != 3 {
+		t.Errorf("Expected the time step of the method to count the updates, got %d", method.TimeStep)
+	}
+	if o.LearningRate() != 0.25 {
+		t.Errorf("Expected the schedule to count the updates, got learning rate %f", o.LearningRate())
+	}
+}
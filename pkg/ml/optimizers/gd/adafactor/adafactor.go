@@ -0,0 +1,224 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package adafactor
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"math"
+)
+
+var _ gd.MethodConfig = &Config{}
+
+// Config provides configuration settings for an Adafactor optimizer.
+type Config struct {
+	gd.MethodConfig
+	// StepSize is the (maximum) learning rate. If RelativeStep is true, the learning rate
+	// at the time step t is min(StepSize, 1/sqrt(t)).
+	StepSize float64
+	// Beta1 is the decay rate of the first moment of the updates; 0.0 disables it, saving memory.
+	Beta1 float64
+	// DecayRate is the exponent used to compute the decay rate of the second moments at the
+	// time step t, that is 1.0 - t^DecayRate.
+	DecayRate float64
+	// Epsilon1 is added to the squared gradients.
+	Epsilon1 float64
+	// Epsilon2 is the lower bound of the scale of the params (see ScaleParameter).
+	Epsilon2 float64
+	// ClipThreshold is the threshold of the root mean square of the updates.
+	ClipThreshold float64
+	// WeightDecay is the decoupled weight decay (see adamw.AdamW).
+	WeightDecay float64
+	// ScaleParameter scales the learning rate by the root mean square of the params.
+	ScaleParameter bool
+	// RelativeStep uses the time-dependent learning rate min(StepSize, 1/sqrt(t)).
+	RelativeStep bool
+}
+
+// NewDefaultConfig returns a new Config with the default values suggested in the paper.
+func NewDefaultConfig() Config {
+	return Config{
+		StepSize:       0.01,
+		Beta1:          0.0,
+		DecayRate:      -0.8,
+		Epsilon1:       1.0e-30,
+		Epsilon2:       1.0e-3,
+		ClipThreshold:  1.0,
+		WeightDecay:    0.0,
+		ScaleParameter: true,
+		RelativeStep:   true,
+	}
+}
+
+var (
+	_ gd.Method             = &Adafactor{}
+	_ gd.LearningRateSetter = &Adafactor{}
+)
+
+// Adafactor implements the Adafactor gradient descent optimization method (Shazeer and Stern, 2018,
+// "Adafactor: Adaptive Learning Rates with Sublinear Memory Cost").
+//
+// The second moments of the gradients of a matrix with r rows and c columns are approximated
+// by the outer product of the moving averages of the row and column means of the squared gradients,
+// requiring r+c values instead of r*c. Vectors keep the full second moments.
+type Adafactor struct {
+	Config
+	TimeStep int
+}
+
+// New returns a new Adafactor optimizer, initialized according to the given configuration.
+// It panics if Beta1 is not in the range [0.0, 1.0).
+func New(c Config) *Adafactor {
+	if !(c.Beta1 >= 0.0 && c.Beta1 < 1.0) {
+		panic("adafactor: `Beta1` must be in the range [0.0, 1.0)")
+	}
+	return &Adafactor{
+		Config:   c,
+		TimeStep: 1,
+	}
+}
+
+// Label returns the enumeration-like value which identifies this gradient descent method.
+func (o *Adafactor) Label() int {
+	return gd.Adafactor
+}
+
+// LearningRate returns the current learning rate.
+func (o *Adafactor) LearningRate() float64 {
+	return o.StepSize
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *Adafactor) SetLearningRate(lr float64) {
+	o.StepSize = lr
+}
+
+// NewSupport returns a new support structure with the given dimensions.
+// For matrices, it contains the row (r x 1) and the column (1 x c) second moments, otherwise
+// the full second moments (r x c). It is followed by the first moments (r x c) if Beta1 is not zero.
+func (o *Adafactor) NewSupport(r, c int) *nn.Payload {
+	var supp []mat.Matrix
+	if isFactored(r, c) {
+		supp = append(supp, mat.NewEmptyDense(r, 1), mat.NewEmptyDense(1, c))
+	} else {
+		supp = append(supp, mat.NewEmptyDense(r, c))
+	}
+	if o.Beta1 != 0.0 {
+		supp = append(supp, mat.NewEmptyDense(r, c))
+	}
+	return &nn.Payload{
+		Label: o.Label(),
+		Data:  supp,
+	}
+}
+
+// isFactored reports whether the second moments of a param with the given dimensions are factored.
+func isFactored(r, c int) bool {
+	return r > 1 && c > 1
+}
+
+// IncExample beats the occurrence of a new example.
+func (o *Adafactor) IncExample() {
+	o.TimeStep++
+}
+
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *Adafactor) Delta(param *nn.Param) mat.Matrix {
+	return o.calcDelta(param.Grad(), param.Value(), gd.GetOrSetPayload(param, o).Data)
+}
+
+// stepSize returns the learning rate for the given params.
+func (o *Adafactor) stepSize(weights mat.Matrix) float64 {
+	rho := o.StepSize
+	if o.RelativeStep {
+		rho = math.Min(rho, 1.0/math.Sqrt(float64(o.TimeStep)))
+	}
+	if o.ScaleParameter {
+		rho *= math.Max(o.Epsilon2, rms(weights.Data()))
+	}
+	return rho
+}
+
+// beta2 = 1.0 - t^decayRate
+// g2 = grads*grads + eps1
+// r = r*beta2 + rowMean(g2)*(1.0-beta2)
+// c = c*beta2 + colMean(g2)*(1.0-beta2)
+// u = grads / sqrt(r c / mean(r))
+// u = u / max(1.0, rms(u) / clipThreshold)
+// m = m*beta1 + u*(1.0-beta1)
+// d = m * alpha + weights * (alpha * weightDecay)
+func (o *Adafactor) calcDelta(grads, weights mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	rows, cols := grads.Dims()
+	g := grads.Data()
+	alpha := o.stepSize(weights)
+	beta2 := 1.0 - math.Pow(float64(o.TimeStep), o.DecayRate)
+	u := mat.NewEmptyDense(rows, cols)
+	ud := u.Data()
+
+	var next int
+	if isFactored(rows, cols) {
+		rowV, colV := supp[0].Data(), supp[1].Data()
+		next = 2
+		for i := 0; i < rows; i++ {
+			mean := 0.0
+			for j := 0; j < cols; j++ {
+				mean += g[i*cols+j]*g[i*cols+j] + o.Epsilon1
+			}
+			rowV[i] = rowV[i]*beta2 + (mean/float64(cols))*(1.0-beta2)
+		}
+		for j := 0; j < cols; j++ {
+			mean := 0.0
+			for i := 0; i < rows; i++ {
+				mean += g[i*cols+j]*g[i*cols+j] + o.Epsilon1
+			}
+			colV[j] = colV[j]*beta2 + (mean/float64(rows))*(1.0-beta2)
+		}
+		rowMean := 0.0
+		for _, x := range rowV {
+			rowMean += x
+		}
+		rowMean /= float64(rows)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				ud[i*cols+j] = g[i*cols+j] / math.Sqrt(rowV[i]/rowMean*colV[j])
+			}
+		}
+	} else {
+		fullV := supp[0].Data()
+		next = 1
+		for i, x := range g {
+			fullV[i] = fullV[i]*beta2 + (x*x+o.Epsilon1)*(1.0-beta2)
+			ud[i] = x / math.Sqrt(fullV[i])
+		}
+	}
+
+	u.ProdScalarInPlace(1.0 / math.Max(1.0, rms(ud)/o.ClipThreshold))
+
+	if o.Beta1 != 0.0 {
+		supp[next].ProdScalarInPlace(o.Beta1)
+		supp[next].AddInPlace(u.ProdScalarInPlace(1.0 - o.Beta1))
+		u.Copy(supp[next])
+	}
+	u.ProdScalarInPlace(alpha)
+	if o.WeightDecay != 0.0 {
+		decay := weights.ProdScalar(alpha * o.WeightDecay)
+		defer mat.ReleaseDense(decay.(*mat.Dense))
+		u.AddInPlace(decay)
+	}
+	return u
+}
+
+// rms returns the root mean square of the given values.
+func rms(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0.0
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x * x
+	}
+	return math.Sqrt(sum / float64(len(xs)))
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package adafactor
+
+import (
+	"bytes"
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func Test_NewSupport(t *testing.T) {
+	updater := New(NewDefaultConfig())
+
+	supp := updater.NewSupport(4, 3).Data
+	if len(supp) != 2 {
+		t.Fatal("The support of a matrix must contain the row and column moments only")
+	}
+	if r, c := supp[0].Dims(); r != 4 || c != 1 {
+		t.Error("The row moments have wrong dimensions")
+	}
+	if r, c := supp[1].Dims(); r != 1 || c != 3 {
+		t.Error("The column moments have wrong dimensions")
+	}
+
+	supp = updater.NewSupport(4, 1).Data
+	if len(supp) != 1 {
+		t.Fatal("The support of a vector must contain the full moments only")
+	}
+	if r, c := supp[0].Dims(); r != 4 || c != 1 {
+		t.Error("The moments have wrong dimensions")
+	}
+
+	config := NewDefaultConfig()
+	config.Beta1 = 0.9
+	supp = New(config).NewSupport(4, 3).Data
+	if len(supp) != 3 {
+		t.Fatal("The support must contain the first moments when Beta1 is not zero")
+	}
+	if r, c := supp[2].Dims(); r != 4 || c != 3 {
+		t.Error("The first moments have wrong dimensions")
+	}
+}
+
+func Test_Update(t *testing.T) {
+	updater := New(NewDefaultConfig())
+
+	params := mat.NewDense(2, 3, []float64{
+		1.4, 1.3, 0.0,
+		-0.8, 0.16, 0.65,
+	})
+	supp := updater.NewSupport(params.Dims()).Data
+
+	// === First iteration
+
+	grads := mat.NewDense(2, 3, []float64{
+		0.5, 0.3, -0.1,
+		-0.6, -0.4, -1.0,
+	})
+	params.SubInPlace(updater.calcDelta(grads, params, supp))
+
+	if !floats.EqualApprox(supp[0].Data(), []float64{0.11666666666666665, 0.5066666666666667}, 1.0e-6) {
+		t.Error("The row moments don't match the expected values (first iteration)")
+	}
+	if !floats.EqualApprox(supp[1].Data(), []float64{0.305, 0.125, 0.505}, 1.0e-6) {
+		t.Error("The column moments don't match the expected values (first iteration)")
+	}
+	if !floats.EqualApprox(params.Data(), []float64{
+		1.387735577, 1.288505415, 0.001906257,
+		-0.79293779, 0.167354349, 0.659147314,
+	}, 1.0e-6) {
+		t.Error("The updated params don't match the expected values (first iteration)")
+	}
+
+	// === Second iteration
+
+	updater.IncExample()
+
+	grads2 := mat.NewDense(2, 3, []float64{
+		0.7, 0.44, -0.66,
+		-0.56, 0.4, 1.4,
+	})
+	params.SubInPlace(updater.calcDelta(grads2, params, supp))
+
+	if !floats.EqualApprox(supp[0].Data(), []float64{0.263929796, 0.681575136}, 1.0e-6) {
+		t.Error("The row moments don't match the expected values (second iteration)")
+	}
+	if !floats.EqualApprox(supp[1].Data(), []float64{0.360597, 0.154751287, 0.90290911}, 1.0e-6) {
+		t.Error("The column moments don't match the expected values (second iteration)")
+	}
+	if !floats.EqualApprox(params.Data(), []float64{
+		1.376069558, 1.277311778, 0.008857422,
+		-0.787130146, 0.161021985, 0.649971829,
+	}, 1.0e-6) {
+		t.Error("The updated params don't match the expected values (second iteration)")
+	}
+}
+
+func Test_UpdateWithMomentumAndWeightDecay(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Beta1 = 0.9
+	config.WeightDecay = 0.1
+	updater := New(config)
+
+	params := mat.NewDense(2, 3, []float64{
+		1.4, 1.3, 0.0,
+		-0.8, 0.16, 0.65,
+	})
+	supp := updater.NewSupport(params.Dims()).Data
+
+	grads := mat.NewDense(2, 3, []float64{
+		0.5, 0.3, -0.1,
+		-0.6, -0.4, -1.0,
+	})
+	params.SubInPlace(updater.calcDelta(grads, params, supp))
+
+	if !floats.EqualApprox(supp[2].Data(), []float64{
+		0.138013208, 0.129350113, -0.021451362,
+		-0.079471997, -0.082759485, -0.102935957,
+	}, 1.0e-6) {
+		t.Error("The first moments don't match the expected values")
+	}
+	if !floats.EqualApprox(params.Data(), []float64{
+		1.39752946, 1.297695308, 0.000190626,
+		-0.798582866, 0.160593252, 0.650337115,
+	}, 1.0e-6) {
+		t.Error("The updated params don't match the expected values")
+	}
+}
+
+func Test_PayloadSerialization(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Beta1 = 0.9
+	supp := New(config).NewSupport(3, 4)
+	supp.Data[0].SetData([]float64{0.1, 0.2, 0.3})
+	supp.Data[1].SetData([]float64{0.4, 0.5, 0.6, 0.7})
+
+	buf := new(bytes.Buffer)
+	n, err := nn.PayloadMarshalBinaryTo(supp, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, n2, err := nn.NewPayloadUnmarshalBinaryFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != n2 {
+		t.Errorf("The number of bytes read (%d) doesn't match the number of bytes written (%d)", n2, n)
+	}
+	if decoded.Label != supp.Label || len(decoded.Data) != len(supp.Data) {
+		t.Fatal("The decoded payload doesn't match the original one")
+	}
+	for i, x := range supp.Data {
+		if !mat.SameDims(x, decoded.Data[i]) || !floats.Equal(x.Data(), decoded.Data[i].Data()) {
+			t.Errorf("The decoded support matrix %d doesn't match the original one", i)
+		}
+	}
+}
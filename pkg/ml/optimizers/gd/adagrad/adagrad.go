@@ -35,7 +35,10 @@ func NewDefaultConfig() Config {
 	}
 }
 
-var _ gd.Method = &AdaGrad{}
+var (
+	_ gd.Method             = &AdaGrad{}
+	_ gd.LearningRateSetter = &AdaGrad{}
+)
 
 // AdaGrad assigns a different learning rate to each parameter using the sum of squares of its all historical gradients.
 // References
@@ -57,6 +60,16 @@ func (o *AdaGrad) Label() int {
 	return gd.AdaGrad
 }
 
+// LearningRate returns the current learning rate.
+func (o *AdaGrad) LearningRate() float64 {
+	return o.LR
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *AdaGrad) SetLearningRate(lr float64) {
+	o.LR = lr
+}
+
 // NewSupport returns a new support structure with the given dimensions.
 func (o *AdaGrad) NewSupport(r, c int) *nn.Payload {
 	return &nn.Payload{
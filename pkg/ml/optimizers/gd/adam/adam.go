@@ -48,7 +48,10 @@ func NewDefaultConfig() Config {
 	}
 }
 
-var _ gd.Method = &Adam{}
+var (
+	_ gd.Method             = &Adam{}
+	_ gd.LearningRateSetter = &Adam{}
+)
 
 // Adam implements the Adam gradient descent optimization method.
 type Adam struct {
@@ -72,6 +75,17 @@ func (o *Adam) Label() int {
 	return gd.Adam
 }
 
+// LearningRate returns the current learning rate.
+func (o *Adam) LearningRate() float64 {
+	return o.StepSize
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *Adam) SetLearningRate(lr float64) {
+	o.StepSize = lr
+	o.updateAlpha()
+}
+
 const (
 	v    int = 0
 	m    int = 1
@@ -0,0 +1,170 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package adamw
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"math"
+)
+
+var _ gd.MethodConfig = &Config{}
+
+// Config provides configuration settings for an AdamW optimizer.
+type Config struct {
+	gd.MethodConfig
+	StepSize    float64
+	Beta1       float64
+	Beta2       float64
+	Epsilon     float64
+	WeightDecay float64
+}
+
+// NewConfig returns a new AdamW Config.
+// It panics if beta1 or beta2 are not in the range [0.0, 1.0), or if the weight decay is negative.
+func NewConfig(stepSize, beta1, beta2, epsilon, weightDecay float64) Config {
+	if !(beta1 >= 0.0 && beta1 < 1.0) {
+		panic("adamw: `beta1` must be in the range [0.0, 1.0)")
+	}
+	if !(beta2 >= 0.0 && beta2 < 1.0) {
+		panic("adamw: `beta2` must be in the range [0.0, 1.0)")
+	}
+	if weightDecay < 0.0 {
+		panic("adamw: `weightDecay` must be greater than or equal to 0.0")
+	}
+	return Config{
+		StepSize:    stepSize,
+		Beta1:       beta1,
+		Beta2:       beta2,
+		Epsilon:     epsilon,
+		WeightDecay: weightDecay,
+	}
+}
+
+// NewDefaultConfig returns a new Config with generically reasonable default values.
+func NewDefaultConfig() Config {
+	return Config{
+		StepSize:    0.001,
+		Beta1:       0.9,
+		Beta2:       0.999,
+		Epsilon:     1.0e-8,
+		WeightDecay: 0.01,
+	}
+}
+
+var (
+	_ gd.Method             = &AdamW{}
+	_ gd.LearningRateSetter = &AdamW{}
+)
+
+// AdamW implements the Adam gradient descent optimization method with decoupled weight decay
+// (Loshchilov and Hutter, 2019, "Decoupled Weight Decay Regularization").
+//
+// Differently from adding an L2 penalty to the loss, the weight decay is not scaled by the
+// adaptive learning rate: it is applied directly to the params, proportionally to the step size.
+type AdamW struct {
+	Config
+	Alpha    float64
+	TimeStep int
+}
+
+// New returns a new AdamW optimizer, initialized according to the given configuration.
+func New(c Config) *AdamW {
+	adamw := &AdamW{
+		Config: c,
+		Alpha:  c.StepSize,
+	}
+	adamw.IncExample() // initialize 'alpha' coefficient
+	return adamw
+}
+
+// Label returns the enumeration-like value which identifies this gradient descent method.
+func (o *AdamW) Label() int {
+	return gd.AdamW
+}
+
+// LearningRate returns the current learning rate.
+func (o *AdamW) LearningRate() float64 {
+	return o.StepSize
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *AdamW) SetLearningRate(lr float64) {
+	o.StepSize = lr
+	o.updateAlpha()
+}
+
+const (
+	v    int = 0
+	m    int = 1
+	buf1 int = 2 // contains 'grads.ProdScalar(1.0 - beta1)'
+	buf2 int = 3 // contains 'grads.Prod(grads).ProdScalar(1.0 - beta2)'
+	buf3 int = 4
+)
+
+// NewSupport returns a new support structure with the given dimensions.
+func (o *AdamW) NewSupport(r, c int) *nn.Payload {
+	supp := make([]mat.Matrix, 5)
+	supp[v] = mat.NewEmptyDense(r, c)
+	supp[m] = mat.NewEmptyDense(r, c)
+	supp[buf1] = mat.NewEmptyDense(r, c)
+	supp[buf2] = mat.NewEmptyDense(r, c)
+	supp[buf3] = mat.NewEmptyDense(r, c)
+	return &nn.Payload{
+		Label: o.Label(),
+		Data:  supp,
+	}
+}
+
+// IncExample beats the occurrence of a new example.
+func (o *AdamW) IncExample() {
+	o.TimeStep++
+	o.updateAlpha()
+}
+
+func (o *AdamW) updateAlpha() {
+	o.Alpha = o.StepSize * math.Sqrt(1.0-math.Pow(o.Beta2, float64(o.TimeStep))) / (1.0 - math.Pow(o.Beta1, float64(o.TimeStep)))
+}
+
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *AdamW) Delta(param *nn.Param) mat.Matrix {
+	return o.calcDelta(param.Grad(), param.Value(), gd.GetOrSetPayload(param, o).Data)
+}
+
+// v = v*beta1 + grads*(1.0-beta1)
+// m = m*beta2 + (grads*grads)*(1.0-beta2)
+// d = (v / (sqrt(m) + eps)) * alpha + weights * (stepSize * weightDecay)
+func (o *AdamW) calcDelta(grads, weights mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	updateV(grads, supp, o.Beta1)
+	updateM(grads, supp, o.Beta2)
+	buf := supp[m].Sqrt().AddScalarInPlace(o.Epsilon)
+	defer mat.ReleaseDense(buf.(*mat.Dense))
+	suppDiv := supp[v].Div(buf)
+	defer mat.ReleaseDense(suppDiv.(*mat.Dense))
+	supp[buf3].ProdMatrixScalarInPlace(suppDiv, o.Alpha)
+	if o.WeightDecay != 0.0 {
+		decay := weights.ProdScalar(o.StepSize * o.WeightDecay)
+		defer mat.ReleaseDense(decay.(*mat.Dense))
+		supp[buf3].AddInPlace(decay)
+	}
+	return supp[buf3]
+}
+
+// v = v*beta1 + grads*(1.0-beta1)
+func updateV(grads mat.Matrix, supp []mat.Matrix, beta1 float64) {
+	supp[v].ProdScalarInPlace(beta1)
+	supp[buf1].ProdMatrixScalarInPlace(grads, 1.0-beta1)
+	supp[v].AddInPlace(supp[buf1])
+}
+
+// m = m*beta2 + (grads*grads)*(1.0-beta2)
+func updateM(grads mat.Matrix, supp []mat.Matrix, beta2 float64) {
+	supp[m].ProdScalarInPlace(beta2)
+	sqGrad := grads.Prod(grads)
+	defer mat.ReleaseDense(sqGrad.(*mat.Dense))
+	supp[buf2].ProdMatrixScalarInPlace(sqGrad, 1.0-beta2)
+	supp[m].AddInPlace(supp[buf2])
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package adamw
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func Test_Update(t *testing.T) {
+	updater := New(NewConfig(
+		0.001,  // step size
+		0.9,    // beta1
+		0.999,  // beta2
+		1.0e-8, // epsilon
+		0.01,   // weight decay
+	))
+
+	params := mat.NewVecDense([]float64{0.4, 0.4, 0.5, 1.0, 0.8})
+	grads := mat.NewVecDense([]float64{0.9, 0.7, 0.4, 0.8, 0.1})
+
+	supp := updater.NewSupport(params.Dims()).Data
+	supp[v].SetData([]float64{0.7, 0.8, 0.5, 0.3, 0.2})
+	supp[m].SetData([]float64{1.0, 0.4, 0.7, 0.0, 0.2})
+
+	params.SubInPlace(updater.calcDelta(grads, params, supp))
+
+	if !floats.EqualApprox(params.Data(), []float64{0.399768294, 0.399601044, 0.499809726, 0.995615002, 0.799857586}, 1.0e-6) {
+		t.Error("The updated params don't match the expected values")
+	}
+}
+
+func Test_UpdateWithoutWeightDecay(t *testing.T) {
+	updater := New(NewConfig(
+		0.001,  // step size
+		0.9,    // beta1
+		0.999,  // beta2
+		1.0e-8, // epsilon
+		0.0,    // weight decay
+	))
+
+	params := mat.NewVecDense([]float64{0.4, 0.4, 0.5, 1.0, 0.8})
+	grads := mat.NewVecDense([]float64{0.9, 0.7, 0.4, 0.8, 0.1})
+
+	supp := updater.NewSupport(params.Dims()).Data
+	supp[v].SetData([]float64{0.7, 0.8, 0.5, 0.3, 0.2})
+	supp[m].SetData([]float64{1.0, 0.4, 0.7, 0.0, 0.2})
+
+	params.SubInPlace(updater.calcDelta(grads, params, supp))
+
+	// same as Adam
+	if !floats.EqualApprox(params.Data(), []float64{0.399772, 0.399605, 0.4998147, 0.995625, 0.799865}, 1.0e-6) {
+		t.Error("The updated params don't match the expected values")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/utils"
+)
+
+// Checkpoint saves and restores the state of a training to a file, so that an interrupted training can be
+// resumed: the state of the optimizer of the models (see StateSerializer), the state of the random generator
+// and the progress of the trainer (e.g. the number of processed examples).
+// The values of the params are not included: they can be serialized separately with nn.ParamsSerializer.
+type Checkpoint struct {
+	path      string
+	optimizer *GradientDescent
+	rndGen    *rand.LockedRand
+	models    []nn.Model
+}
+
+// NewCheckpoint returns a new Checkpoint of the training of the given models, saved to the given path.
+// If the path is empty, the checkpoint is never saved.
+func NewCheckpoint(path string, optimizer *GradientDescent, rndGen *rand.LockedRand, models ...nn.Model) *Checkpoint {
+	return &Checkpoint{
+		path:      path,
+		optimizer: optimizer,
+		rndGen:    rndGen,
+		models:    models,
+	}
+}
+
+// checkpointState is the metadata of the state of the optimizer.
+type checkpointState struct {
+	RandState []byte
+	Progress  interface{}
+}
+
+// Save saves the state of the training together with the JSON encoding of the progress, if the path is defined.
+// It returns an error if the optimizer has pending micro-batches (see PendingMicroBatches).
+func (c *Checkpoint) Save(progress interface{}) error {
+	if c.path == "" {
+		return nil
+	}
+	randState, err := c.rndGen.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	state := &checkpointState{RandState: randState, Progress: progress}
+	return utils.SerializeToFile(c.path, NewStateSerializer(c.optimizer, c.models...).WithMetadata(state))
+}
+
+// Load restores the state of the training saved by Save. The progress must be a pointer, which is assigned
+// the saved value.
+func (c *Checkpoint) Load(progress interface{}) error {
+	state := &checkpointState{Progress: progress}
+	err := utils.DeserializeFromFile(c.path, NewStateSerializer(c.optimizer, c.models...).WithMetadata(state))
+	if err != nil {
+		return err
+	}
+	return c.rndGen.UnmarshalBinary(state.RandState)
+}
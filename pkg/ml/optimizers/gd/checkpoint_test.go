@@ -0,0 +1,64 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd_test
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"gonum.org/v1/gonum/floats"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gd_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+
+	m := newTestModel()
+	o := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(m))
+	rndGen := rand.NewLockedRand(42)
+	trainStep(m, o, 1)
+	rndGen.Int63()
+	if err := gd.NewCheckpoint(path, o, rndGen, m).Save(3); err != nil {
+		t.Fatal(err)
+	}
+
+	m2 := newTestModel()
+	nn.LoadParamsVector(m2, nn.DumpParamsVector(m))
+	o2 := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(m2))
+	rndGen2 := rand.NewLockedRand(1)
+	var progress int
+	if err := gd.NewCheckpoint(path, o2, rndGen2, m2).Load(&progress); err != nil {
+		t.Fatal(err)
+	}
+	if progress != 3 {
+		t.Errorf("Expected the progress 3, got %d", progress)
+	}
+	if rndGen2.Int63() != rndGen.Int63() {
+		t.Error("The random generator doesn't match the original one")
+	}
+
+	trainStep(m, o, 2)
+	trainStep(m2, o2, 2)
+	if !floats.EqualApprox(nn.DumpParamsVector(m2).Data(), nn.DumpParamsVector(m).Data(), 1.0e-12) {
+		t.Error("The resumed training doesn't match the original one")
+	}
+}
+
+func TestCheckpointWithoutPath(t *testing.T) {
+	m := newTestModel()
+	o := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(m))
+	if err := gd.NewCheckpoint("", o, rand.NewLockedRand(42), m).Save(0); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ema implements the Exponential Moving Average (EMA) of the parameters of a model.
+//
+// The EMA keeps a shadow copy of each parameter, which is updated after each optimization step:
+//    shadow = decay * shadow + (1 - decay) * param
+// The averaged weights are usually more stable than the trained ones, so they are preferred for the evaluation
+// and the checkpoints. To update the EMA after each step, pass its Update method to the optimizer:
+//    e := ema.New(model, 0.999)
+//    optimizer := gd.NewOptimizer(method, nn.NewDefaultParamsIterator(model), gd.OnOptimize(e.Update))
+package ema
+
+import (
+	"math"
+	"sync"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/internal/shadow"
+)
+
+// EMA keeps the exponential moving average of the parameters of a model.
+type EMA struct {
+	decay      float64
+	warmup     bool
+	numUpdates int
+	shadows    *shadow.Params
+	mu         sync.Mutex
+}
+
+// Option allows to configure a new EMA with your specific needs.
+type Option func(*EMA)
+
+// Warmup is an option to use a lower decay during the first updates, so that the average is not
+// dominated by the initial values of the parameters:
+//    decay = min(decay, (1 + n) / (10 + n))
+// where n is the number of updates.
+func Warmup() Option {
+	return func(e *EMA) {
+		e.warmup = true
+	}
+}
+
+// New returns a new EMA of the parameters of the given model, starting from their current values.
+// The parameters are collected once; the parameters shared by several sub-models are averaged once.
+func New(m nn.Model, decay float64, opts ...Option) *EMA {
+	e := &EMA{
+		decay:   decay,
+		shadows: shadow.New(m, "ema"),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Decay returns the decay used by the next update.
+func (e *EMA) Decay() float64 {
+	if !e.warmup {
+		return e.decay
+	}
+	n := float64(e.numUpdates)
+	return math.Min(e.decay, (1.0+n)/(10.0+n))
+}
+
+// Update updates the shadow parameters with the current values of the model parameters.
+func (e *EMA) Update() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	decay := e.Decay()
+	e.shadows.Update(func(value, shadow mat.Matrix) {
+		update := value.ProdScalar(1.0 - decay)
+		shadow.ProdScalarInPlace(decay).AddInPlace(update)
+		mat.ReleaseDense(update.(*mat.Dense))
+	})
+	e.numUpdates++
+}
+
+// Swap exchanges the values of the model parameters with the shadow ones.
+// Calling it twice restores the original values.
+func (e *EMA) Swap() {
+	e.shadows.Swap()
+}
+
+// WithShadowWeights temporarily swaps in the shadow weights while executing the callback,
+// e.g. for the evaluation of the model.
+func (e *EMA) WithShadowWeights(callback func()) {
+	e.shadows.With(callback)
+}
+
+// Shadow returns a model containing the shadow parameters, laid out like the parameters of the
+// original model, shared ones included. Saved with nn.ParamsSerializer, it can be loaded into the original model.
+func (e *EMA) Shadow() nn.Model {
+	return e.shadows.Model()
+}
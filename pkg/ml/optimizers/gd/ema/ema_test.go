@@ -0,0 +1,69 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ema
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestEMA(t *testing.T) {
+	model := linear.New(1, 2)
+	model.W.Value().SetData([]float64{1.0, 2.0})
+	e := New(model, 0.5)
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(1.0, 0.0, false)), nn.NewDefaultParamsIterator(model),
+		gd.OnOptimize(e.Update))
+
+	model.W.PropagateGrad(mat.NewDense(2, 1, []float64{1.0, 1.0}))
+	optimizer.Optimize()
+
+	if !floats.EqualApprox(model.W.Value().Data(), []float64{0.0, 1.0}, 1.0e-6) {
+		t.Fatal("The optimized params don't match the expected values")
+	}
+	if !floats.EqualApprox(nn.DumpParamsVector(e.Shadow()).Data()[:2], []float64{0.5, 1.5}, 1.0e-6) {
+		t.Error("The shadow params don't match the expected values")
+	}
+
+	e.WithShadowWeights(func() {
+		if !floats.EqualApprox(model.W.Value().Data(), []float64{0.5, 1.5}, 1.0e-6) {
+			t.Error("The shadow weights are expected to be swapped in")
+		}
+	})
+	if !floats.EqualApprox(model.W.Value().Data(), []float64{0.0, 1.0}, 1.0e-6) {
+		t.Error("The original weights are expected to be restored")
+	}
+
+	var buf bytes.Buffer
+	if _, err := nn.NewParamsSerializer(e.Shadow()).Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := linear.New(1, 2)
+	if _, err := nn.NewParamsSerializer(loaded).Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !floats.EqualApprox(loaded.W.Value().Data(), []float64{0.5, 1.5}, 1.0e-6) {
+		t.Error("The serialized shadow weights are expected to be loadable by the original model")
+	}
+}
+
+func TestEMA_Warmup(t *testing.T) {
+	e := New(linear.New(1, 1), 0.99, Warmup())
+	if !floats.EqualWithinAbs(e.Decay(), 0.1, 1.0e-9) {
+		t.Errorf("Expected decay 0.1 at the first update, got %f", e.Decay())
+	}
+	for i := 0; i < 1000; i++ {
+		e.Update()
+	}
+	if e.Decay() != 0.99 {
+		t.Errorf("Expected decay 0.99 after the warmup, got %f", e.Decay())
+	}
+}
@@ -17,8 +17,23 @@ type GradientDescent struct {
 	gradClipper      clipper.GradClipper
 	paramsIterator   nn.ParamsIterator
 	paramsToOptimize []*nn.Param
+	onOptimize       []func()
+	lrSchedule       LearningRateSchedule
+	lrScheduleEvent  scheduleEvent
+	lrTimeStep       int
+	paramGroups      []*paramGroup
+	accumulation     *accumulation
 }
 
+// scheduleEvent identifies the occurrence which advances the learning rate schedule.
+type scheduleEvent int
+
+const (
+	onExample scheduleEvent = iota
+	onBatch
+	onEpoch
+)
+
 // Option allows to configure a new GradientDescent with your specific needs.
 type Option func(*GradientDescent)
 
@@ -40,6 +55,39 @@ func ClipGradByNorm(max, normType float64) Option {
 	}
 }
 
+// OnOptimize is an option to invoke the given callback at the end of each Optimize call,
+// e.g. to update an exponential moving average of the parameters (see package ema).
+func OnOptimize(callback func()) Option {
+	return func(f *GradientDescent) {
+		f.onOptimize = append(f.onOptimize, callback)
+	}
+}
+
+// ScheduleLRByExample is an option to set the learning rate of the method according to the given schedule,
+// advancing it at each new example (see IncExample).
+func ScheduleLRByExample(schedule LearningRateSchedule) Option {
+	return scheduleLR(schedule, onExample)
+}
+
+// ScheduleLRByBatch is an option to set the learning rate of the method according to the given schedule,
+// advancing it at each new batch (see IncBatch).
+func ScheduleLRByBatch(schedule LearningRateSchedule) Option {
+	return scheduleLR(schedule, onBatch)
+}
+
+// ScheduleLRByEpoch is an option to set the learning rate of the method according to the given schedule,
+// advancing it at each new epoch (see IncEpoch).
+func ScheduleLRByEpoch(schedule LearningRateSchedule) Option {
+	return scheduleLR(schedule, onEpoch)
+}
+
+func scheduleLR(schedule LearningRateSchedule, event scheduleEvent) Option {
+	return func(f *GradientDescent) {
+		f.lrSchedule = schedule
+		f.lrScheduleEvent = event
+	}
+}
+
 // NewOptimizer returns a new GradientDescent optimizer. The gradient clipper can be set to nil.
 func NewOptimizer(method Method, paramsIterator nn.ParamsIterator, opts ...Option) *GradientDescent {
 	optimizer := &GradientDescent{
@@ -50,19 +98,73 @@ func NewOptimizer(method Method, paramsIterator nn.ParamsIterator, opts ...Optio
 	for _, opt := range opts {
 		opt(optimizer)
 	}
+	if optimizer.lrSchedule != nil {
+		optimizer.updateLearningRate()
+	}
 	return optimizer
 }
 
+// LearningRate returns the current learning rate of the method.
+// It panics if the method doesn't implement LearningRateSetter.
+func (o *GradientDescent) LearningRate() float64 {
+	return o.learningRateSetter().LearningRate()
+}
+
+// updateLearningRate sets the learning rate of the method according to the schedule.
+func (o *GradientDescent) updateLearningRate() {
+	o.learningRateSetter().SetLearningRate(o.lrSchedule.LearningRate(o.lrTimeStep))
+}
+
+func (o *GradientDescent) learningRateSetter() LearningRateSetter {
+	method, ok := o.method.(LearningRateSetter)
+	if !ok {
+		panic("gd: the optimization method doesn't support the change of the learning rate")
+	}
+	return method
+}
+
+// advanceSchedule moves the learning rate schedule one step forward, if it is driven by the given event.
+func (o *GradientDescent) advanceSchedule(event scheduleEvent) {
+	if o.lrSchedule == nil || o.lrScheduleEvent != event {
+		return
+	}
+	o.lrTimeStep++
+	o.updateLearningRate()
+}
+
 // Optimize optimize the params, applying the optional gradient clipping.
 // After the optimization the params have zero gradients.
+// If the gradients are accumulated (see AccumulateGrads), the params are optimized only once every
+// the given number of calls, otherwise the gradients are kept.
 func (o *GradientDescent) Optimize() {
+	if o.accumulation != nil && !o.accumulation.next() {
+		return
+	}
+	o.optimize()
+}
+
+func (o *GradientDescent) optimize() {
 	o.paramsToOptimize = o.paramsIterator.ParamsList()
+	o.normalizeGrads()
 	if o.paramsToOptimize == nil {
 		return
 	}
 	o.clipGrads()
-	o.updateParams()
+	o.splitParams()
+	var wg sync.WaitGroup
+	o.updateParamsAsync(&wg)
+	for _, group := range o.paramGroups {
+		group.optimizer.clipGrads()
+		group.optimizer.updateParamsAsync(&wg)
+	}
+	wg.Wait()
 	o.paramsToOptimize = nil
+	for _, group := range o.paramGroups {
+		group.optimizer.paramsToOptimize = nil
+	}
+	for _, callback := range o.onOptimize {
+		callback()
+	}
 }
 
 // updateParamsSerial applies the optimization method to all the observed parameters.
@@ -76,10 +178,10 @@ func (o *GradientDescent) updateParamsSerial() {
 	}
 }
 
-// updateParams applies the optimization method to all the observed parameters concurrently.
+// updateParamsAsync applies the optimization method to all the observed parameters concurrently,
+// adding the pending updates to the wait group.
 // TODO: distribute the workload proportionately to the number of available CPUs?
-func (o *GradientDescent) updateParams() {
-	var wg sync.WaitGroup
+func (o *GradientDescent) updateParamsAsync(wg *sync.WaitGroup) {
 	for _, param := range o.paramsToOptimize {
 		if param.HasGrad() {
 			wg.Add(1)
@@ -91,7 +193,6 @@ func (o *GradientDescent) updateParams() {
 			}(param)
 		}
 	}
-	wg.Wait()
 }
 
 // clipGrad applies the gradient clipping to all the observed parameters.
@@ -110,16 +211,30 @@ func (o *GradientDescent) clipGrads() {
 
 // IncExample beats the occurrence of a new example.
 func (o *GradientDescent) IncExample() {
+	if o.accumulation != nil && !o.accumulation.beat(onExample) {
+		return
+	}
 	if method, ok := o.method.(ExampleScheduler); ok {
 		method.IncExample()
 	}
+	o.advanceSchedule(onExample)
+	for _, group := range o.paramGroups {
+		group.optimizer.IncExample()
+	}
 }
 
 // IncBatch beats the occurrence of a new batch.
 func (o *GradientDescent) IncBatch() {
+	if o.accumulation != nil && !o.accumulation.beat(onBatch) {
+		return
+	}
 	if method, ok := o.method.(BatchScheduler); ok {
 		method.IncBatch()
 	}
+	o.advanceSchedule(onBatch)
+	for _, group := range o.paramGroups {
+		group.optimizer.IncBatch()
+	}
 }
 
 // IncEpoch beats the occurrence of a new epoch.
@@ -127,4 +242,8 @@ func (o *GradientDescent) IncEpoch() {
 	if method, ok := o.method.(EpochScheduler); ok {
 		method.IncEpoch()
 	}
+	o.advanceSchedule(onEpoch)
+	for _, group := range o.paramGroups {
+		group.optimizer.IncEpoch()
+	}
 }
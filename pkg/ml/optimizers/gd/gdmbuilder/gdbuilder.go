@@ -6,8 +6,11 @@ package gdmbuilder
 
 import (
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adafactor"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adagrad"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adamw"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lamb"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/radam"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/rmsprop"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
@@ -22,6 +25,12 @@ func NewMethod(config gd.MethodConfig) gd.Method {
 		return adagrad.New(config)
 	case adam.Config:
 		return adam.New(config)
+	case adamw.Config:
+		return adamw.New(config)
+	case adafactor.Config:
+		return adafactor.New(config)
+	case lamb.Config:
+		return lamb.New(config)
 	case radam.Config:
 		return radam.New(config)
 	case rmsprop.Config:
@@ -0,0 +1,100 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package shadow implements the shadow copies of the parameters of a model, which are updated along the
+// optimization according to a rule of their own, e.g. the moving averages of the packages ema and swa.
+package shadow
+
+import (
+	"sync"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var _ nn.Model = &shadowModel{}
+
+// Params contains a shadow copy of each parameter of a model.
+type Params struct {
+	params  []*nn.Param
+	shadows []*nn.Param
+	model   *shadowModel
+	mu      sync.Mutex
+}
+
+// New returns the shadow copies of the parameters of the given model, starting from their current values.
+// The parameters are collected once; the parameters shared by several sub-models have a single copy.
+// The name identifies the owner of the copies in the panic messages.
+func New(m nn.Model, name string) *Params {
+	p := &Params{
+		model: &shadowModel{name: name},
+	}
+	visited := make(map[*nn.Param]*nn.Param)
+	nn.ForEachParam(m, func(param *nn.Param) {
+		shadow, ok := visited[param]
+		if !ok {
+			shadow = nn.NewParam(param.Value().Clone(), nn.RequiresGrad(false))
+			visited[param] = shadow
+			p.params = append(p.params, param)
+			p.shadows = append(p.shadows, shadow)
+		}
+		p.model.Params = append(p.model.Params, shadow)
+	})
+	return p
+}
+
+// Len returns the number of distinct parameters.
+func (p *Params) Len() int {
+	return len(p.params)
+}
+
+// Update calls the callback with the value of each distinct parameter and the value of its shadow copy,
+// which can be modified in place.
+func (p *Params) Update(callback func(value, shadow mat.Matrix)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, param := range p.params {
+		callback(param.Value(), p.shadows[i].Value())
+	}
+}
+
+// Swap exchanges the values of the model parameters with the shadow ones.
+// Calling it twice restores the original values.
+func (p *Params) Swap() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, param := range p.params {
+		value := param.Value()
+		shadow := p.shadows[i].Value()
+		backup := value.Clone()
+		value.SetData(shadow.Data())
+		shadow.SetData(backup.Data())
+		mat.ReleaseDense(backup.(*mat.Dense))
+	}
+}
+
+// With temporarily swaps in the shadow values while executing the callback.
+func (p *Params) With(callback func()) {
+	p.Swap()
+	defer p.Swap()
+	callback()
+}
+
+// Model returns a model containing the shadow parameters. A parameter shared by several sub-models
+// of the original model is repeated at each of its occurrences, as done by nn.ForEachParam, so that the
+// file written by nn.ParamsSerializer has the same layout of the one of the original model.
+func (p *Params) Model() nn.Model {
+	return p.model
+}
+
+// shadowModel contains the shadow parameters.
+type shadowModel struct {
+	Params []*nn.Param
+	name   string
+}
+
+// NewProc is not implemented for the shadow model (it always panics).
+func (m *shadowModel) NewProc(_ nn.Context) nn.Processor {
+	panic(m.name + ": the shadow model cannot be processed; swap the weights instead.")
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestParams(t *testing.T) {
+	model := linear.New(1, 2)
+	model.W.Value().SetData([]float64{1.0, 2.0})
+	p := New(model, "test")
+	p.Update(func(value, shadow mat.Matrix) {
+		shadow.ProdScalarInPlace(2.0)
+	})
+
+	p.With(func() {
+		if !floats.EqualApprox(model.W.Value().Data(), []float64{2.0, 4.0}, 1.0e-9) {
+			t.Error("The shadow weights are expected to be swapped in")
+		}
+	})
+	if !floats.EqualApprox(model.W.Value().Data(), []float64{1.0, 2.0}, 1.0e-9) {
+		t.Error("The original weights are expected to be restored")
+	}
+}
+
+// sharedModel uses the same layer twice, followed by another one.
+type sharedModel struct {
+	First  *linear.Model
+	Second *linear.Model
+	Output *linear.Model
+}
+
+func newSharedModel() *sharedModel {
+	layer := linear.New(1, 1)
+	return &sharedModel{First: layer, Second: layer, Output: linear.New(1, 1)}
+}
+
+func (m *sharedModel) NewProc(_ nn.Context) nn.Processor {
+	panic("not implemented")
+}
+
+func TestParams_Shared(t *testing.T) {
+	model := newSharedModel()
+	model.First.W.Value().SetData([]float64{1.0})
+	model.Output.W.Value().SetData([]float64{2.0})
+	p := New(model, "test")
+	if p.Len() != 4 {
+		t.Fatalf("Expected the shared params to be copied once, got %d params", p.Len())
+	}
+	p.Update(func(value, shadow mat.Matrix) {
+		shadow.AddScalarInPlace(1.0)
+	})
+
+	var buf bytes.Buffer
+	if _, err := nn.NewParamsSerializer(p.Model()).Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := newSharedModel()
+	if _, err := nn.NewParamsSerializer(loaded).Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !floats.EqualApprox(loaded.First.W.Value().Data(), []float64{2.0}, 1.0e-9) ||
+		!floats.EqualApprox(loaded.Output.W.Value().Data(), []float64{3.0}, 1.0e-9) {
+		t.Error("The serialized shadow weights are expected to be loadable by the original model")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lamb
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"math"
+)
+
+var _ gd.MethodConfig = &Config{}
+
+// Config provides configuration settings for a LAMB optimizer.
+type Config struct {
+	gd.MethodConfig
+	StepSize    float64
+	Beta1       float64
+	Beta2       float64
+	Epsilon     float64
+	WeightDecay float64
+}
+
+// NewConfig returns a new LAMB Config.
+// It panics if beta1 or beta2 are not in the range [0.0, 1.0), or if the weight decay is negative.
+func NewConfig(stepSize, beta1, beta2, epsilon, weightDecay float64) Config {
+	if !(beta1 >= 0.0 && beta1 < 1.0) {
+		panic("lamb: `beta1` must be in the range [0.0, 1.0)")
+	}
+	if !(beta2 >= 0.0 && beta2 < 1.0) {
+		panic("lamb: `beta2` must be in the range [0.0, 1.0)")
+	}
+	if weightDecay < 0.0 {
+		panic("lamb: `weightDecay` must be greater than or equal to 0.0")
+	}
+	return Config{
+		StepSize:    stepSize,
+		Beta1:       beta1,
+		Beta2:       beta2,
+		Epsilon:     epsilon,
+		WeightDecay: weightDecay,
+	}
+}
+
+// NewDefaultConfig returns a new Config with generically reasonable default values.
+func NewDefaultConfig() Config {
+	return Config{
+		StepSize:    0.001,
+		Beta1:       0.9,
+		Beta2:       0.999,
+		Epsilon:     1.0e-6,
+		WeightDecay: 0.01,
+	}
+}
+
+var (
+	_ gd.Method             = &LAMB{}
+	_ gd.LearningRateSetter = &LAMB{}
+)
+
+// LAMB implements the LAMB gradient descent optimization method (You et al., 2020,
+// "Large Batch Optimization for Deep Learning: Training BERT in 76 minutes").
+//
+// The update of Adam, plus the decoupled weight decay, is scaled layer-wise (i.e. for each param)
+// by the trust ratio ||w|| / ||update||, which makes the training stable with very large batches.
+type LAMB struct {
+	Config
+	TimeStep int
+}
+
+// New returns a new LAMB optimizer, initialized according to the given configuration.
+func New(c Config) *LAMB {
+	return &LAMB{
+		Config:   c,
+		TimeStep: 1,
+	}
+}
+
+// Label returns the enumeration-like value which identifies this gradient descent method.
+func (o *LAMB) Label() int {
+	return gd.LAMB
+}
+
+// LearningRate returns the current learning rate.
+func (o *LAMB) LearningRate() float64 {
+	return o.StepSize
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *LAMB) SetLearningRate(lr float64) {
+	o.StepSize = lr
+}
+
+const (
+	v    int = 0
+	m    int = 1
+	buf1 int = 2 // contains 'grads.ProdScalar(1.0 - beta1)'
+	buf2 int = 3 // contains 'grads.Prod(grads).ProdScalar(1.0 - beta2)'
+	buf3 int = 4
+)
+
+// NewSupport returns a new support structure with the given dimensions.
+func (o *LAMB) NewSupport(r, c int) *nn.Payload {
+	supp := make([]mat.Matrix, 5)
+	supp[v] = mat.NewEmptyDense(r, c)
+	supp[m] = mat.NewEmptyDense(r, c)
+	supp[buf1] = mat.NewEmptyDense(r, c)
+	supp[buf2] = mat.NewEmptyDense(r, c)
+	supp[buf3] = mat.NewEmptyDense(r, c)
+	return &nn.Payload{
+		Label: o.Label(),
+		Data:  supp,
+	}
+}
+
+// IncExample beats the occurrence of a new example.
+func (o *LAMB) IncExample() {
+	o.TimeStep++
+}
+
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *LAMB) Delta(param *nn.Param) mat.Matrix {
+	return o.calcDelta(param.Grad(), param.Value(), gd.GetOrSetPayload(param, o).Data)
+}
+
+// v = v*beta1 + grads*(1.0-beta1)
+// m = m*beta2 + (grads*grads)*(1.0-beta2)
+// u = (v / (1.0-beta1^t)) / (sqrt(m / (1.0-beta2^t)) + eps) + weights * weightDecay
+// d = u * stepSize * (||weights|| / ||u||)
+func (o *LAMB) calcDelta(grads, weights mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	updateV(grads, supp, o.Beta1)
+	updateM(grads, supp, o.Beta2)
+	t := float64(o.TimeStep)
+	buf := supp[m].ProdScalar(1.0 / (1.0 - math.Pow(o.Beta2, t)))
+	defer mat.ReleaseDense(buf.(*mat.Dense))
+	sqrtBuf := buf.Sqrt().AddScalarInPlace(o.Epsilon)
+	defer mat.ReleaseDense(sqrtBuf.(*mat.Dense))
+	supp[buf3].ProdMatrixScalarInPlace(supp[v], 1.0/(1.0-math.Pow(o.Beta1, t)))
+	supp[buf3].DivInPlace(sqrtBuf)
+	if o.WeightDecay != 0.0 {
+		decay := weights.ProdScalar(o.WeightDecay)
+		defer mat.ReleaseDense(decay.(*mat.Dense))
+		supp[buf3].AddInPlace(decay)
+	}
+	supp[buf3].ProdScalarInPlace(o.StepSize * trustRatio(weights.Norm(2), supp[buf3].Norm(2)))
+	return supp[buf3]
+}
+
+// trustRatio returns the ratio between the norm of the weights and the norm of the update,
+// falling back to 1.0 when either of them is zero (e.g. for freshly initialized biases).
+func trustRatio(weightsNorm, updateNorm float64) float64 {
+	if weightsNorm == 0.0 || updateNorm == 0.0 {
+		return 1.0
+	}
+	return weightsNorm / updateNorm
+}
+
+// v = v*beta1 + grads*(1.0-beta1)
+func updateV(grads mat.Matrix, supp []mat.Matrix, beta1 float64) {
+	supp[v].ProdScalarInPlace(beta1)
+	supp[buf1].ProdMatrixScalarInPlace(grads, 1.0-beta1)
+	supp[v].AddInPlace(supp[buf1])
+}
+
+// m = m*beta2 + (grads*grads)*(1.0-beta2)
+func updateM(grads mat.Matrix, supp []mat.Matrix, beta2 float64) {
+	supp[m].ProdScalarInPlace(beta2)
+	sqGrad := grads.Prod(grads)
+	defer mat.ReleaseDense(sqGrad.(*mat.Dense))
+	supp[buf2].ProdMatrixScalarInPlace(sqGrad, 1.0-beta2)
+	supp[m].AddInPlace(supp[buf2])
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lamb
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func Test_Update(t *testing.T) {
+	updater := New(NewConfig(
+		0.001,  // step size
+		0.9,    // beta1
+		0.999,  // beta2
+		1.0e-6, // epsilon
+		0.01,   // weight decay
+	))
+
+	params := mat.NewVecDense([]float64{0.4, 0.4, 0.5, 1.0, 0.8})
+	supp := updater.NewSupport(params.Dims()).Data
+
+	// === First iteration
+
+	grads := mat.NewVecDense([]float64{0.9, 0.7, 0.4, 0.8, 0.1})
+	params.SubInPlace(updater.calcDelta(grads, params, supp))
+
+	if !floats.EqualApprox(params.Data(), []float64{0.399336623, 0.399336623, 0.499335963, 0.999332659, 0.799333986}, 1.0e-6) {
+		t.Error("The updated params don't match the expected values (first iteration)")
+	}
+
+	// === Second iteration
+
+	updater.IncExample()
+
+	grads2 := mat.NewVecDense([]float64{0.3, -0.2, 0.5, 0.1, -0.4})
+	params.SubInPlace(updater.calcDelta(grads2, params, supp))
+
+	if !floats.EqualApprox(params.Data(), []float64{0.398568752, 0.398947254, 0.498454387, 0.998659419, 0.799817941}, 1.0e-6) {
+		t.Error("The updated params don't match the expected values (second iteration)")
+	}
+}
+
+func Test_TrustRatio(t *testing.T) {
+	if trustRatio(0.0, 2.0) != 1.0 {
+		t.Error("The trust ratio must be 1.0 when the weights are zero")
+	}
+	if trustRatio(2.0, 0.0) != 1.0 {
+		t.Error("The trust ratio must be 1.0 when the update is zero")
+	}
+	if trustRatio(3.0, 2.0) != 1.5 {
+		t.Error("The trust ratio doesn't match the expected value")
+	}
+}
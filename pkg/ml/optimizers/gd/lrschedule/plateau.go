@@ -0,0 +1,144 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lrschedule
+
+import (
+	"encoding/json"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"math"
+)
+
+var _ gd.LearningRateSchedule = &Plateau{}
+
+// PlateauConfig provides configuration settings for a Plateau schedule.
+type PlateauConfig struct {
+	// Maximize is true when the observed metric must be maximized (e.g. the accuracy), false when it
+	// must be minimized (e.g. the loss).
+	Maximize bool
+	// Factor multiplies the learning rate at each reduction.
+	Factor float64
+	// Patience is the number of observations without improvement after which the learning rate is reduced.
+	Patience int
+	// Threshold is the minimum relative change of the metric which is considered an improvement.
+	Threshold float64
+	// Cooldown is the number of observations to wait after a reduction before resuming the normal operation.
+	Cooldown int
+	// MinLR is the lower bound of the learning rate.
+	MinLR float64
+}
+
+// NewDefaultPlateauConfig returns a new PlateauConfig with generically reasonable default values.
+func NewDefaultPlateauConfig() PlateauConfig {
+	return PlateauConfig{
+		Maximize:  false,
+		Factor:    0.1,
+		Patience:  10,
+		Threshold: 1.0e-4,
+		Cooldown:  0,
+		MinLR:     0.0,
+	}
+}
+
+// Plateau reduces the learning rate of another schedule when a metric, typically computed on a validation set,
+// has stopped improving. The metric is fed by Observe; the reductions apply to all the following steps.
+type Plateau struct {
+	PlateauConfig
+	schedule        gd.LearningRateSchedule
+	scale           float64
+	best            float64
+	badObservations int
+	cooldownCounter int
+	hasObservation  bool
+	numOfReductions int
+}
+
+// NewPlateau returns a new Plateau schedule. It panics if the factor is not in the range (0.0, 1.0).
+func NewPlateau(schedule gd.LearningRateSchedule, config PlateauConfig) *Plateau {
+	if !(config.Factor > 0.0 && config.Factor < 1.0) {
+		panic("lrschedule: `Factor` must be in the range (0.0, 1.0)")
+	}
+	return &Plateau{
+		PlateauConfig: config,
+		schedule:      schedule,
+		scale:         1.0,
+	}
+}
+
+// LearningRate returns the learning rate after t steps.
+func (s *Plateau) LearningRate(t int) float64 {
+	return math.Max(s.MinLR, s.schedule.LearningRate(t)*s.scale)
+}
+
+// NumOfReductions returns the number of times the learning rate has been reduced.
+func (s *Plateau) NumOfReductions() int {
+	return s.numOfReductions
+}
+
+// Observe records a new value of the metric, and returns whether the learning rate has been reduced.
+func (s *Plateau) Observe(metric float64) bool {
+	if !s.hasObservation || s.isImprovement(metric) {
+		s.best = metric
+		s.hasObservation = true
+		s.badObservations = 0
+	} else {
+		s.badObservations++
+	}
+	if s.cooldownCounter > 0 {
+		s.cooldownCounter--
+		s.badObservations = 0
+	}
+	if s.badObservations <= s.Patience {
+		return false
+	}
+	s.scale *= s.Factor
+	s.numOfReductions++
+	s.cooldownCounter = s.Cooldown
+	s.badObservations = 0
+	return true
+}
+
+func (s *Plateau) isImprovement(metric float64) bool {
+	if s.Maximize {
+		return metric > s.best*(1.0+math.Copysign(s.Threshold, s.best))
+	}
+	return metric < s.best*(1.0-math.Copysign(s.Threshold, s.best))
+}
+
+// plateauState is the serializable state of a Plateau schedule.
+type plateauState struct {
+	Scale           float64
+	Best            float64
+	BadObservations int
+	CooldownCounter int
+	HasObservation  bool
+	NumOfReductions int
+}
+
+// MarshalJSON returns the JSON encoding of the state of the schedule (see gd.StateSerializer).
+func (s *Plateau) MarshalJSON() ([]byte, error) {
+	return json.Marshal(plateauState{
+		Scale:           s.scale,
+		Best:            s.best,
+		BadObservations: s.badObservations,
+		CooldownCounter: s.cooldownCounter,
+		HasObservation:  s.hasObservation,
+		NumOfReductions: s.numOfReductions,
+	})
+}
+
+// UnmarshalJSON restores the state of the schedule from its JSON encoding.
+func (s *Plateau) UnmarshalJSON(data []byte) error {
+	var state plateauState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	s.scale = state.Scale
+	s.best = state.Best
+	s.badObservations = state.BadObservations
+	s.cooldownCounter = state.CooldownCounter
+	s.hasObservation = state.HasObservation
+	s.numOfReductions = state.NumOfReductions
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lrschedule
+
+import (
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func TestPlateau(t *testing.T) {
+	config := NewDefaultPlateauConfig()
+	config.Factor = 0.5
+	config.Patience = 1
+	config.MinLR = 0.2
+	s := NewPlateau(Constant(1.0), config)
+
+	var reduced []bool
+	for _, loss := range []float64{1.0, 0.9, 0.95, 0.92, 0.91, 0.91, 0.91, 0.91, 0.91} {
+		reduced = append(reduced, s.Observe(loss))
+	}
+	expected := []bool{false, false, false, true, false, true, false, true, false}
+	for i := range expected {
+		if reduced[i] != expected[i] {
+			t.Fatalf("Unexpected reductions: %v", reduced)
+		}
+	}
+	if s.NumOfReductions() != 3 {
+		t.Error("The number of reductions doesn't match the expected value")
+	}
+	if s.LearningRate(0) != 0.2 {
+		t.Error("The learning rate must not be lower than MinLR")
+	}
+}
+
+func TestPlateauMaximizeWithCooldown(t *testing.T) {
+	config := NewDefaultPlateauConfig()
+	config.Maximize = true
+	config.Factor = 0.5
+	config.Patience = 0
+	config.Cooldown = 2
+	s := NewPlateau(NewLinear(1.0, 0.0, 10), config)
+
+	var lrs []float64
+	for _, accuracy := range []float64{0.5, 0.6, 0.6, 0.6, 0.6, 0.6, 0.7} {
+		s.Observe(accuracy)
+		lrs = append(lrs, s.LearningRate(0))
+	}
+	if !floats.Equal(lrs, []float64{1.0, 1.0, 0.5, 0.5, 0.5, 0.25, 0.25}) {
+		t.Errorf("The learning rates don't match the expected values: %v", lrs)
+	}
+	if s.LearningRate(5) != 0.125 {
+		t.Error("The reductions must scale the underlying schedule")
+	}
+}
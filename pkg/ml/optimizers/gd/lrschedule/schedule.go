@@ -0,0 +1,250 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lrschedule provides learning rate schedules which can be used with any gradient descent method
+// implementing gd.LearningRateSetter (see gd.ScheduleLRByBatch and gd.ScheduleLRByEpoch).
+//
+// A schedule returns the learning rate after t steps, where a step is an example, a batch or an epoch,
+// according to how the schedule is attached to the optimizer. Schedules can be composed, e.g.
+//    NewWarmup(1000, NewLinear(5e-5, 0.0, 9000))
+// warms up the learning rate linearly for 1000 steps, then decays it linearly to zero in 9000 steps.
+package lrschedule
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"math"
+	"sort"
+)
+
+var (
+	_ gd.LearningRateSchedule = Constant(0)
+	_ gd.LearningRateSchedule = &Step{}
+	_ gd.LearningRateSchedule = &MultiStep{}
+	_ gd.LearningRateSchedule = &Linear{}
+	_ gd.LearningRateSchedule = &Cosine{}
+	_ gd.LearningRateSchedule = &OneCycle{}
+	_ gd.LearningRateSchedule = &Warmup{}
+)
+
+// Constant is a schedule which always returns the same learning rate.
+type Constant float64
+
+// LearningRate returns the learning rate after t steps.
+func (c Constant) LearningRate(_ int) float64 {
+	return float64(c)
+}
+
+// Step decays the learning rate by gamma every size steps:
+//    lr = init * gamma^floor(t / size)
+type Step struct {
+	init  float64
+	size  int
+	gamma float64
+}
+
+// NewStep returns a new Step schedule. It panics if size is not positive.
+func NewStep(init float64, size int, gamma float64) *Step {
+	if size <= 0 {
+		panic("lrschedule: the step size must be positive")
+	}
+	return &Step{
+		init:  init,
+		size:  size,
+		gamma: gamma,
+	}
+}
+
+// LearningRate returns the learning rate after t steps.
+func (s *Step) LearningRate(t int) float64 {
+	return s.init * math.Pow(s.gamma, float64(t/s.size))
+}
+
+// MultiStep decays the learning rate by gamma once the number of steps reaches each milestone.
+type MultiStep struct {
+	init       float64
+	milestones []int
+	gamma      float64
+}
+
+// NewMultiStep returns a new MultiStep schedule.
+func NewMultiStep(init float64, milestones []int, gamma float64) *MultiStep {
+	sorted := append([]int(nil), milestones...)
+	sort.Ints(sorted)
+	return &MultiStep{
+		init:       init,
+		milestones: sorted,
+		gamma:      gamma,
+	}
+}
+
+// LearningRate returns the learning rate after t steps.
+func (s *MultiStep) LearningRate(t int) float64 {
+	reached := sort.Search(len(s.milestones), func(i int) bool { return s.milestones[i] > t })
+	return s.init * math.Pow(s.gamma, float64(reached))
+}
+
+// Linear decays (or increases) the learning rate linearly from init to final in the given number of steps:
+//    lr = init + (final - init) * min(t, steps) / steps
+type Linear struct {
+	init  float64
+	final float64
+	steps int
+}
+
+// NewLinear returns a new Linear schedule. It panics if steps is not positive.
+func NewLinear(init, final float64, steps int) *Linear {
+	if steps <= 0 {
+		panic("lrschedule: the number of steps must be positive")
+	}
+	return &Linear{
+		init:  init,
+		final: final,
+		steps: steps,
+	}
+}
+
+// LearningRate returns the learning rate after t steps.
+func (s *Linear) LearningRate(t int) float64 {
+	if t >= s.steps {
+		return s.final
+	}
+	return s.init + (s.final-s.init)*float64(t)/float64(s.steps)
+}
+
+// Cosine anneals the learning rate from init to final following a half cosine wave, optionally with
+// warm restarts (Loshchilov and Hutter, 2017, "SGDR: Stochastic Gradient Descent with Warm Restarts"):
+//    lr = final + (init - final) * (1 + cos(pi * tc / T)) / 2
+// where tc is the number of steps since the last restart and T is the length of the current cycle.
+type Cosine struct {
+	init   float64
+	final  float64
+	period int
+	mult   int
+	cyclic bool
+}
+
+// NewCosine returns a new Cosine schedule, which anneals the learning rate in the given number of steps
+// and keeps it to final afterwards. It panics if steps is not positive.
+func NewCosine(init, final float64, steps int) *Cosine {
+	if steps <= 0 {
+		panic("lrschedule: the number of steps must be positive")
+	}
+	return &Cosine{
+		init:   init,
+		final:  final,
+		period: steps,
+		mult:   1,
+		cyclic: false,
+	}
+}
+
+// NewCosineWithRestarts returns a new Cosine schedule, which restarts from init at the end of each cycle.
+// The first cycle lasts period steps, and each following cycle is mult times longer than the previous one.
+// It panics if period or mult are not positive.
+func NewCosineWithRestarts(init, final float64, period, mult int) *Cosine {
+	if period <= 0 {
+		panic("lrschedule: the period must be positive")
+	}
+	if mult <= 0 {
+		panic("lrschedule: the period multiplier must be positive")
+	}
+	return &Cosine{
+		init:   init,
+		final:  final,
+		period: period,
+		mult:   mult,
+		cyclic: true,
+	}
+}
+
+// LearningRate returns the learning rate after t steps.
+func (s *Cosine) LearningRate(t int) float64 {
+	tc, period := t, s.period
+	if !s.cyclic {
+		if t >= period {
+			return s.final
+		}
+	} else {
+		for tc >= period {
+			tc -= period
+			period *= s.mult
+		}
+	}
+	return cosineAnnealing(s.init, s.final, float64(tc)/float64(period))
+}
+
+// cosineAnnealing returns the value between start and end after the given fraction of a half cosine wave.
+func cosineAnnealing(start, end, fraction float64) float64 {
+	return end + (start-end)*(1.0+math.Cos(math.Pi*fraction))/2.0
+}
+
+// OneCycle implements the 1cycle policy (Smith and Topin, 2018, "Super-Convergence: Very Fast Training
+// of Neural Networks Using Large Learning Rates"). The learning rate is annealed from maxLR/divFactor
+// up to maxLR in the first part of the steps, then down to maxLR/(divFactor*finalDivFactor).
+type OneCycle struct {
+	maxLR   float64
+	initLR  float64
+	finalLR float64
+	upSteps int
+	steps   int
+}
+
+// NewOneCycle returns a new OneCycle schedule. The fraction of the steps spent increasing the learning rate
+// is given by pctStart. It panics if steps is not positive or pctStart is not in the range (0.0, 1.0).
+func NewOneCycle(maxLR float64, steps int, pctStart, divFactor, finalDivFactor float64) *OneCycle {
+	if steps <= 0 {
+		panic("lrschedule: the number of steps must be positive")
+	}
+	if !(pctStart > 0.0 && pctStart < 1.0) {
+		panic("lrschedule: `pctStart` must be in the range (0.0, 1.0)")
+	}
+	initLR := maxLR / divFactor
+	return &OneCycle{
+		maxLR:   maxLR,
+		initLR:  initLR,
+		finalLR: initLR / finalDivFactor,
+		upSteps: int(math.Max(1, math.Round(pctStart*float64(steps)))),
+		steps:   steps,
+	}
+}
+
+// NewDefaultOneCycle returns a new OneCycle schedule with generically reasonable default values.
+func NewDefaultOneCycle(maxLR float64, steps int) *OneCycle {
+	return NewOneCycle(maxLR, steps, 0.3, 25.0, 1.0e4)
+}
+
+// LearningRate returns the learning rate after t steps.
+func (s *OneCycle) LearningRate(t int) float64 {
+	switch {
+	case t < s.upSteps:
+		return cosineAnnealing(s.initLR, s.maxLR, float64(t)/float64(s.upSteps))
+	case t < s.steps:
+		return cosineAnnealing(s.maxLR, s.finalLR, float64(t-s.upSteps)/float64(s.steps-s.upSteps))
+	default:
+		return s.finalLR
+	}
+}
+
+// Warmup increases the learning rate linearly from zero to the initial value of another schedule
+// in the given number of steps, and then it follows that schedule (shifted by the warmup steps).
+type Warmup struct {
+	steps    int
+	schedule gd.LearningRateSchedule
+}
+
+// NewWarmup returns a new Warmup schedule.
+func NewWarmup(steps int, schedule gd.LearningRateSchedule) *Warmup {
+	return &Warmup{
+		steps:    steps,
+		schedule: schedule,
+	}
+}
+
+// LearningRate returns the learning rate after t steps.
+func (s *Warmup) LearningRate(t int) float64 {
+	if t < s.steps {
+		return s.schedule.LearningRate(0) * float64(t+1) / float64(s.steps)
+	}
+	return s.schedule.LearningRate(t - s.steps)
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lrschedule
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func learningRates(s gd.LearningRateSchedule, n int) []float64 {
+	lrs := make([]float64, n)
+	for t := range lrs {
+		lrs[t] = s.LearningRate(t)
+	}
+	return lrs
+}
+
+func TestConstant(t *testing.T) {
+	if !floats.Equal(learningRates(Constant(0.1), 3), []float64{0.1, 0.1, 0.1}) {
+		t.Error("The learning rates don't match the expected values")
+	}
+}
+
+func TestStep(t *testing.T) {
+	lrs := learningRates(NewStep(1.0, 2, 0.5), 6)
+	if !floats.EqualApprox(lrs, []float64{1.0, 1.0, 0.5, 0.5, 0.25, 0.25}, 1.0e-12) {
+		t.Error("The learning rates don't match the expected values")
+	}
+}
+
+func TestMultiStep(t *testing.T) {
+	lrs := learningRates(NewMultiStep(1.0, []int{4, 1}, 0.1), 6)
+	if !floats.EqualApprox(lrs, []float64{1.0, 0.1, 0.1, 0.1, 0.01, 0.01}, 1.0e-12) {
+		t.Error("The learning rates don't match the expected values")
+	}
+}
+
+func TestLinear(t *testing.T) {
+	lrs := learningRates(NewLinear(1.0, 0.0, 4), 6)
+	if !floats.EqualApprox(lrs, []float64{1.0, 0.75, 0.5, 0.25, 0.0, 0.0}, 1.0e-12) {
+		t.Error("The learning rates don't match the expected values")
+	}
+}
+
+func TestCosine(t *testing.T) {
+	lrs := learningRates(NewCosine(1.0, 0.0, 4), 6)
+	if !floats.EqualApprox(lrs, []float64{1.0, 0.853553, 0.5, 0.146447, 0.0, 0.0}, 1.0e-6) {
+		t.Error("The learning rates don't match the expected values")
+	}
+}
+
+func TestCosineWithRestarts(t *testing.T) {
+	lrs := learningRates(NewCosineWithRestarts(1.0, 0.0, 2, 2), 8)
+	if !floats.EqualApprox(lrs, []float64{
+		1.0, 0.5, // first cycle
+		1.0, 0.853553, 0.5, 0.146447, // second cycle
+		1.0, 0.961940, // third cycle
+	}, 1.0e-6) {
+		t.Error("The learning rates don't match the expected values")
+	}
+}
+
+func TestOneCycle(t *testing.T) {
+	s := NewOneCycle(1.0, 6, 0.5, 10.0, 100.0)
+	lrs := learningRates(s, 7)
+	if !floats.EqualApprox(lrs, []float64{0.1, 0.325, 0.775, 1.0, 0.75025, 0.25075, 0.001}, 1.0e-6) {
+		t.Errorf("The learning rates don't match the expected values: %v", lrs)
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	lrs := learningRates(NewWarmup(4, NewLinear(1.0, 0.0, 2)), 8)
+	if !floats.EqualApprox(lrs, []float64{0.25, 0.5, 0.75, 1.0, 1.0, 0.5, 0.0, 0.0}, 1.0e-12) {
+		t.Error("The learning rates don't match the expected values")
+	}
+}
+
+func TestGradientDescentSchedule(t *testing.T) {
+	method := sgd.New(sgd.NewConfig(1.0, 0.0, false))
+	optimizer := gd.NewOptimizer(method, nn.NewDefaultParamsIterator(), gd.ScheduleLRByBatch(NewLinear(1.0, 0.0, 4)))
+
+	if optimizer.LearningRate() != 1.0 {
+		t.Error("The initial learning rate doesn't match the expected value")
+	}
+	optimizer.IncEpoch()
+	optimizer.IncExample()
+	if optimizer.LearningRate() != 1.0 {
+		t.Error("The learning rate must change at each new batch only")
+	}
+	optimizer.IncBatch()
+	optimizer.IncBatch()
+	if optimizer.LearningRate() != 0.5 || method.Alpha != 0.5 {
+		t.Error("The learning rate doesn't match the expected value")
+	}
+}
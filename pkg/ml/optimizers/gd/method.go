@@ -22,10 +22,16 @@ const (
 	RAdam
 	// RMSProp represents the RMSProp gradient descent optimization method.
 	RMSProp
+	// AdamW represents the AdamW gradient descent optimization method.
+	AdamW
+	// LAMB represents the LAMB gradient descent optimization method.
+	LAMB
+	// Adafactor represents the Adafactor gradient descent optimization method.
+	Adafactor
 )
 
 // MethodConfig is an empty interface implemented by the configuration structures of
-// AdaGrad, Adam, RMSProp and SGD.
+// AdaGrad, Adam, AdamW, Adafactor, LAMB, RAdam, RMSProp and SGD.
 type MethodConfig interface{}
 
 // Method is implemented by any optimization method.
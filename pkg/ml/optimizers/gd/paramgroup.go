@@ -0,0 +1,174 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"regexp"
+)
+
+// ParamSelector is implemented by any value that has the Filter method, used to select the params of a group
+// (see WithParamGroup).
+type ParamSelector interface {
+	// Filter returns the params which belong to the group, among the given ones.
+	Filter(params []*nn.Param) []*nn.Param
+}
+
+// ParamSelectorFunc is a ParamSelector which selects the params satisfying the function.
+type ParamSelectorFunc func(param *nn.Param) bool
+
+// Filter returns the params which belong to the group, among the given ones.
+func (f ParamSelectorFunc) Filter(params []*nn.Param) []*nn.Param {
+	var selected []*nn.Param
+	for _, param := range params {
+		if f(param) {
+			selected = append(selected, param)
+		}
+	}
+	return selected
+}
+
+// SelectByType returns a ParamSelector which selects the params of the given types,
+// according to the `type` tags of the models.
+func SelectByType(types ...nn.ParamsType) ParamSelector {
+	return ParamSelectorFunc(func(param *nn.Param) bool {
+		for _, t := range types {
+			if param.Type() == t {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// SelectByModel returns a ParamSelector which selects the params of the given models (including sub-models).
+// The params of the models are visited again whenever the number of params to select from changes, so that
+// new ones (e.g. embeddings) are included.
+func SelectByModel(models ...nn.Model) ParamSelector {
+	return paramSetSelector(func(visit func(param *nn.Param)) {
+		for _, m := range models {
+			nn.ForEachParam(m, visit)
+		}
+	})
+}
+
+// SelectByPath returns a ParamSelector which selects the params of the model whose path matches the given
+// regular expression (see nn.ForEachParamWithPath). It panics if the expression cannot be parsed.
+//
+// For example, the following selects the biases and the normalization weights of a BERT model:
+//    SelectByPath(model, `(\.B|Norm\.W)$`)
+func SelectByPath(m nn.Model, pattern string) ParamSelector {
+	re := regexp.MustCompile(pattern)
+	return paramSetSelector(func(visit func(param *nn.Param)) {
+		nn.ForEachParamWithPath(m, func(param *nn.Param, path string) {
+			if re.MatchString(path) {
+				visit(param)
+			}
+		})
+	})
+}
+
+// paramSetSelector returns a ParamSelector which selects the params visited by the given function.
+// The visited params are cached, and visited again only when the number of params to select from changes,
+// so that the models are not walked at each optimization step.
+func paramSetSelector(forEach func(visit func(param *nn.Param))) ParamSelector {
+	var set map[*nn.Param]bool
+	numOfParams := 0
+	return selectorFunc(func(params []*nn.Param) []*nn.Param {
+		if set == nil || len(params) != numOfParams {
+			set = make(map[*nn.Param]bool)
+			forEach(func(param *nn.Param) {
+				set[param] = true
+			})
+			numOfParams = len(params)
+		}
+		return ParamSelectorFunc(func(param *nn.Param) bool {
+			return set[param]
+		}).Filter(params)
+	})
+}
+
+// SelectAll returns a ParamSelector which selects the params selected by all the given selectors.
+func SelectAll(selectors ...ParamSelector) ParamSelector {
+	return selectorFunc(func(params []*nn.Param) []*nn.Param {
+		for _, s := range selectors {
+			params = s.Filter(params)
+		}
+		return params
+	})
+}
+
+// SelectAny returns a ParamSelector which selects the params selected by at least one of the given selectors.
+func SelectAny(selectors ...ParamSelector) ParamSelector {
+	return selectorFunc(func(params []*nn.Param) []*nn.Param {
+		set := make(map[*nn.Param]bool)
+		for _, s := range selectors {
+			for _, param := range s.Filter(params) {
+				set[param] = true
+			}
+		}
+		return ParamSelectorFunc(func(param *nn.Param) bool {
+			return set[param]
+		}).Filter(params)
+	})
+}
+
+type selectorFunc func(params []*nn.Param) []*nn.Param
+
+func (f selectorFunc) Filter(params []*nn.Param) []*nn.Param {
+	return f(params)
+}
+
+// paramGroup is a group of params optimized by its own GradientDescent.
+type paramGroup struct {
+	selector  ParamSelector
+	optimizer *GradientDescent
+}
+
+// WithParamGroup is an option to optimize the params selected by the given selector with their own method,
+// e.g. to exclude some params from the weight decay, or to use discriminative learning rates.
+// The options configure the group as a separate GradientDescent (e.g. with its own learning rate schedule);
+// the gradient clipping of the optimizer applies to all the params, before the one of the group, while
+// the OnOptimize callbacks of the group are ignored.
+//
+// The groups are matched in the order they are added: a param belongs to the first group which selects it,
+// or to the default method of the optimizer if no group selects it.
+func WithParamGroup(selector ParamSelector, method Method, opts ...Option) Option {
+	return func(f *GradientDescent) {
+		f.paramGroups = append(f.paramGroups, &paramGroup{
+			selector:  selector,
+			optimizer: NewOptimizer(method, nil, opts...),
+		})
+	}
+}
+
+// splitParams assigns the params to optimize to the groups, leaving the remaining ones to the optimizer.
+func (o *GradientDescent) splitParams() {
+	remaining := o.paramsToOptimize
+	for _, group := range o.paramGroups {
+		selected := group.selector.Filter(remaining)
+		group.optimizer.paramsToOptimize = selected
+		remaining = exclude(remaining, selected)
+	}
+	o.paramsToOptimize = remaining
+}
+
+// exclude returns the params which are not in the given subset, preserving the order.
+func exclude(params, subset []*nn.Param) []*nn.Param {
+	if len(subset) == 0 {
+		return params
+	}
+	set := make(map[*nn.Param]bool, len(subset))
+	for _, param := range subset {
+		set[param] = true
+	}
+	rest := make([]*nn.Param, 0, len(params)-len(subset))
+	for _, param := range params {
+		if !set[param] {
+			rest = append(rest, param)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd_test
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lrschedule"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+type testLayer struct {
+	W *nn.Param `type:"weights"`
+	B *nn.Param `type:"biases"`
+}
+
+func (m *testLayer) NewProc(_ nn.Context) nn.Processor {
+	panic("not implemented")
+}
+
+type testModel struct {
+	Layers []nn.Model
+	Norm   *testLayer
+}
+
+func (m *testModel) NewProc(_ nn.Context) nn.Processor {
+	panic("not implemented")
+}
+
+func newTestLayer() *testLayer {
+	return &testLayer{
+		W: nn.NewParam(mat.NewVecDense([]float64{1.0, 2.0})),
+		B: nn.NewParam(mat.NewVecDense([]float64{3.0})),
+	}
+}
+
+func newTestModel() *testModel {
+	return &testModel{
+		Layers: []nn.Model{newTestLayer(), newTestLayer()},
+		Norm:   newTestLayer(),
+	}
+}
+
+func layer(m *testModel, i int) *testLayer {
+	return m.Layers[i].(*testLayer)
+}
+
+func accumulateOnes(m *testModel) {
+	nn.ForEachParam(m, func(param *nn.Param) {
+		param.PropagateGrad(param.Value().OnesLike())
+	})
+}
+
+func TestParamGroups(t *testing.T) {
+	m := newTestModel()
+	optimizer := gd.NewOptimizer(
+		sgd.New(sgd.NewConfig(1.0, 0.0, false)),
+		nn.NewDefaultParamsIterator(m),
+		gd.WithParamGroup(gd.SelectByType(nn.Biases), sgd.New(sgd.NewConfig(0.5, 0.0, false))),
+		gd.WithParamGroup(gd.SelectByModel(m.Layers[1]), sgd.New(sgd.NewConfig(0.1, 0.0, false))),
+		gd.WithParamGroup(gd.SelectByPath(m, `^Norm\.`), sgd.New(sgd.NewConfig(0.0, 0.0, false))),
+	)
+
+	accumulateOnes(m)
+	optimizer.Optimize()
+
+	// the biases are selected by the first group, even if they belong to the other groups too
+	for _, b := range []*nn.Param{layer(m, 0).B, layer(m, 1).B, m.Norm.B} {
+		if !floats.EqualApprox(b.Value().Data(), []float64{2.5}, 1.0e-12) {
+			t.Errorf("The biases don't match the expected value: %v", b.Value().Data())
+		}
+		if b.HasGrad() {
+			t.Error("The gradients must be zero after the optimization")
+		}
+	}
+	if !floats.EqualApprox(layer(m, 0).W.Value().Data(), []float64{0.0, 1.0}, 1.0e-12) {
+		t.Error("The weights of the default group don't match the expected values")
+	}
+	if !floats.EqualApprox(layer(m, 1).W.Value().Data(), []float64{0.9, 1.9}, 1.0e-12) {
+		t.Error("The weights of the model group don't match the expected values")
+	}
+	if !floats.EqualApprox(m.Norm.W.Value().Data(), []float64{1.0, 2.0}, 1.0e-12) {
+		t.Error("The weights of the path group don't match the expected values")
+	}
+}
+
+func TestParamGroupSchedule(t *testing.T) {
+	m := newTestModel()
+	groupMethod := sgd.New(sgd.NewConfig(1.0, 0.0, false))
+	optimizer := gd.NewOptimizer(
+		sgd.New(sgd.NewConfig(1.0, 0.0, false)),
+		nn.NewDefaultParamsIterator(m),
+		gd.WithParamGroup(
+			gd.SelectAll(gd.SelectByModel(m.Layers[0]), gd.SelectByType(nn.Weights)),
+			groupMethod,
+			gd.ScheduleLRByEpoch(lrschedule.NewStep(1.0, 1, 0.5)),
+		),
+	)
+
+	optimizer.IncEpoch()
+	if groupMethod.Alpha != 0.5 {
+		t.Error("The learning rate of the group doesn't follow its schedule")
+	}
+	if optimizer.LearningRate() != 1.0 {
+		t.Error("The learning rate of the default method must not change")
+	}
+
+	accumulateOnes(m)
+	optimizer.Optimize()
+
+	if !floats.EqualApprox(layer(m, 0).W.Value().Data(), []float64{0.5, 1.5}, 1.0e-12) {
+		t.Error("The weights of the group don't match the expected values")
+	}
+	if !floats.EqualApprox(layer(m, 0).B.Value().Data(), []float64{2.0}, 1.0e-12) {
+		t.Error("The biases of the default group don't match the expected values")
+	}
+}
+
+func TestSelectAny(t *testing.T) {
+	m := newTestModel()
+	params := nn.NewDefaultParamsIterator(m).ParamsList()
+	selected := gd.SelectAny(gd.SelectByModel(m.Norm), gd.SelectByPath(m, `^Layers\[1\]\.W$`)).Filter(params)
+	if len(selected) != 3 {
+		t.Fatalf("Expected 3 params, got %d", len(selected))
+	}
+	if selected[0] != layer(m, 1).W || selected[1] != m.Norm.W || selected[2] != m.Norm.B {
+		t.Error("The selected params don't match the expected ones, in the original order")
+	}
+}
+
+func TestSelectByModel_Refresh(t *testing.T) {
+	m := newTestModel()
+	selector := gd.SelectByModel(m)
+	params := nn.NewDefaultParamsIterator(m).ParamsList()
+	if selected := selector.Filter(params); len(selected) != 6 {
+		t.Fatalf("Expected 6 params, got %d", len(selected))
+	}
+
+	// the selection is cached until the number of params changes
+	m.Layers = append(m.Layers, newTestLayer())
+	extended := append(params[:len(params):len(params)], layer(m, 2).W)
+	if selected := selector.Filter(extended[1:]); len(selected) != 5 {
+		t.Errorf("Expected the cached selection of 5 params, got %d", len(selected))
+	}
+	if selected := selector.Filter(extended); len(selected) != 7 || selected[6] != layer(m, 2).W {
+		t.Errorf("Expected the new param to be selected, got %d params", len(selected))
+	}
+}
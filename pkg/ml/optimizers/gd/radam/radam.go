@@ -49,7 +49,10 @@ func NewDefaultConfig() Config {
 	}
 }
 
-var _ gd.Method = &RAdam{}
+var (
+	_ gd.Method             = &RAdam{}
+	_ gd.LearningRateSetter = &RAdam{}
+)
 
 // RAdam implements the RAdam gradient descent optimization method.
 type RAdam struct {
@@ -73,6 +76,16 @@ func (o *RAdam) Label() int {
 	return gd.RAdam
 }
 
+// LearningRate returns the current learning rate.
+func (o *RAdam) LearningRate() float64 {
+	return o.StepSize
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *RAdam) SetLearningRate(lr float64) {
+	o.StepSize = lr
+}
+
 const (
 	m    int = 0
 	v    int = 1
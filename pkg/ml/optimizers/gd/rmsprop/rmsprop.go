@@ -38,7 +38,10 @@ func NewDefaultConfig() Config {
 	}
 }
 
-var _ gd.Method = &RMSProp{}
+var (
+	_ gd.Method             = &RMSProp{}
+	_ gd.LearningRateSetter = &RMSProp{}
+)
 
 // The RMSProp method is a variant of AdaGrad where the squared sum of previous gradients is replaced with a moving average.
 // References:
@@ -58,6 +61,16 @@ func (o *RMSProp) Label() int {
 	return gd.RMSProp
 }
 
+// LearningRate returns the current learning rate.
+func (o *RMSProp) LearningRate() float64 {
+	return o.LR
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *RMSProp) SetLearningRate(lr float64) {
+	o.LR = lr
+}
+
 const v = 0
 
 // NewSupport returns a new support structure with the given dimensions.
@@ -21,3 +21,17 @@ type ExampleScheduler interface {
 	// IncExample beats the occurrence of a new example.
 	IncExample()
 }
+
+// LearningRateSetter is implemented by any method whose learning rate can be changed during the training.
+type LearningRateSetter interface {
+	// LearningRate returns the current learning rate.
+	LearningRate() float64
+	// SetLearningRate changes the learning rate.
+	SetLearningRate(lr float64)
+}
+
+// LearningRateSchedule is implemented by any value that has the LearningRate method.
+type LearningRateSchedule interface {
+	// LearningRate returns the learning rate after t steps (starting from zero).
+	LearningRate(t int) float64
+}
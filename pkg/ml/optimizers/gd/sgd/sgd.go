@@ -29,7 +29,10 @@ func NewConfig(lr, momentum float64, nesterov bool) Config {
 	}
 }
 
-var _ gd.Method = &SGD{}
+var (
+	_ gd.Method             = &SGD{}
+	_ gd.LearningRateSetter = &SGD{}
+)
 
 // SGD implements the SGD gradient descent optimization method.
 type SGD struct {
@@ -47,6 +50,17 @@ func (o *SGD) Label() int {
 	return gd.SGD
 }
 
+// LearningRate returns the current learning rate.
+func (o *SGD) LearningRate() float64 {
+	return o.LR
+}
+
+// SetLearningRate changes the learning rate, e.g. according to a schedule.
+func (o *SGD) SetLearningRate(lr float64) {
+	o.LR = lr
+	o.Alpha = lr
+}
+
 const (
 	v     int = 0
 	buf   int = 1
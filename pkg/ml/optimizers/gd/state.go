@@ -0,0 +1,280 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"io"
+)
+
+var (
+	_ utils.Serializer   = &StateSerializer{}
+	_ utils.Deserializer = &StateSerializer{}
+)
+
+// StateSerializer allows serialization and deserialization of the state of a GradientDescent optimizer,
+// so that an interrupted training can be resumed without restarting the optimization from scratch.
+//
+// The state includes the exported fields of the methods (e.g. the time step of Adam), the time step of the
+// learning rate schedules, the state of the schedules implementing json.Marshaler and json.Unmarshaler
+// (e.g. lrschedule.Plateau), and the payloads of the params of the given models (e.g. the moments of Adam),
+// which are identified by their paths (see nn.ForEachParamWithPath). The values of the params are not
+// included: they can be serialized separately with nn.ParamsSerializer.
+//
+// The gradients accumulated in an incomplete cycle (see AccumulateGrads) are not included either, so the
+// state can be serialized only at the end of a cycle, i.e. when there are no pending micro-batches.
+type StateSerializer struct {
+	optimizer *GradientDescent
+	models    []nn.Model
+	metadata  interface{}
+}
+
+// NewStateSerializer returns a new StateSerializer of the optimizer of the given models.
+// The state must be restored with the same configuration of the optimizer (methods, groups and schedules).
+func NewStateSerializer(optimizer *GradientDescent, models ...nn.Model) *StateSerializer {
+	return &StateSerializer{
+		optimizer: optimizer,
+		models:    models,
+	}
+}
+
+// WithMetadata adds to the state the JSON encoding of the given value, e.g. the progress of a trainer and the
+// state of its random generator. The value must be a pointer, which is assigned on deserialization.
+func (s *StateSerializer) WithMetadata(metadata interface{}) *StateSerializer {
+	s.metadata = metadata
+	return s
+}
+
+// stateHeader is the serializable state of the optimizer, excluding the payloads.
+type stateHeader struct {
+	Optimizers []methodState
+	Metadata   json.RawMessage `json:",omitempty"`
+}
+
+// methodState is the serializable state of a GradientDescent (excluding its groups).
+type methodState struct {
+	Label      int
+	Method     json.RawMessage
+	LRTimeStep int
+	Schedule   json.RawMessage `json:",omitempty"`
+}
+
+// optimizers returns the optimizer followed by the ones of its param groups.
+func (s *StateSerializer) optimizers() []*GradientDescent {
+	optimizers := []*GradientDescent{s.optimizer}
+	for _, group := range s.optimizer.paramGroups {
+		optimizers = append(optimizers, group.optimizer)
+	}
+	return optimizers
+}
+
+// forEachParam iterates the params of the models once, with their unique keys.
+func (s *StateSerializer) forEachParam(callback func(param *nn.Param, key string)) {
+	visited := make(map[*nn.Param]bool)
+	for i, m := range s.models {
+		prefix := ""
+		if len(s.models) > 1 {
+			prefix = fmt.Sprintf("%d:", i)
+		}
+		nn.ForEachParamWithPath(m, func(param *nn.Param, path string) {
+			if visited[param] {
+				return
+			}
+			visited[param] = true
+			callback(param, prefix+path)
+		})
+	}
+}
+
+// Serialize dumps the state of the optimizer to the writer.
+// It returns an error if the optimizer has pending micro-batches (see PendingMicroBatches).
+func (s *StateSerializer) Serialize(w io.Writer) (int, error) {
+	if n := s.optimizer.PendingMicroBatches(); n > 0 {
+		return 0, fmt.Errorf("gd: cannot serialize the state with %d pending micro-batches", n)
+	}
+	var h stateHeader
+	for _, o := range s.optimizers() {
+		state, err := o.marshalState()
+		if err != nil {
+			return 0, err
+		}
+		h.Optimizers = append(h.Optimizers, state)
+	}
+	if s.metadata != nil {
+		metadata, err := json.Marshal(s.metadata)
+		if err != nil {
+			return 0, err
+		}
+		h.Metadata = metadata
+	}
+	header, err := json.Marshal(h)
+	if err != nil {
+		return 0, err
+	}
+	n, err := writeBytes(w, header)
+	if err != nil {
+		return n, err
+	}
+
+	var keys []string
+	var payloads []*nn.Payload
+	s.forEachParam(func(param *nn.Param, key string) {
+		if payload := param.Payload(); payload != nil && payload.Label != None {
+			keys = append(keys, key)
+			payloads = append(payloads, payload)
+		}
+	})
+	n2, err := writeUint64(w, uint64(len(payloads)))
+	n += n2
+	if err != nil {
+		return n, err
+	}
+	for i, payload := range payloads {
+		n2, err = writeBytes(w, []byte(keys[i]))
+		n += n2
+		if err != nil {
+			return n, err
+		}
+		n2, err = nn.PayloadMarshalBinaryTo(payload, w)
+		n += n2
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Deserialize restores the state of the optimizer from the reader.
+func (s *StateSerializer) Deserialize(r io.Reader) (int, error) {
+	header, n, err := readBytes(r)
+	if err != nil {
+		return n, err
+	}
+	var h stateHeader
+	if err := json.Unmarshal(header, &h); err != nil {
+		return n, err
+	}
+	optimizers := s.optimizers()
+	if len(h.Optimizers) != len(optimizers) {
+		return n, fmt.Errorf("gd: expected the state of %d optimizers, found %d", len(optimizers), len(h.Optimizers))
+	}
+	for i, o := range optimizers {
+		if err := o.unmarshalState(h.Optimizers[i]); err != nil {
+			return n, err
+		}
+	}
+	if s.metadata != nil && len(h.Metadata) > 0 {
+		if err := json.Unmarshal(h.Metadata, s.metadata); err != nil {
+			return n, err
+		}
+	}
+
+	params := make(map[string]*nn.Param)
+	s.forEachParam(func(param *nn.Param, key string) {
+		params[key] = param
+	})
+	size, n2, err := readUint64(r)
+	n += n2
+	if err != nil {
+		return n, err
+	}
+	for i := uint64(0); i < size; i++ {
+		key, n2, err := readBytes(r)
+		n += n2
+		if err != nil {
+			return n, err
+		}
+		payload, n2, err := nn.NewPayloadUnmarshalBinaryFrom(r)
+		n += n2
+		if err != nil {
+			return n, err
+		}
+		param, ok := params[string(key)]
+		if !ok {
+			return n, fmt.Errorf("gd: unknown param %q", key)
+		}
+		param.SetPayload(payload)
+	}
+	return n, nil
+}
+
+func (o *GradientDescent) marshalState() (methodState, error) {
+	method, err := json.Marshal(o.method)
+	if err != nil {
+		return methodState{}, err
+	}
+	state := methodState{
+		Label:      o.method.Label(),
+		Method:     method,
+		LRTimeStep: o.lrTimeStep,
+	}
+	if schedule, ok := o.lrSchedule.(json.Marshaler); ok {
+		state.Schedule, err = schedule.MarshalJSON()
+		if err != nil {
+			return methodState{}, err
+		}
+	}
+	return state, nil
+}
+
+func (o *GradientDescent) unmarshalState(state methodState) error {
+	if state.Label != o.method.Label() {
+		return fmt.Errorf("gd: the state of method %d is not compatible with method %d", state.Label, o.method.Label())
+	}
+	if err := json.Unmarshal(state.Method, o.method); err != nil {
+		return err
+	}
+	o.lrTimeStep = state.LRTimeStep
+	if o.lrSchedule == nil {
+		return nil
+	}
+	if schedule, ok := o.lrSchedule.(json.Unmarshaler); ok && len(state.Schedule) > 0 {
+		if err := schedule.UnmarshalJSON(state.Schedule); err != nil {
+			return err
+		}
+	}
+	o.updateLearningRate()
+	return nil
+}
+
+func writeUint64(w io.Writer, x uint64) (int, error) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, x)
+	return w.Write(buf)
+}
+
+func readUint64(r io.Reader) (uint64, int, error) {
+	buf := make([]byte, 8)
+	n, err := utils.ReadFull(r, buf)
+	if err != nil {
+		return 0, n, err
+	}
+	return binary.LittleEndian.Uint64(buf), n, nil
+}
+
+// writeBytes writes the length of data followed by data.
+func writeBytes(w io.Writer, data []byte) (int, error) {
+	n, err := writeUint64(w, uint64(len(data)))
+	if err != nil {
+		return n, err
+	}
+	n2, err := w.Write(data)
+	return n + n2, err
+}
+
+// readBytes reads the data written by writeBytes.
+func readBytes(r io.Reader) ([]byte, int, error) {
+	size, n, err := readUint64(r)
+	if err != nil {
+		return nil, n, err
+	}
+	data := make([]byte, size)
+	n2, err := utils.ReadFull(r, data)
+	return data, n + n2, err
+}
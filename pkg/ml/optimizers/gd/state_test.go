@@ -0,0 +1,111 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd_test
+
+import (
+	"bytes"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lrschedule"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func newStatefulOptimizer(m *testModel, plateau *lrschedule.Plateau) *gd.GradientDescent {
+	return gd.NewOptimizer(
+		adam.New(adam.NewDefaultConfig()),
+		nn.NewDefaultParamsIterator(m),
+		gd.ScheduleLRByEpoch(plateau),
+		gd.WithParamGroup(gd.SelectByType(nn.Biases), sgd.New(sgd.NewConfig(0.1, 0.9, false)),
+			gd.ScheduleLRByBatch(lrschedule.NewLinear(0.1, 0.0, 10))),
+	)
+}
+
+func newTestPlateau() *lrschedule.Plateau {
+	config := lrschedule.NewDefaultPlateauConfig()
+	config.Patience = 0
+	return lrschedule.NewPlateau(lrschedule.Constant(0.01), config)
+}
+
+func trainStep(m *testModel, o *gd.GradientDescent, step int) {
+	nn.ForEachParam(m, func(param *nn.Param) {
+		param.PropagateGrad(param.Value().OnesLike().ProdScalar(float64(step)))
+	})
+	o.IncBatch()
+	o.IncExample()
+	o.Optimize()
+}
+
+func TestStateSerializer(t *testing.T) {
+	m := newTestModel()
+	plateau := newTestPlateau()
+	o := newStatefulOptimizer(m, plateau)
+	for step := 1; step <= 3; step++ {
+		trainStep(m, o, step)
+		plateau.Observe(1.0)
+		o.IncEpoch()
+	}
+
+	type progress struct{ Steps int }
+	buf := new(bytes.Buffer)
+	if _, err := gd.NewStateSerializer(o, m).WithMetadata(&progress{Steps: 3}).Serialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// resume from a copy of the params
+	m2 := newTestModel()
+	nn.LoadParamsVector(m2, nn.DumpParamsVector(m))
+	plateau2 := newTestPlateau()
+	o2 := newStatefulOptimizer(m2, plateau2)
+	var restored progress
+	if _, err := gd.NewStateSerializer(o2, m2).WithMetadata(&restored).Deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Steps != 3 {
+		t.Error("The metadata doesn't match the original one")
+	}
+	if plateau2.NumOfReductions() != plateau.NumOfReductions() || o2.LearningRate() != o.LearningRate() {
+		t.Error("The state of the schedule doesn't match the original one")
+	}
+
+	trainStep(m, o, 4)
+	trainStep(m2, o2, 4)
+
+	if !floats.EqualApprox(nn.DumpParamsVector(m2).Data(), nn.DumpParamsVector(m).Data(), 1.0e-12) {
+		t.Error("The resumed training doesn't match the original one")
+	}
+}
+
+func TestStateSerializerUnknownParam(t *testing.T) {
+	m := newTestModel()
+	o := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(m))
+	trainStep(m, o, 1)
+
+	buf := new(bytes.Buffer)
+	if _, err := gd.NewStateSerializer(o, m).Serialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	other := newTestLayer()
+	o2 := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(other))
+	if _, err := gd.NewStateSerializer(o2, other).Deserialize(buf); err == nil {
+		t.Error("Expected an error restoring the state of a different model")
+	}
+}
+
+func TestStateSerializerPendingMicroBatches(t *testing.T) {
+	m := newTestModel()
+	o := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(m), gd.AccumulateGrads(2))
+	trainStep(m, o, 1)
+	if _, err := gd.NewStateSerializer(o, m).Serialize(new(bytes.Buffer)); err == nil {
+		t.Error("Expected an error serializing the state in the middle of a cycle")
+	}
+	trainStep(m, o, 2)
+	if _, err := gd.NewStateSerializer(o, m).Serialize(new(bytes.Buffer)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package swa implements the Stochastic Weight Averaging (SWA), as introduced by Pavel Izmailov et al. in
+// "Averaging Weights Leads to Wider Optima and Better Generalization", 2018 (https://arxiv.org/pdf/1803.05407.pdf).
+//
+// Starting from a given optimization step, the weights of the model are averaged with equal weights
+// every given number of steps. To collect the weights during the training, pass the Update method to the optimizer:
+//    s := swa.New(model, 1000, 100)
+//    optimizer := gd.NewOptimizer(method, nn.NewDefaultParamsIterator(model), gd.OnOptimize(s.Update))
+// If the model contains batch normalization layers, their running statistics should be recomputed
+// with the averaged weights, by processing the training data in nn.Training mode.
+package swa
+
+import (
+	"sync"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/internal/shadow"
+)
+
+// SWA keeps the average of the parameters of a model along the optimization.
+type SWA struct {
+	start       int
+	frequency   int
+	steps       int
+	numAveraged int
+	averages    *shadow.Params
+	mu          sync.Mutex
+}
+
+// New returns a new SWA of the parameters of the given model, which averages the parameters
+// every frequency steps, starting from the start step (counting from 1).
+// The parameters are collected once; the parameters shared by several sub-models are averaged once.
+func New(m nn.Model, start, frequency int) *SWA {
+	if frequency < 1 {
+		panic("swa: the frequency must be positive")
+	}
+	return &SWA{
+		start:     start,
+		frequency: frequency,
+		averages:  shadow.New(m, "swa"),
+	}
+}
+
+// Update beats the occurrence of a new optimization step, and adds the current values of the parameters
+// to the average according to the schedule.
+func (s *SWA) Update() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps++
+	if s.steps < s.start || (s.steps-s.start)%s.frequency != 0 {
+		return
+	}
+	// running average: avg = avg + (x - avg) / (n + 1)
+	weight := 1.0 / float64(s.numAveraged+1)
+	s.averages.Update(func(value, avg mat.Matrix) {
+		diff := value.Sub(avg)
+		avg.AddInPlace(diff.ProdScalarInPlace(weight))
+		mat.ReleaseDense(diff.(*mat.Dense))
+	})
+	s.numAveraged++
+}
+
+// NumAveraged returns the number of times the parameters have been added to the average.
+func (s *SWA) NumAveraged() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.numAveraged
+}
+
+// Swap exchanges the values of the model parameters with the averaged ones.
+// Calling it twice restores the original values.
+func (s *SWA) Swap() {
+	s.averages.Swap()
+}
+
+// WithAveragedWeights temporarily swaps in the averaged weights while executing the callback,
+// e.g. for the evaluation of the model.
+func (s *SWA) WithAveragedWeights(callback func()) {
+	s.averages.With(callback)
+}
+
+// Averaged returns a model containing the averaged parameters, laid out like the parameters of the
+// original model, shared ones included. Saved with nn.ParamsSerializer, it can be loaded into the original model.
+func (s *SWA) Averaged() nn.Model {
+	return s.averages.Model()
+}
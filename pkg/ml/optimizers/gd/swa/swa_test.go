@@ -0,0 +1,42 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swa
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestSWA(t *testing.T) {
+	model := linear.New(1, 1)
+	s := New(model, 2, 2)
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(1.0, 0.0, false)), nn.NewDefaultParamsIterator(model),
+		gd.OnOptimize(s.Update))
+
+	// the weight decreases by one at each step: -1, -2, -3, -4, -5
+	for i := 0; i < 5; i++ {
+		model.W.PropagateGrad(mat.NewScalar(1.0))
+		optimizer.Optimize()
+	}
+
+	// averaged at the steps 2 and 4
+	if s.NumAveraged() != 2 {
+		t.Fatalf("expected 2 averaged steps, got %d", s.NumAveraged())
+	}
+	s.WithAveragedWeights(func() {
+		if !floats.EqualApprox(model.W.Value().Data(), []float64{-3.0}, 1.0e-9) {
+			t.Errorf("The averaged weight doesn't match the expected value: %v", model.W.Value().Data())
+		}
+	})
+	if !floats.EqualApprox(model.W.Value().Data(), []float64{-5.0}, 1.0e-9) {
+		t.Error("The original weights are expected to be restored")
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package lbfgs provides an implementation of the full-batch L-BFGS optimizer, with an optional line search
+satisfying the strong Wolfe conditions.
+
+It is suitable for small models, or for training a classifier on top of frozen features, where the loss of the
+whole dataset can be computed at each step. The implementation follows the one of PyTorch
+(https://pytorch.org/docs/stable/optim.html#torch.optim.LBFGS), which in turn is based on minFunc
+(https://www.cs.ubc.ca/~schmidtm/Software/minFunc.html).
+
+Reference: "Numerical Optimization" by Jorge Nocedal and Stephen J. Wright (2006), Algorithms 7.4 and 3.5.
+*/
+package lbfgs
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers"
+	"math"
+)
+
+var _ optimizers.Optimizer = &LBFGS{}
+
+// Closure defines the computation of the loss on the given graph and returns the loss node, e.g. through
+// a processor created with nn.Context{Graph: g, Mode: nn.Training}.
+// It is called each time the optimizer needs to evaluate the loss and its gradients w.r.t. the params,
+// therefore it must always compute the loss over the same (whole) dataset.
+type Closure func(g *ag.Graph) ag.Node
+
+// Config provides configuration settings for an L-BFGS optimizer.
+type Config struct {
+	// The learning rate, i.e. the initial step length (default 1)
+	LR float64
+	// The maximum number of iterations for each call to Optimize (default 20)
+	MaxIterations int
+	// The maximum number of evaluations of the loss for each call to Optimize (default MaxIterations * 5 / 4)
+	MaxEvaluations int
+	// The termination tolerance on the first order optimality (default 1e-7)
+	GradTolerance float64
+	// The termination tolerance on the changes of the loss and of the params (default 1e-9)
+	ChangeTolerance float64
+	// The number of past updates used to approximate the inverse Hessian (default 100)
+	HistorySize int
+	// Whether to determine the step length with a line search satisfying the strong Wolfe conditions
+	StrongWolfe bool
+	// The sufficient decrease constant of the strong Wolfe conditions (default 1e-4)
+	C1 float64
+	// The curvature constant of the strong Wolfe conditions (default 0.9)
+	C2 float64
+	// The maximum number of iterations of the line search (default 25)
+	MaxLineSearchIterations int
+}
+
+// NewConfig returns a new L-BFGS Config, with the remaining settings set to their defaults.
+func NewConfig(lr float64, maxIterations, historySize int, strongWolfe bool) Config {
+	return Config{
+		LR:                      lr,
+		MaxIterations:           maxIterations,
+		MaxEvaluations:          maxIterations * 5 / 4,
+		GradTolerance:           1e-7,
+		ChangeTolerance:         1e-9,
+		HistorySize:             historySize,
+		StrongWolfe:             strongWolfe,
+		C1:                      1e-4,
+		C2:                      0.9,
+		MaxLineSearchIterations: 25,
+	}
+}
+
+// NewDefaultConfig returns a new L-BFGS Config with the strong Wolfe line search and the default settings.
+func NewDefaultConfig() Config {
+	return NewConfig(1.0, 20, 100, true)
+}
+
+// LBFGS implements the limited-memory BFGS optimizer.
+type LBFGS struct {
+	Config
+	paramsIterator nn.ParamsIterator
+	closure        Closure
+	params         []*nn.Param
+	// the number of elements of all the params
+	size int
+	// the last computed loss
+	loss float64
+	// the number of iterations since the beginning (or the last reset)
+	iterations int
+	// the last search direction
+	d *mat.Dense
+	// the last step length
+	t float64
+	// the last differences of the gradients (y) and of the params (s), and their 1/(y·s)
+	ys, ss []*mat.Dense
+	ro     []float64
+	// the scaling factor of the initial approximation of the inverse Hessian
+	hDiag    float64
+	prevGrad *mat.Dense
+	prevLoss float64
+}
+
+// New returns a new L-BFGS optimizer of the params, which minimizes the loss computed by the closure.
+// The set of params must not change between the calls to Optimize, otherwise the history is reset.
+func New(config Config, paramsIterator nn.ParamsIterator, closure Closure) *LBFGS {
+	return &LBFGS{
+		Config:         config,
+		paramsIterator: paramsIterator,
+		closure:        closure,
+	}
+}
+
+// Loss returns the last loss computed during the optimization.
+func (o *LBFGS) Loss() float64 {
+	return o.loss
+}
+
+// Iterations returns the number of iterations done since the beginning of the optimization.
+func (o *LBFGS) Iterations() int {
+	return o.iterations
+}
+
+// Reset discards the history of the past updates, restarting the optimization from the steepest descent.
+func (o *LBFGS) Reset() {
+	o.iterations = 0
+	o.d = nil
+	o.ys, o.ss, o.ro = nil, nil, nil
+	o.prevGrad = nil
+}
+
+// Optimize performs up to MaxIterations iterations of L-BFGS, stopping earlier if the optimization converges.
+// After the optimization the params have zero gradients.
+func (o *LBFGS) Optimize() {
+	o.params = o.paramsIterator.ParamsList()
+	defer o.zeroGrads()
+	if size := o.numOfElements(); size != o.size {
+		o.size = size
+		o.Reset()
+	}
+	if o.size == 0 {
+		return
+	}
+
+	loss, grad := o.evaluate()
+	o.loss = loss
+	evaluations := 1
+	if grad.Abs().Max() <= o.GradTolerance {
+		return // already optimal
+	}
+
+	for n := 1; n <= o.MaxIterations; n++ {
+		o.iterations++
+		o.updateDirection(grad)
+		o.prevGrad = grad
+		o.prevLoss = loss
+
+		if o.iterations == 1 {
+			o.t = math.Min(1.0, 1.0/grad.Abs().Sum()) * o.LR
+		} else {
+			o.t = o.LR
+		}
+		gtd := grad.DotUnitary(o.d) // directional derivative
+		if gtd > -o.ChangeTolerance {
+			break // not a descent direction
+		}
+
+		optimal := false
+		if o.StrongWolfe {
+			offset := 0.0
+			phi := func(t float64) (float64, *mat.Dense) {
+				o.step(t-offset, o.d)
+				offset = t
+				return o.evaluate()
+			}
+			p, lsEvaluations := strongWolfe(phi, o.d, o.t, loss, grad, gtd, o.Config)
+			o.step(p.t-offset, o.d)
+			loss, grad, o.t = p.f, p.g, p.t
+			evaluations += lsEvaluations
+			optimal = grad.Abs().Max() <= o.GradTolerance
+		} else {
+			o.step(o.t, o.d)
+			if n != o.MaxIterations {
+				loss, grad = o.evaluate()
+				evaluations++
+				optimal = grad.Abs().Max() <= o.GradTolerance
+			}
+		}
+		o.loss = loss
+
+		if optimal || evaluations >= o.MaxEvaluations {
+			break
+		}
+		if o.d.Abs().Max()*math.Abs(o.t) <= o.ChangeTolerance || math.Abs(loss-o.prevLoss) < o.ChangeTolerance {
+			break // lack of progress
+		}
+	}
+}
+
+// updateDirection updates the history with the last curvature pair and computes the new search direction.
+func (o *LBFGS) updateDirection(grad *mat.Dense) {
+	if o.iterations == 1 {
+		o.d = grad.ProdScalar(-1.0).(*mat.Dense)
+		o.ys, o.ss, o.ro = nil, nil, nil
+		o.hDiag = 1.0
+		return
+	}
+	y := grad.Sub(o.prevGrad).(*mat.Dense)
+	s := o.d.ProdScalar(o.t).(*mat.Dense)
+	ys := y.DotUnitary(s)
+	if ys > 1e-10 { // skip the update if the curvature condition doesn't hold
+		if len(o.ys) == o.HistorySize {
+			o.ys, o.ss, o.ro = o.ys[1:], o.ss[1:], o.ro[1:]
+		}
+		o.ys = append(o.ys, y)
+		o.ss = append(o.ss, s)
+		o.ro = append(o.ro, 1.0/ys)
+		o.hDiag = ys / y.DotUnitary(y)
+	}
+	o.d = o.direction(grad)
+}
+
+// direction returns the search direction -H·grad, computing the product with the approximation of the
+// inverse Hessian H by means of the two-loop recursion.
+func (o *LBFGS) direction(grad *mat.Dense) *mat.Dense {
+	q := grad.ProdScalar(-1.0).(*mat.Dense)
+	alpha := make([]float64, len(o.ys))
+	for i := len(o.ys) - 1; i >= 0; i-- {
+		alpha[i] = o.ss[i].DotUnitary(q) * o.ro[i]
+		q.SubInPlace(o.ys[i].ProdScalar(alpha[i]))
+	}
+	r := q.ProdScalarInPlace(o.hDiag).(*mat.Dense)
+	for i := range o.ys {
+		beta := o.ys[i].DotUnitary(r) * o.ro[i]
+		r.AddInPlace(o.ss[i].ProdScalar(alpha[i] - beta))
+	}
+	return r
+}
+
+// evaluate computes the loss and returns it together with the flattened gradients of the params.
+func (o *LBFGS) evaluate() (float64, *mat.Dense) {
+	o.zeroGrads()
+	g := ag.NewGraph()
+	defer g.Clear()
+	loss := o.closure(g)
+	g.Backward(loss)
+	return loss.ScalarValue(), o.flatGrads()
+}
+
+// step moves the params by t along the direction d.
+func (o *LBFGS) step(t float64, d *mat.Dense) {
+	if t == 0.0 {
+		return
+	}
+	data := d.Data()
+	offset := 0
+	for _, param := range o.params {
+		rows, cols := param.Value().Dims()
+		size := rows * cols
+		param.ApplyDelta(mat.NewDense(rows, cols, data[offset:offset+size]).ProdScalarInPlace(-t))
+		offset += size
+	}
+}
+
+// flatGrads returns the gradients of all the params as a single vector.
+func (o *LBFGS) flatGrads() *mat.Dense {
+	grads := make([]float64, 0, o.size)
+	for _, param := range o.params {
+		if param.HasGrad() {
+			grads = append(grads, param.Grad().Data()...)
+		} else {
+			grads = append(grads, make([]float64, param.Value().Size())...)
+		}
+	}
+	return mat.NewVecDense(grads)
+}
+
+func (o *LBFGS) numOfElements() int {
+	size := 0
+	for _, param := range o.params {
+		size += param.Value().Size()
+	}
+	return size
+}
+
+func (o *LBFGS) zeroGrads() {
+	for _, param := range o.params {
+		param.ZeroGrad()
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lbfgs
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+	"math"
+	"testing"
+)
+
+type testModel struct {
+	X *nn.Param
+	Y *nn.Param
+}
+
+func (m *testModel) NewProc(_ nn.Context) nn.Processor {
+	panic("not implemented")
+}
+
+func newTestModel(x, y float64) *testModel {
+	return &testModel{
+		X: nn.NewParam(mat.NewScalar(x)),
+		Y: nn.NewParam(mat.NewScalar(y)),
+	}
+}
+
+// rosenbrock returns the closure computing the Rosenbrock function (1 - x)^2 + 100 * (y - x^2)^2,
+// which has its minimum in (1, 1).
+func rosenbrock(m *testModel) Closure {
+	return func(g *ag.Graph) ag.Node {
+		x, y := g.NewWrap(m.X), g.NewWrap(m.Y)
+		a := g.Square(g.Sub(g.NewScalar(1.0), x))
+		b := g.Square(g.Sub(y, g.Square(x)))
+		return g.Add(a, g.ProdScalar(b, g.NewScalar(100.0)))
+	}
+}
+
+func TestLBFGS_StrongWolfe(t *testing.T) {
+	m := newTestModel(-1.5, 2.0)
+	optimizer := New(NewDefaultConfig(), nn.NewDefaultParamsIterator(m), rosenbrock(m))
+
+	for i := 0; i < 5; i++ {
+		optimizer.Optimize()
+	}
+	if !floats.EqualApprox([]float64{m.X.ScalarValue(), m.Y.ScalarValue()}, []float64{1.0, 1.0}, 1.0e-4) {
+		t.Errorf("Expected the minimum in (1, 1), got (%f, %f)", m.X.ScalarValue(), m.Y.ScalarValue())
+	}
+	if optimizer.Loss() > 1.0e-8 {
+		t.Errorf("Expected a loss close to zero, got %g", optimizer.Loss())
+	}
+	if m.X.HasGrad() || m.Y.HasGrad() {
+		t.Error("The params must have zero gradients after the optimization")
+	}
+}
+
+func TestLBFGS_FixedStep(t *testing.T) {
+	m := newTestModel(0.0, 0.0)
+	// a convex quadratic: (x - 3)^2 + 2 * (y + 1)^2 + x * y
+	closure := func(g *ag.Graph) ag.Node {
+		x, y := g.NewWrap(m.X), g.NewWrap(m.Y)
+		a := g.Square(g.Sub(x, g.NewScalar(3.0)))
+		b := g.ProdScalar(g.Square(g.Add(y, g.NewScalar(1.0))), g.NewScalar(2.0))
+		return g.Add(g.Add(a, b), g.Prod(x, y))
+	}
+	config := NewConfig(1.0, 100, 10, false)
+	optimizer := New(config, nn.NewDefaultParamsIterator(m), closure)
+	optimizer.Optimize()
+
+	// the gradient (2(x - 3) + y, 4(y + 1) + x) is zero in (4, -2)
+	expected := []float64{4.0, -2.0}
+	if !floats.EqualApprox([]float64{m.X.ScalarValue(), m.Y.ScalarValue()}, expected, 1.0e-6) {
+		t.Errorf("Expected the minimum in %v, got (%f, %f)", expected, m.X.ScalarValue(), m.Y.ScalarValue())
+	}
+	if optimizer.Iterations() >= config.MaxIterations {
+		t.Errorf("Expected the optimization to converge before the maximum number of iterations")
+	}
+}
+
+func TestLBFGS_HistorySize(t *testing.T) {
+	m := newTestModel(-1.5, 2.0)
+	config := NewDefaultConfig()
+	config.HistorySize = 2
+	optimizer := New(config, nn.NewDefaultParamsIterator(m), rosenbrock(m))
+	optimizer.Optimize()
+
+	if len(optimizer.ys) > 2 || len(optimizer.ss) != len(optimizer.ys) || len(optimizer.ro) != len(optimizer.ys) {
+		t.Errorf("Expected at most 2 curvature pairs in the history, got %d", len(optimizer.ys))
+	}
+	optimizer.Reset()
+	if optimizer.Iterations() != 0 || len(optimizer.ys) != 0 {
+		t.Error("Expected an empty history after the reset")
+	}
+}
+
+func TestStrongWolfe(t *testing.T) {
+	// phi(t) = (t - 2)^4 along the direction d = 1, starting from 0
+	d := mat.NewVecDense([]float64{1.0})
+	phi := func(t float64) (float64, *mat.Dense) {
+		return math.Pow(t-2, 4), mat.NewVecDense([]float64{4 * math.Pow(t-2, 3)})
+	}
+	f, g := phi(0.0)
+	gtd := g.DotUnitary(d)
+
+	for _, t0 := range []float64{0.01, 1.0, 10.0} {
+		p, evaluations := strongWolfe(phi, d, t0, f, g, gtd, NewDefaultConfig())
+		if p.f > f+1e-4*p.t*gtd {
+			t.Errorf("The sufficient decrease condition doesn't hold for the step %f (initial step %f)", p.t, t0)
+		}
+		if math.Abs(p.gtd) > -0.9*gtd {
+			t.Errorf("The curvature condition doesn't hold for the step %f (initial step %f)", p.t, t0)
+		}
+		if evaluations < 1 || evaluations > 25 {
+			t.Errorf("Unexpected number of evaluations %d", evaluations)
+		}
+	}
+}
+
+func TestCubicInterpolate(t *testing.T) {
+	// f(t) = (t - 1)^2 is interpolated exactly, with the minimum in 1
+	p1 := linePoint{t: 0.0, f: 1.0, gtd: -2.0}
+	p2 := linePoint{t: 3.0, f: 4.0, gtd: 4.0}
+	if got := cubicInterpolate(p1, p2, 0.0, 3.0); math.Abs(got-1.0) > 1.0e-12 {
+		t.Errorf("Expected 1, got %f", got)
+	}
+	if got := cubicInterpolate(p2, p1, 2.0, 3.0); got != 2.0 {
+		t.Errorf("Expected the minimizer to be clamped to 2, got %f", got)
+	}
+}
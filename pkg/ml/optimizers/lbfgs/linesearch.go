@@ -0,0 +1,147 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lbfgs
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"math"
+)
+
+// lineFunc evaluates the loss and its gradients at the step t along the search direction.
+type lineFunc func(t float64) (f float64, g *mat.Dense)
+
+// linePoint is a point evaluated during the line search.
+type linePoint struct {
+	// the step length
+	t float64
+	// the loss
+	f float64
+	// the gradients
+	g *mat.Dense
+	// the directional derivative
+	gtd float64
+}
+
+// strongWolfe returns a step length along the direction d which satisfies the strong Wolfe conditions,
+// starting from the step t, together with the number of evaluations of phi.
+// The loss f, the gradients g and the directional derivative gtd are the ones at the step 0.
+//
+// Reference: "Numerical Optimization" by Jorge Nocedal and Stephen J. Wright (2006), Algorithms 3.5 and 3.6.
+func strongWolfe(phi lineFunc, d *mat.Dense, t, f float64, g *mat.Dense, gtd float64, c Config) (linePoint, int) {
+	dNorm := d.Abs().Max()
+	init := linePoint{t: 0.0, f: f, g: g, gtd: gtd}
+	evaluate := func(t float64) linePoint {
+		fNew, gNew := phi(t)
+		return linePoint{t: t, f: fNew, g: gNew, gtd: gNew.DotUnitary(d)}
+	}
+	// sufficientDecrease reports whether the point satisfies the Armijo condition.
+	sufficientDecrease := func(p linePoint) bool {
+		return p.f <= f+c.C1*p.t*gtd
+	}
+	// curvature reports whether the point satisfies the strong curvature condition.
+	curvature := func(p linePoint) bool {
+		return math.Abs(p.gtd) <= -c.C2*gtd
+	}
+
+	// bracketing phase
+	prev, cur := init, evaluate(t)
+	evaluations := 1
+	var bracket [2]linePoint
+	done := false
+	iter := 0
+	for ; iter < c.MaxLineSearchIterations; iter++ {
+		if !sufficientDecrease(cur) || (iter > 1 && cur.f >= prev.f) {
+			bracket = [2]linePoint{prev, cur}
+			break
+		}
+		if curvature(cur) {
+			bracket = [2]linePoint{cur, cur}
+			done = true
+			break
+		}
+		if cur.gtd >= 0 {
+			bracket = [2]linePoint{prev, cur}
+			break
+		}
+		// extrapolation
+		minStep := cur.t + 0.01*(cur.t-prev.t)
+		maxStep := cur.t * 10
+		next := cubicInterpolate(prev, cur, minStep, maxStep)
+		prev, cur = cur, evaluate(next)
+		evaluations++
+	}
+	if iter == c.MaxLineSearchIterations {
+		bracket = [2]linePoint{init, cur}
+	}
+
+	// zoom phase
+	insufficientProgress := false
+	low, high := sortBracket(bracket)
+	for !done && iter < c.MaxLineSearchIterations {
+		if math.Abs(bracket[1].t-bracket[0].t)*dNorm < c.ChangeTolerance {
+			break // the bracket is too small
+		}
+		lo, hi := math.Min(bracket[0].t, bracket[1].t), math.Max(bracket[0].t, bracket[1].t)
+		t := cubicInterpolate(bracket[0], bracket[1], lo, hi)
+		// if the point is too close to the boundary, move it towards the interior
+		eps := 0.1 * (hi - lo)
+		if math.Min(hi-t, t-lo) < eps {
+			if insufficientProgress || t >= hi || t <= lo {
+				if math.Abs(t-hi) < math.Abs(t-lo) {
+					t = hi - eps
+				} else {
+					t = lo + eps
+				}
+				insufficientProgress = false
+			} else {
+				insufficientProgress = true
+			}
+		} else {
+			insufficientProgress = false
+		}
+
+		p := evaluate(t)
+		evaluations++
+		iter++
+		if !sufficientDecrease(p) || p.f >= bracket[low].f {
+			bracket[high] = p
+			low, high = sortBracket(bracket)
+		} else {
+			if curvature(p) {
+				done = true
+			} else if p.gtd*(bracket[high].t-bracket[low].t) >= 0 {
+				bracket[high] = bracket[low]
+			}
+			bracket[low] = p
+		}
+	}
+	return bracket[low], evaluations
+}
+
+// sortBracket returns the indices of the points of the bracket with the lowest and highest loss.
+func sortBracket(bracket [2]linePoint) (low, high int) {
+	if bracket[0].f <= bracket[1].f {
+		return 0, 1
+	}
+	return 1, 0
+}
+
+// cubicInterpolate returns the minimizer, within the bounds, of the cubic interpolating the loss and the
+// directional derivative of the two points. If the cubic has no minimizer, it returns the middle of the bounds.
+func cubicInterpolate(p1, p2 linePoint, lo, hi float64) float64 {
+	d1 := p1.gtd + p2.gtd - 3*(p1.f-p2.f)/(p1.t-p2.t)
+	d2Square := d1*d1 - p1.gtd*p2.gtd
+	if d2Square < 0 {
+		return (lo + hi) / 2
+	}
+	d2 := math.Sqrt(d2Square)
+	var minPos float64
+	if p1.t <= p2.t {
+		minPos = p2.t - (p2.t-p1.t)*((p2.gtd+d2-d1)/(p2.gtd-p1.gtd+2*d2))
+	} else {
+		minPos = p1.t - (p1.t-p2.t)*((p1.gtd+d2-d1)/(p1.gtd-p2.gtd+2*d2))
+	}
+	return math.Min(math.Max(minPos, lo), hi)
+}
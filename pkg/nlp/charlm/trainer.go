@@ -27,6 +27,9 @@ type TrainingConfig struct {
 	SerializationInterval int
 	UpdateMethod          gd.MethodConfig
 	ModelPath             string
+	// CheckpointPath is the file where the state of the training (optimizer, random generator and progress)
+	// is saved together with the model, so that it can be resumed (see Resume). If empty, it is not saved.
+	CheckpointPath string
 }
 
 // Trainer implements the training process for a Character-level Language Model.
@@ -39,26 +42,37 @@ type Trainer struct {
 	bestLoss      float64
 	lastBatchLoss float64
 	curPerplexity float64
+	countLine     int
+	checkpoint    *gd.Checkpoint
 }
 
 // NewTrainer returns a new Trainer.
 func NewTrainer(config TrainingConfig, corpus corpora.TextCorpusIterator, model *Model) *Trainer {
+	randGen := rand.NewLockedRand(config.Seed)
+	optimizer := gd.NewOptimizer(
+		gdmbuilder.NewMethod(config.UpdateMethod),
+		nn.NewDefaultParamsIterator(model),
+		gd.ClipGradByNorm(config.GradientClipping, 2.0))
 	return &Trainer{
 		TrainingConfig: config,
-		randGen:        rand.NewLockedRand(config.Seed),
+		randGen:        randGen,
 		corpus:         corpus,
 		model:          model,
-		optimizer: gd.NewOptimizer(
-			gdmbuilder.NewMethod(config.UpdateMethod),
-			nn.NewDefaultParamsIterator(model),
-			gd.ClipGradByNorm(config.GradientClipping, 2.0)),
+		optimizer:      optimizer,
+		checkpoint:     gd.NewCheckpoint(config.CheckpointPath, optimizer, randGen, model),
 	}
 }
 
 // Train executes the training process.
+// If the training has been resumed, the lines already processed are skipped.
 func (t *Trainer) Train() {
+	start := t.countLine
 	t.corpus.ForEachLine(func(i int, line string) {
+		if i <= start {
+			return
+		}
 		t.trainPassage(i, line)
+		t.countLine++
 		// TODO: save the model only if it is better against a validation criterion (yet to be defined)
 		if i > 0 && i%t.SerializationInterval == 0 {
 			fmt.Println("=== MODEL SERIALIZATION")
@@ -66,10 +80,23 @@ func (t *Trainer) Train() {
 			if err != nil {
 				panic("charlm: error during model serialization.")
 			}
+			if err := t.checkpoint.Save(t.countLine); err != nil {
+				panic(fmt.Sprintf("charlm: error during checkpoint serialization (%s)", err.Error()))
+			}
 		}
 	})
 }
 
+// Resume restores the model from ModelPath and the state of the training from CheckpointPath, so that the
+// next call to Train continues from the first line not yet processed.
+func (t *Trainer) Resume() error {
+	err := utils.DeserializeFromFile(t.ModelPath, nn.NewParamsSerializer(t.model))
+	if err != nil {
+		return err
+	}
+	return t.checkpoint.Load(&t.countLine)
+}
+
 func (t *Trainer) trainPassage(index int, text string) {
 	// This is a particular case where computing the forward after the graph definition can be more efficient.
 	g := ag.NewGraph(
@@ -0,0 +1,110 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package charlm
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"gonum.org/v1/gonum/floats"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testCorpus = []string{
+	"the cat sleeps",
+	"the dog runs",
+	"a cat runs",
+	"a dog sleeps",
+	"the cat runs",
+	"the dog sleeps",
+}
+
+// testCorpusIterator iterates the lines of an in-memory corpus.
+type testCorpusIterator []string
+
+func (c testCorpusIterator) ForEachLine(callback func(i int, line string)) {
+	for i, line := range c {
+		callback(i+1, line)
+	}
+}
+
+func newTestModel() *Model {
+	chars := []string{DefaultSequenceSeparator, DefaultUnknownToken}
+	seen := make(map[string]bool)
+	for _, c := range strings.Split(strings.Join(testCorpus, ""), "") {
+		if !seen[c] {
+			seen[c] = true
+			chars = append(chars, c)
+		}
+	}
+	model := New(Config{
+		VocabularySize: len(chars),
+		EmbeddingSize:  4,
+		HiddenSize:     6,
+	})
+	model.Vocabulary = vocabulary.New(chars)
+	Initialize(model, rand.NewLockedRand(42))
+	return model
+}
+
+func newTestTrainingConfig(dir string) TrainingConfig {
+	return TrainingConfig{
+		Seed:                  7,
+		BatchSize:             4,
+		BackStep:              10,
+		GradientClipping:      1.0,
+		SerializationInterval: 3,
+		UpdateMethod:          adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8),
+		ModelPath:             filepath.Join(dir, "model.bin"),
+		CheckpointPath:        filepath.Join(dir, "checkpoint.bin"),
+	}
+}
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "charlm_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestTrainer_Resume(t *testing.T) {
+	const checkpointLine = 3
+
+	// uninterrupted training
+	expectedModel := newTestModel()
+	expected := NewTrainer(newTestTrainingConfig(newTestDir(t)), testCorpusIterator(testCorpus), expectedModel)
+	expected.Train()
+
+	// training interrupted after the checkpoint
+	dir := newTestDir(t)
+	interrupted := NewTrainer(newTestTrainingConfig(dir), testCorpusIterator(testCorpus[:checkpointLine+1]),
+		newTestModel())
+	interrupted.Train()
+
+	resumedModel := New(expectedModel.Config)
+	resumedModel.Vocabulary = expectedModel.Vocabulary
+	resumed := NewTrainer(newTestTrainingConfig(dir), testCorpusIterator(testCorpus), resumedModel)
+	if err := resumed.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.countLine != checkpointLine {
+		t.Fatalf("Expected to resume after %d lines, got %d", checkpointLine, resumed.countLine)
+	}
+	resumed.Train()
+	if resumed.countLine != len(testCorpus) {
+		t.Fatalf("Expected %d trained lines, got %d", len(testCorpus), resumed.countLine)
+	}
+
+	if !floats.EqualApprox(nn.DumpParamsVector(resumedModel).Data(), nn.DumpParamsVector(expectedModel).Data(), 1.0e-9) {
+		t.Error("The resumed training differs from the uninterrupted one")
+	}
+}
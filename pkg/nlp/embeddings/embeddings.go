@@ -29,6 +29,9 @@ type Model struct {
 	mu             sync.Mutex
 	UsedEmbeddings map[string]*nn.Param `type:"weights"`
 	ZeroEmbedding  *nn.Param            `type:"weights"`
+	// version is incremented whenever the storage is modified, to invalidate the copies of the values
+	// of the embeddings (see OutputProjection).
+	version uint64
 }
 
 // Config provides configuration settings for an embeddings Model.
@@ -49,14 +52,17 @@ type Config struct {
 // New returns a new embedding model.
 func New(config Config) *Model {
 	m := &Model{
-		Config: config,
-		storage: kvdb.NewDefaultKeyValueDB(kvdb.Config{
+		Config:         config,
+		UsedEmbeddings: map[string]*nn.Param{},
+		ZeroEmbedding:  nn.NewParam(mat.NewEmptyVecDense(config.Size)),
+	}
+	m.storage = versionedStorage{
+		KeyValueDB: kvdb.NewDefaultKeyValueDB(kvdb.Config{
 			Path:     config.DBPath,
 			ReadOnly: config.ReadOnly,
 			ForceNew: config.ForceNewDB,
 		}),
-		UsedEmbeddings: map[string]*nn.Param{},
-		ZeroEmbedding:  nn.NewParam(mat.NewEmptyVecDense(config.Size)),
+		model: m,
 	}
 	nn.RequiresGrad(false)(m.ZeroEmbedding)
 	allModels = append(allModels, m)
@@ -133,6 +139,39 @@ func (m *Model) SetEmbedding(word string, value *mat.Dense) {
 	}
 }
 
+// versionedStorage is the storage of the embeddings of a Model, which records any modification, e.g. the
+// update of an embedding by an optimizer.
+type versionedStorage struct {
+	kvdb.KeyValueDB
+	model *Model
+}
+
+// Put stores the value of the key, invalidating the copies of the embeddings.
+func (s versionedStorage) Put(key []byte, value []byte) error {
+	defer s.model.invalidate()
+	return s.KeyValueDB.Put(key, value)
+}
+
+// DropAll drops all the data, invalidating the copies of the embeddings.
+func (s versionedStorage) DropAll() error {
+	defer s.model.invalidate()
+	return s.KeyValueDB.DropAll()
+}
+
+// invalidate records that the embeddings may have changed.
+func (m *Model) invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.version++
+}
+
+// currentVersion returns the number of times the embeddings may have changed.
+func (m *Model) currentVersion() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.version
+}
+
 // SetEmbeddingFromData inserts a new word embeddings.
 // If the word is already on the map, overwrites the existing value with the new one.
 func (m *Model) SetEmbeddingFromData(word string, data []float64) {
@@ -169,27 +208,51 @@ func (m *Model) getEmbedding(word string) *nn.Param {
 	if embedding, ok := m.getUsedEmbedding(word); ok {
 		return embedding
 	}
+	embedding := m.loadEmbedding(word)
+	if embedding == nil {
+		return nil // embedding not found
+	}
+	if m.ReadOnly {
+		nn.RequiresGrad(false)(embedding)
+	}
+	embedding.SetName(word)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.UsedEmbeddings[word] = embedding // important
+	return embedding
+}
+
+// loadEmbedding reads the parameter (the word embedding) associated with the given word from the storage,
+// without caching it. It returns nil if the word is not found.
+func (m *Model) loadEmbedding(word string) *nn.Param {
 	data, ok, err := m.storage.Get([]byte(word))
 	if err != nil {
 		log.Fatal(err)
 	}
 	if !ok {
-		return nil // embedding not found
+		return nil
 	}
 	embedding := nn.NewParam(nil, nn.SetStorage(m.storage))
 	if _, err := (&nn.ParamSerializer{Param: embedding}).Deserialize(bytes.NewReader(data)); err != nil {
 		log.Fatal(err)
 	}
-	if m.ReadOnly {
-		nn.RequiresGrad(false)(embedding)
-	}
-	embedding.SetName(word)
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.UsedEmbeddings[word] = embedding // important
 	return embedding
 }
 
+// embeddingValue returns the value of the embedding associated with the given word, like GetEmbedding,
+// but without caching it in m.UsedEmbeddings. If no embedding is found, nil is returned.
+func (m *Model) embeddingValue(word string) mat.Matrix {
+	for _, w := range []string{word, strings.ToLower(word)} {
+		if embedding, ok := m.getUsedEmbedding(w); ok {
+			return embedding.Value()
+		}
+		if embedding := m.loadEmbedding(w); embedding != nil {
+			return embedding.Value()
+		}
+	}
+	return nil
+}
+
 func (m *Model) getUsedEmbedding(word string) (embedding *nn.Param, ok bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -0,0 +1,123 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embeddings
+
+import (
+	"sync"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.Model     = &OutputProjection{}
+	_ nn.Processor = &OutputProjectionProcessor{}
+)
+
+// OutputProjection is a linear projection whose weights are tied to the word embeddings of a Model:
+//    y = E x + b
+// where the i-th row of E is the embedding of the i-th word.
+//
+// The embeddings are not parameters of the projection, but they still belong to the embeddings Model only.
+// In this way, the gradients coming from the projection accumulate on the same parameters used to encode
+// the words, which are stored and optimized once.
+//
+// In training mode, each processor stacks the embeddings of all the words, which are all involved in the
+// gradients. Otherwise, the stacked values are cached by the projection and shared among the processors,
+// without keeping the embeddings in use, until the storage of the embeddings is modified (e.g. by an update).
+type OutputProjection struct {
+	embeddings *Model
+	words      []string
+	B          *nn.Param `type:"biases"`
+	mu         sync.Mutex
+	// weights is the cached matrix of the values of the embeddings, stacked at the given version.
+	weights *mat.Dense
+	version uint64
+}
+
+// NewOutputProjection returns a new OutputProjection over the embeddings of the given words.
+// The size of the output is equal to the number of words. The biases are initialized to zeros.
+func NewOutputProjection(embeddings *Model, words []string) *OutputProjection {
+	return &OutputProjection{
+		embeddings: embeddings,
+		words:      words,
+		B:          nn.NewParam(mat.NewEmptyVecDense(len(words))),
+	}
+}
+
+// OutputProjectionProcessor implements the nn.Processor interface for an OutputProjection.
+type OutputProjectionProcessor struct {
+	nn.BaseProcessor
+	b    ag.Node
+	once sync.Once
+	w    ag.Node
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *OutputProjection) NewProc(ctx nn.Context) nn.Processor {
+	return &OutputProjectionProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: false,
+		},
+		b: ctx.Graph.NewWrap(m.B),
+	}
+}
+
+// weights returns the matrix of the word embeddings, which is built once for each processor.
+// The words without embedding are represented by zeros.
+func (p *OutputProjectionProcessor) weights() ag.Node {
+	p.once.Do(func() {
+		m := p.Model.(*OutputProjection)
+		if p.Mode != nn.Training {
+			p.w = p.Graph.NewVariable(m.cachedWeights(), false)
+			return
+		}
+		rows := make([]ag.Node, len(m.words))
+		for i, word := range m.words {
+			if param := m.embeddings.GetEmbedding(word); param != nil {
+				rows[i] = p.Graph.NewWrap(param)
+			} else {
+				rows[i] = p.Graph.NewVariable(mat.NewEmptyVecDense(m.embeddings.Size), false)
+			}
+		}
+		p.w = p.Graph.Stack(rows...)
+	})
+	return p.w
+}
+
+// cachedWeights returns the values of the word embeddings stacked in a matrix, which is built again only if
+// the embeddings may have changed since the last call. The words without embedding are represented by zeros.
+func (m *OutputProjection) cachedWeights() *mat.Dense {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	version := m.embeddings.currentVersion()
+	if m.weights != nil && m.version == version {
+		return m.weights
+	}
+	weights := mat.NewEmptyDense(len(m.words), m.embeddings.Size)
+	for i, word := range m.words {
+		if value := m.embeddings.embeddingValue(word); value != nil {
+			for j, v := range value.Data() {
+				weights.Set(i, j, v)
+			}
+		}
+	}
+	m.weights, m.version = weights, version
+	return weights
+}
+
+// Forward performs the forward step for each input and returns the result.
+func (p *OutputProjectionProcessor) Forward(xs ...ag.Node) []ag.Node {
+	w := p.weights()
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = p.Graph.Add(p.Graph.Mul(w, x), p.b)
+	}
+	return ys
+}
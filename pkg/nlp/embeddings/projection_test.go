@@ -0,0 +1,102 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embeddings
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestOutputProjection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "embeddings_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	model := New(Config{Size: 2, DBPath: dir, ForceNewDB: true})
+	defer model.Close()
+	model.SetEmbeddingFromData("a", []float64{1.0, 2.0})
+	model.SetEmbeddingFromData("b", []float64{0.0, 1.0})
+
+	projection := NewOutputProjection(model, []string{"a", "b", "c"})
+	projection.B.Value().SetData([]float64{0.1, 0.2, 0.3})
+
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+	x := model.NewProc(ctx).(*Processor).Encode([]string{"a"})[0]
+	y := projection.NewProc(ctx).Forward(x)[0]
+
+	// the "c" word has no embedding
+	if !floats.EqualApprox(y.Value().Data(), []float64{5.1, 2.2, 0.3}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+
+	g.Backward(y, ag.OutputGrad(mat.NewVecDense([]float64{1.0, 0.0, 0.0})))
+
+	// both the encoding and the projection contribute to the gradients of "a": 2 * a
+	if !floats.EqualApprox(model.GetEmbedding("a").Grad().Data(), []float64{2.0, 4.0}, 1.0e-6) {
+		t.Error("The gradients of the tied embedding don't match the expected values")
+	}
+	if !floats.EqualApprox(projection.B.Grad().Data(), []float64{1.0, 0.0, 0.0}, 1.0e-6) {
+		t.Error("The gradients of the biases don't match the expected values")
+	}
+
+	count := 0
+	nn.ForEachParam(projection, func(_ *nn.Param) { count++ })
+	if count != 1 {
+		t.Errorf("The projection is expected to own the biases only, found %d params", count)
+	}
+}
+
+func TestOutputProjection_Inference(t *testing.T) {
+	dir, err := ioutil.TempDir("", "embeddings_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	model := New(Config{Size: 2, DBPath: dir, ForceNewDB: true})
+	defer model.Close()
+	model.SetEmbeddingFromData("a", []float64{1.0, 2.0})
+	model.SetEmbeddingFromData("b", []float64{0.0, 1.0})
+	projection := NewOutputProjection(model, []string{"a", "b", "c"})
+
+	forward := func() []float64 {
+		g := ag.NewGraph()
+		defer g.Clear()
+		x := g.NewVariable(mat.NewVecDense([]float64{1.0, 1.0}), false)
+		y := projection.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).Forward(x)[0]
+		return append([]float64(nil), y.Value().Data()...)
+	}
+
+	if !floats.EqualApprox(forward(), []float64{3.0, 1.0, 0.0}, 1.0e-6) {
+		t.Error("The output doesn't match the expected values")
+	}
+	if len(model.UsedEmbeddings) != 0 {
+		t.Errorf("Expected no used embeddings in inference mode, found %d", len(model.UsedEmbeddings))
+	}
+	weights := projection.cachedWeights()
+	forward()
+	if projection.cachedWeights() != weights {
+		t.Error("Expected the weights to be cached among the processors")
+	}
+
+	// the update of an embedding invalidates the cache
+	model.GetEmbedding("b").ApplyDelta(mat.NewVecDense([]float64{-1.0, 0.0}))
+	if !floats.EqualApprox(forward(), []float64{3.0, 2.0, 0.0}, 1.0e-6) {
+		t.Error("The output doesn't reflect the updated embedding")
+	}
+	model.SetEmbeddingFromData("c", []float64{1.0, 1.0})
+	if !floats.EqualApprox(forward(), []float64{3.0, 2.0, 2.0}, 1.0e-6) {
+		t.Error("The output doesn't reflect the new embedding")
+	}
+}
@@ -6,157 +6,25 @@ package bartdecoder
 
 import (
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/multiheadattention"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
-	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartencoder"
-)
-
-var (
-	_ nn.Model     = &bartencoder.Layer{}
-	_ nn.Processor = &bartencoder.LayerProcessor{}
 )
 
 // Layer implements a BART decoder layer.
-type Layer struct {
-	Config                    bartconfig.Config
-	SelfAttention             *multiheadattention.Model
-	SelfAttentionLayerNorm    *layernorm.Model
-	EncoderAttention          *multiheadattention.Model
-	EncoderAttentionLayerNorm *layernorm.Model
-	FFN                       *stack.Model // FC2(Activation(FC1))
-	LayerNorm                 *layernorm.Model
-}
-
-// NewLayer returns a new BART decoder Layer.
-func NewLayer(config bartconfig.Config) *Layer {
-	return &Layer{
-		Config: config,
-		SelfAttention: multiheadattention.New(
-			config.DModel,
-			config.DecoderAttentionHeads,
-			true, // use causal mask
-			// TODO: config.AttentionDropout
-		),
-		SelfAttentionLayerNorm: layernorm.New(config.DModel),
-		EncoderAttention: multiheadattention.New(
-			config.DModel,
-			config.DecoderAttentionHeads,
-			false, // don't use causal mask
-			// TODO: config.AttentionDropout, encoder_decoder_attention=True
-		),
-		EncoderAttentionLayerNorm: layernorm.New(config.DModel),
-		FFN: stack.New(
-			linear.New(config.DModel, config.DecoderFFNDim),
-			activation.New(ag.OpGELU), // TODO: config.ActivationFunction
-			// dropout.New(config.ActivationDropout)
-			linear.New(config.DecoderFFNDim, config.DModel),
-			// dropout.New(config.Dropout)
-		),
-		LayerNorm: layernorm.New(config.DModel),
-	}
-}
+type Layer = transformer.DecoderLayer
 
 // LayerProcessor implements the nn.Processor interface for a BART decoder Layer.
-type LayerProcessor struct {
-	nn.BaseProcessor
-	bartconfig.Config
-	SelfAttention             *multiheadattention.Processor
-	SelfAttentionLayerNorm    *layernorm.Processor
-	EncoderAttention          *multiheadattention.Processor
-	EncoderAttentionLayerNorm *layernorm.Processor
-	FFN                       *stack.Processor
-	LayerNorm                 *layernorm.Processor
-}
-
-// NewProc returns a new processor to execute the forward step.
-func (m *Layer) NewProc(ctx nn.Context) nn.Processor {
-	return &LayerProcessor{
-		BaseProcessor: nn.BaseProcessor{
-			Model:             m,
-			Mode:              ctx.Mode,
-			Graph:             ctx.Graph,
-			FullSeqProcessing: true,
-		},
-		SelfAttention:             m.SelfAttention.NewProc(ctx).(*multiheadattention.Processor),
-		SelfAttentionLayerNorm:    m.SelfAttentionLayerNorm.NewProc(ctx).(*layernorm.Processor),
-		EncoderAttention:          m.EncoderAttention.NewProc(ctx).(*multiheadattention.Processor),
-		EncoderAttentionLayerNorm: m.EncoderAttentionLayerNorm.NewProc(ctx).(*layernorm.Processor),
-		FFN:                       m.FFN.NewProc(ctx).(*stack.Processor),
-		LayerNorm:                 m.LayerNorm.NewProc(ctx).(*layernorm.Processor),
-	}
-}
-
-// Process performs the forward step for each input and returns the result.
-func (p *LayerProcessor) Process(xs []ag.Node, encoderHiddenStates []ag.Node) []ag.Node {
-	selfAtt := p.selfAttentionBlock(xs)
-	crossAtt := p.crossAttentionBlock(selfAtt, encoderHiddenStates)
-	out := p.fullyConnectedBlock(crossAtt)
-	return out
-}
+type LayerProcessor = transformer.DecoderLayerProcessor
 
-func (p *LayerProcessor) selfAttentionBlock(xs []ag.Node) []ag.Node {
-	residual := p.copy(xs)
-	if p.NormalizeBefore {
-		xs = p.SelfAttentionLayerNorm.Forward(xs...)
-	}
-	xs = p.SelfAttention.Forward(xs...) // query=xs, key=xs, value=xs
-	// xs = p.Dropout(xs)
-	xs = p.add(residual, xs)
-	if !p.NormalizeBefore {
-		xs = p.SelfAttentionLayerNorm.Forward(xs...)
-	}
-	return xs
-}
-
-func (p *LayerProcessor) crossAttentionBlock(xs []ag.Node, ex []ag.Node) []ag.Node {
-	residual := p.copy(xs)
-	if p.NormalizeBefore {
-		xs = p.EncoderAttentionLayerNorm.Forward(xs...)
-	}
-	xs = p.EncoderAttention.ForwardQKV(xs, ex, ex) // query=xs, key=ex, value=ex
-	// xs = p.Dropout(xs)
-	xs = p.add(residual, xs)
-	if !p.NormalizeBefore {
-		xs = p.EncoderAttentionLayerNorm.Forward(xs...)
-	}
-	return xs
-}
-
-func (p *LayerProcessor) fullyConnectedBlock(xs []ag.Node) []ag.Node {
-	residual := p.copy(xs)
-	if p.NormalizeBefore {
-		xs = p.LayerNorm.Forward(xs...)
-	}
-	xs = p.FFN.Forward(xs...)
-	xs = p.add(residual, xs)
-	if !p.NormalizeBefore {
-		xs = p.LayerNorm.Forward(xs...)
-	}
-	return xs
-}
-
-func (p *LayerProcessor) copy(xs []ag.Node) []ag.Node {
-	copied := func(x ag.Node) ag.Node {
-		return p.Graph.Identity(x)
-	}
-	return ag.Map(copied, xs)
-}
-
-func (p *LayerProcessor) add(a []ag.Node, b []ag.Node) []ag.Node {
-	c := make([]ag.Node, len(a))
-	for i := 0; i < len(a); i++ {
-		c[i] = p.Graph.Add(a[i], b[i])
-	}
-	return c
-}
-
-// Forward is not implemented for BART decoder LayerProcessor (it always panics).
-// You should use Process instead.
-func (p *LayerProcessor) Forward(_ ...ag.Node) []ag.Node {
-	panic("bertdecoder: Forward() not implemented; use Process() instead.")
+// NewLayer returns a new BART decoder Layer.
+// The layer normalization is applied before each sub-layer if config.NormalizeBefore is true (e.g. mBART),
+// after each residual connection otherwise.
+func NewLayer(config bartconfig.Config) *Layer {
+	return transformer.NewDecoderLayer(transformer.Config{
+		Size:                   config.DModel,
+		NumOfAttentionHeads:    config.DecoderAttentionHeads, // TODO: config.AttentionDropout
+		IntermediateSize:       config.DecoderFFNDim,
+		IntermediateActivation: ag.OpGELU, // TODO: config.ActivationFunction
+		NormalizeBefore:        config.NormalizeBefore,
+	})
 }
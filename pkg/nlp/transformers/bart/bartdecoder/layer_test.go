@@ -0,0 +1,24 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bartdecoder
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"testing"
+)
+
+func TestNewLayer_NormalizeBefore(t *testing.T) {
+	for _, normalizeBefore := range []bool{false, true} {
+		layer := NewLayer(bartconfig.Config{
+			DModel:                4,
+			DecoderAttentionHeads: 2,
+			DecoderFFNDim:         8,
+			NormalizeBefore:       normalizeBefore,
+		})
+		if layer.NormalizeBefore != normalizeBefore {
+			t.Errorf("Expected NormalizeBefore %t, got %t", normalizeBefore, layer.NormalizeBefore)
+		}
+	}
+}
@@ -94,7 +94,7 @@ func (p *Processor) Decode(xs, encoded []ag.Node) []ag.Node {
 	// ys = p.Dropout(ys)
 
 	for _, layer := range p.Layers.Layers {
-		ys = layer.(*LayerProcessor).Process(ys, encoded)
+		ys = layer.(*LayerProcessor).Decode(ys, encoded, nil, nil)
 		// TODO: save all hidden states into the processor to allow a later access
 	}
 
@@ -6,118 +6,25 @@ package bartencoder
 
 import (
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
-	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/multiheadattention"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
 )
 
-var (
-	_ nn.Model     = &Layer{}
-	_ nn.Processor = &LayerProcessor{}
-)
-
 // Layer implements a BART encoder layer.
-type Layer struct {
-	Config                 bartconfig.Config
-	SelfAttention          *multiheadattention.Model
-	SelfAttentionLayerNorm *layernorm.Model
-	FFN                    *stack.Model // FC2(Activation(FC1))
-	LayerNorm              *layernorm.Model
-}
-
-// NewLayer returns a new BART encoder Layer.
-func NewLayer(config bartconfig.Config) *Layer {
-	return &Layer{
-		Config:                 config,
-		SelfAttention:          multiheadattention.New(config.DModel, config.EncoderAttentionHeads, false), // TODO: config.AttentionDropout
-		SelfAttentionLayerNorm: layernorm.New(config.DModel),
-		FFN: stack.New(
-			linear.New(config.DModel, config.EncoderFFNDim),
-			activation.New(ag.OpGELU), // TODO: config.ActivationFunction
-			// dropout.New(config.ActivationDropout)
-			linear.New(config.EncoderFFNDim, config.DModel),
-			// dropout.New(config.Dropout)
-		),
-		LayerNorm: layernorm.New(config.DModel),
-	}
-}
+type Layer = transformer.EncoderLayer
 
 // LayerProcessor implements the nn.Processor interface for a BART encoder Layer.
-type LayerProcessor struct {
-	nn.BaseProcessor
-	bartconfig.Config
-	SelfAttention          *multiheadattention.Processor
-	SelfAttentionLayerNorm *layernorm.Processor
-	FFN                    *stack.Processor
-	LayerNorm              *layernorm.Processor
-}
-
-// NewProc returns a new processor to execute the forward step.
-func (m *Layer) NewProc(ctx nn.Context) nn.Processor {
-	return &LayerProcessor{
-		BaseProcessor: nn.BaseProcessor{
-			Model:             m,
-			Mode:              ctx.Mode,
-			Graph:             ctx.Graph,
-			FullSeqProcessing: true,
-		},
-		SelfAttention:          m.SelfAttention.NewProc(ctx).(*multiheadattention.Processor),
-		SelfAttentionLayerNorm: m.SelfAttentionLayerNorm.NewProc(ctx).(*layernorm.Processor),
-		FFN:                    m.FFN.NewProc(ctx).(*stack.Processor),
-		LayerNorm:              m.LayerNorm.NewProc(ctx).(*layernorm.Processor),
-	}
-}
+type LayerProcessor = transformer.EncoderLayerProcessor
 
-// Forward performs the forward step for each input and returns the result.
-func (p *LayerProcessor) Forward(xs ...ag.Node) []ag.Node {
-	selfAtt := p.selfAttentionBlock(xs)
-	out := p.fullyConnectedBlock(selfAtt)
-	// TODO: limit output values if any Inf or NaN
-	return out
-}
-
-func (p *LayerProcessor) selfAttentionBlock(xs []ag.Node) []ag.Node {
-	residual := p.copy(xs)
-	if p.NormalizeBefore {
-		xs = p.SelfAttentionLayerNorm.Forward(xs...)
-	}
-	xs = p.SelfAttention.Forward(xs...) //  query=x, key=x, key_padding_mask=encoder_padding_mask
-	// xs = p.Dropout(xs) // config.Dropout
-	xs = p.add(residual, xs)
-	if !p.NormalizeBefore {
-		xs = p.SelfAttentionLayerNorm.Forward(xs...)
-	}
-	return xs
-}
-
-func (p *LayerProcessor) fullyConnectedBlock(xs []ag.Node) []ag.Node {
-	residual := p.copy(xs)
-	if p.NormalizeBefore {
-		xs = p.LayerNorm.Forward(xs...)
-	}
-	xs = p.FFN.Forward(xs...)
-	xs = p.add(residual, xs)
-	if !p.NormalizeBefore {
-		xs = p.LayerNorm.Forward(xs...)
-	}
-	return xs
-}
-
-func (p *LayerProcessor) copy(xs []ag.Node) []ag.Node {
-	copied := func(x ag.Node) ag.Node {
-		return p.Graph.Identity(x)
-	}
-	return ag.Map(copied, xs)
-}
-
-func (p *LayerProcessor) add(a []ag.Node, b []ag.Node) []ag.Node {
-	c := make([]ag.Node, len(a))
-	for i := 0; i < len(a); i++ {
-		c[i] = p.Graph.Add(a[i], b[i])
-	}
-	return c
+// NewLayer returns a new BART encoder Layer.
+// The layer normalization is applied before each sub-layer if config.NormalizeBefore is true (e.g. mBART),
+// after each residual connection otherwise.
+func NewLayer(config bartconfig.Config) *Layer {
+	return transformer.NewEncoderLayer(transformer.Config{
+		Size:                   config.DModel,
+		NumOfAttentionHeads:    config.EncoderAttentionHeads, // TODO: config.AttentionDropout
+		IntermediateSize:       config.EncoderFFNDim,
+		IntermediateActivation: ag.OpGELU, // TODO: config.ActivationFunction
+		NormalizeBefore:        config.NormalizeBefore,
+	})
 }
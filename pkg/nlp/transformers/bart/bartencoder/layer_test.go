@@ -0,0 +1,79 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bartencoder
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"gonum.org/v1/gonum/floats"
+	"testing"
+)
+
+func newTestLayer(normalizeBefore bool) *Layer {
+	layer := NewLayer(bartconfig.Config{
+		DModel:                4,
+		EncoderAttentionHeads: 2,
+		EncoderFFNDim:         8,
+		NormalizeBefore:       normalizeBefore,
+	})
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(layer, func(param *nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, rndGen)
+	})
+	return layer
+}
+
+func newTestInputs(g *ag.Graph) []ag.Node {
+	return []ag.Node{
+		g.NewVariable(mat.NewVecDense([]float64{0.3, -0.5, 0.8, 0.1}), false),
+		g.NewVariable(mat.NewVecDense([]float64{-0.2, 0.4, 0.6, -0.9}), false),
+	}
+}
+
+func TestLayer_NormalizeBefore(t *testing.T) {
+	layer := newTestLayer(true)
+	if !layer.NormalizeBefore {
+		t.Fatal("Expected the normalize_before configuration to be honored")
+	}
+	g := ag.NewGraph()
+	proc := layer.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*LayerProcessor)
+	xs := newTestInputs(g)
+
+	ys := proc.Forward(xs...)
+
+	// pre-norm residual connections: h = x + SelfAttention(Norm(x)), y = h + FFN(Norm(h))
+	normalized := proc.SelfAttentionNorm.Forward(xs...)
+	attention := proc.SelfAttention.ForwardQKV(normalized, normalized, normalized)
+	for i, x := range xs {
+		h := g.Add(x, attention[i])
+		expected := g.Add(h, proc.FFN.Forward(proc.FFNNorm.Forward(h)...)[0])
+		if !floats.EqualApprox(ys[i].Value().Data(), expected.Value().Data(), 1.0e-12) {
+			t.Errorf("Unexpected output at position %d", i)
+		}
+	}
+}
+
+func TestLayer_NormalizeAfter(t *testing.T) {
+	layer := newTestLayer(false)
+	g := ag.NewGraph()
+	proc := layer.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*LayerProcessor)
+	xs := newTestInputs(g)
+
+	ys := proc.Forward(xs...)
+
+	// post-norm residual connections: h = Norm(x + SelfAttention(x)), y = Norm(h + FFN(h))
+	attention := proc.SelfAttention.ForwardQKV(xs, xs, xs)
+	for i, x := range xs {
+		h := proc.SelfAttentionNorm.Forward(g.Add(x, attention[i]))[0]
+		expected := proc.FFNNorm.Forward(g.Add(h, proc.FFN.Forward(h)[0]))[0]
+		if !floats.EqualApprox(ys[i].Value().Data(), expected.Value().Data(), 1.0e-12) {
+			t.Errorf("Unexpected output at position %d", i)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package barthead
+
+import (
+	"strconv"
+
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+)
+
+var (
+	_ nn.Model     = &ConditionalGeneration{}
+	_ nn.Processor = &ConditionalGenerationProcessor{}
+)
+
+// ConditionalGeneration is a model for generation tasks which embeds a BART pre-trained model.
+// The language modeling head projects the decoder output onto the vocabulary, and its weights
+// are tied to the shared word embeddings of BART (see embeddings.OutputProjection).
+type ConditionalGeneration struct {
+	BART   *bart.Model
+	LMHead *embeddings.OutputProjection
+}
+
+// NewConditionalGeneration returns a new ConditionalGeneration.
+func NewConditionalGeneration(config bartconfig.Config, embeddingsPath string) *ConditionalGeneration {
+	model := bart.New(config, embeddingsPath)
+	return &ConditionalGeneration{
+		BART:   model,
+		LMHead: embeddings.NewOutputProjection(model.Embeddings, vocabularyIDs(config.VocabSize)),
+	}
+}
+
+// vocabularyIDs returns the keys of the BART word embeddings, which are the string representation of the IDs.
+func vocabularyIDs(size int) []string {
+	ids := make([]string, size)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+	return ids
+}
+
+// Close closes the BART model's embeddings DB.
+func (m *ConditionalGeneration) Close() {
+	m.BART.Close()
+}
+
+// ConditionalGenerationProcessor implements a nn.Processor for a BART ConditionalGeneration.
+type ConditionalGenerationProcessor struct {
+	nn.BaseProcessor
+	BART   *bart.Processor
+	LMHead *embeddings.OutputProjectionProcessor
+}
+
+// NewProc returns a new processor to execute the forward step.
+func (m *ConditionalGeneration) NewProc(ctx nn.Context) nn.Processor {
+	return &ConditionalGenerationProcessor{
+		BaseProcessor: nn.BaseProcessor{
+			Model:             m,
+			Mode:              ctx.Mode,
+			Graph:             ctx.Graph,
+			FullSeqProcessing: true,
+		},
+		BART:   m.BART.NewProc(ctx).(*bart.Processor),
+		LMHead: m.LMHead.NewProc(ctx).(*embeddings.OutputProjectionProcessor),
+	}
+}
+
+// Predict performs the forward step for each input and returns the logits over the vocabulary
+// for each position of the decoder.
+func (p *ConditionalGenerationProcessor) Predict(inputIds ...int) []ag.Node {
+	return p.LMHead.Forward(p.BART.Process(inputIds...)...)
+}
+
+// Forward is not implemented for BART ConditionalGenerationProcessor (it always panics).
+// You should use Predict instead.
+func (p *ConditionalGenerationProcessor) Forward(_ ...ag.Node) []ag.Node {
+	panic("barthead: Forward() not implemented for ConditionalGeneration. Use Predict() instead.")
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package barthead
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/bartconfig"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestConditionalGeneration_TiedLMHead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "barthead")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := bartconfig.Config{
+		ActivationFunction:    "gelu",
+		DModel:                4,
+		DecoderAttentionHeads: 2,
+		DecoderFFNDim:         8,
+		DecoderLayers:         1,
+		EncoderAttentionHeads: 2,
+		EncoderFFNDim:         8,
+		EncoderLayers:         1,
+		ExtraPosEmbedding:     2,
+		MaxPositionEmbeddings: 16,
+		VocabSize:             6,
+		Training:              true,
+	}
+	model := NewConditionalGeneration(config, dir)
+	defer model.Close()
+
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, rndGen)
+		}
+	})
+	initializers.Normal(model.LMHead.B.Value(), 0.0, 1.0, rndGen)
+	// the last word has no embedding
+	for id := 0; id < config.VocabSize-1; id++ {
+		embedding := mat.NewEmptyVecDense(config.DModel)
+		initializers.Normal(embedding, 0.0, 1.0, rndGen)
+		model.BART.Embeddings.SetEmbedding(strconv.Itoa(id), embedding)
+	}
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*ConditionalGenerationProcessor)
+	hidden := proc.BART.Process(1, 2, 3)
+	logits := proc.LMHead.Forward(hidden...)
+
+	// y = E x + b
+	for i, y := range logits {
+		x := hidden[i].Value().(*mat.Dense)
+		expected := make([]float64, config.VocabSize)
+		for id := range expected {
+			expected[id] = model.LMHead.B.Value().AtVec(id)
+			if embedding := model.BART.Embeddings.GetEmbedding(strconv.Itoa(id)); embedding != nil {
+				expected[id] += embedding.Value().(*mat.Dense).DotUnitary(x)
+			}
+		}
+		if !floats.EqualApprox(y.Value().Data(), expected, 1.0e-12) {
+			t.Errorf("Position %d: expected %v, got %v", i, expected, y.Value().Data())
+		}
+	}
+
+	// the gradients of sum(E x + b) w.r.t. an embedding not used by the input are equal to the sum of x
+	g.Backward(g.ReduceSum(g.Concat(logits...)))
+	expected := mat.NewEmptyVecDense(config.DModel)
+	for _, x := range hidden {
+		expected.AddInPlace(x.Value())
+	}
+	embedding := model.BART.Embeddings.GetEmbedding("4")
+	if !embedding.HasGrad() || !floats.EqualApprox(embedding.Grad().Data(), expected.Data(), 1.0e-12) {
+		t.Error("The gradients of the tied embedding don't match the expected values")
+	}
+	if input := model.BART.Embeddings.GetEmbedding("1"); !input.HasGrad() {
+		t.Error("Expected the gradients to reach the embeddings of the input")
+	}
+}
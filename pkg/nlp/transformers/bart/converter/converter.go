@@ -198,15 +198,15 @@ func mapBartEncoder(model *bartencoder.Model) map[string]mat.Matrix {
 		}
 		paramsMap[fmt.Sprintf("%s.self_attn.out_proj.weight", prefixBase)] = layer.SelfAttention.OutputMerge.W.Value()
 		paramsMap[fmt.Sprintf("%s.self_attn.out_proj.bias", prefixBase)] = layer.SelfAttention.OutputMerge.B.Value()
-		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.weight", prefixBase)] = layer.SelfAttentionLayerNorm.W.Value()
-		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.bias", prefixBase)] = layer.SelfAttentionLayerNorm.B.Value()
+		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.weight", prefixBase)] = layer.SelfAttentionNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.bias", prefixBase)] = layer.SelfAttentionNorm.B.Value()
 		// Sublayer 2
 		paramsMap[fmt.Sprintf("%s.fc1.weight", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).W.Value()
 		paramsMap[fmt.Sprintf("%s.fc1.bias", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).B.Value()
 		paramsMap[fmt.Sprintf("%s.fc2.weight", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).W.Value()
 		paramsMap[fmt.Sprintf("%s.fc2.bias", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).B.Value()
-		paramsMap[fmt.Sprintf("%s.final_layer_norm.weight", prefixBase)] = layer.LayerNorm.W.Value()
-		paramsMap[fmt.Sprintf("%s.final_layer_norm.bias", prefixBase)] = layer.LayerNorm.B.Value()
+		paramsMap[fmt.Sprintf("%s.final_layer_norm.weight", prefixBase)] = layer.FFNNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.final_layer_norm.bias", prefixBase)] = layer.FFNNorm.B.Value()
 	}
 
 	paramsMap["model.encoder.layernorm_embedding.weight"] = model.EmbeddingLayerNorm.W.Value()
@@ -235,12 +235,12 @@ func mapBartDecoder(model *bartdecoder.Model) map[string]mat.Matrix {
 		}
 		paramsMap[fmt.Sprintf("%s.self_attn.out_proj.weight", prefixBase)] = layer.SelfAttention.OutputMerge.W.Value()
 		paramsMap[fmt.Sprintf("%s.self_attn.out_proj.bias", prefixBase)] = layer.SelfAttention.OutputMerge.B.Value()
-		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.weight", prefixBase)] = layer.SelfAttentionLayerNorm.W.Value()
-		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.bias", prefixBase)] = layer.SelfAttentionLayerNorm.B.Value()
+		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.weight", prefixBase)] = layer.SelfAttentionNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.self_attn_layer_norm.bias", prefixBase)] = layer.SelfAttentionNorm.B.Value()
 
 		// Cross Attention
 		for j := 0; j < model.Config.DecoderAttentionHeads; j++ {
-			attention := layer.EncoderAttention.Attention[j]
+			attention := layer.CrossAttention.Attention[j]
 			prefix := fmt.Sprintf("%s.%d.encoder_attn", prefixBase, j)
 			paramsMap[fmt.Sprintf("%s.q_proj.weight", prefix)] = attention.Query.W.Value()
 			paramsMap[fmt.Sprintf("%s.q_proj.bias", prefix)] = attention.Query.B.Value()
@@ -249,18 +249,18 @@ func mapBartDecoder(model *bartdecoder.Model) map[string]mat.Matrix {
 			paramsMap[fmt.Sprintf("%s.v_proj.weight", prefix)] = attention.Value.W.Value()
 			paramsMap[fmt.Sprintf("%s.v_proj.bias", prefix)] = attention.Value.B.Value()
 		}
-		paramsMap[fmt.Sprintf("%s.encoder_attn.out_proj.weight", prefixBase)] = layer.EncoderAttention.OutputMerge.W.Value()
-		paramsMap[fmt.Sprintf("%s.encoder_attn.out_proj.bias", prefixBase)] = layer.EncoderAttention.OutputMerge.B.Value()
-		paramsMap[fmt.Sprintf("%s.encoder_attn_layer_norm.weight", prefixBase)] = layer.EncoderAttentionLayerNorm.W.Value()
-		paramsMap[fmt.Sprintf("%s.encoder_attn_layer_norm.bias", prefixBase)] = layer.EncoderAttentionLayerNorm.B.Value()
+		paramsMap[fmt.Sprintf("%s.encoder_attn.out_proj.weight", prefixBase)] = layer.CrossAttention.OutputMerge.W.Value()
+		paramsMap[fmt.Sprintf("%s.encoder_attn.out_proj.bias", prefixBase)] = layer.CrossAttention.OutputMerge.B.Value()
+		paramsMap[fmt.Sprintf("%s.encoder_attn_layer_norm.weight", prefixBase)] = layer.CrossAttentionNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.encoder_attn_layer_norm.bias", prefixBase)] = layer.CrossAttentionNorm.B.Value()
 
 		// Sublayer 2
 		paramsMap[fmt.Sprintf("%s.fc1.weight", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).W.Value()
 		paramsMap[fmt.Sprintf("%s.fc1.bias", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).B.Value()
 		paramsMap[fmt.Sprintf("%s.fc2.weight", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).W.Value()
 		paramsMap[fmt.Sprintf("%s.fc2.bias", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).B.Value()
-		paramsMap[fmt.Sprintf("%s.final_layer_norm.weight", prefixBase)] = layer.LayerNorm.W.Value()
-		paramsMap[fmt.Sprintf("%s.final_layer_norm.bias", prefixBase)] = layer.LayerNorm.B.Value()
+		paramsMap[fmt.Sprintf("%s.final_layer_norm.weight", prefixBase)] = layer.FFNNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.final_layer_norm.bias", prefixBase)] = layer.FFNNorm.B.Value()
 	}
 
 	paramsMap["model.decoder.layernorm_embedding.weight"] = model.EmbeddingLayerNorm.W.Value()
@@ -0,0 +1,41 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/adapter"
+)
+
+// AddAdapters freezes all the parameters of the model and inserts a bottleneck adapter (see package adapter)
+// after the feed-forward network of each encoder layer, before its residual connection and normalization.
+// The layers shared by ALBERT get a single adapter. It returns the new adapters, initialized with
+// adapter.Initialize, so that the model initially computes the same function as before.
+//
+// Only the adapters require gradients; the task-specific heads to be trained along with them
+// can be unfrozen with nn.Unfreeze. The trainable parameters can be stored apart from the
+// frozen ones using nn.NewTrainableParamsSerializer.
+func AddAdapters(m *Model, bottleneckSize int, rndGen *rand.LockedRand) []*adapter.Model {
+	nn.Freeze(m)
+	var adapters []*adapter.Model
+	visited := make(map[*EncoderLayer]bool)
+	for i := range m.Encoder.Layers {
+		layer := m.Encoder.LayerAt(i)
+		if visited[layer] {
+			continue
+		}
+		visited[layer] = true
+		a := adapter.New(adapter.Config{
+			Size:           m.Encoder.Size,
+			BottleneckSize: bottleneckSize,
+			Activation:     m.Encoder.IntermediateActivation,
+		})
+		adapter.Initialize(a, rndGen)
+		layer.FFN.Layers = append(layer.FFN.Layers, a)
+		adapters = append(adapters, a)
+	}
+	return adapters
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+func TestAddAdapters(t *testing.T) {
+	model := newTestModel(t, newTestDir(t), newTestConfig(), 42)
+	expected := encodeTokens(model, testTokens)
+
+	adapters := AddAdapters(model, 2, rand.NewLockedRand(1))
+	if len(adapters) != len(model.Encoder.Layers) {
+		t.Fatalf("Expected %d adapters, got %d", len(model.Encoder.Layers), len(adapters))
+	}
+
+	adapterParams := make(map[*nn.Param]bool)
+	for _, a := range adapters {
+		nn.ForEachParam(a, func(param *nn.Param) {
+			adapterParams[param] = true
+		})
+	}
+	trainable := 0
+	nn.ForEachParamWithPath(model, func(param *nn.Param, path string) {
+		if param.RequiresGrad() != adapterParams[param] {
+			t.Errorf("Unexpected RequiresGrad %t for param %s", param.RequiresGrad(), path)
+		}
+		if param.RequiresGrad() {
+			trainable++
+		}
+	})
+	if trainable != len(adapterParams) {
+		t.Errorf("Expected %d trainable params, got %d", len(adapterParams), trainable)
+	}
+
+	assertEqualEncodings(t, expected, encodeTokens(model, testTokens), 0.0)
+}
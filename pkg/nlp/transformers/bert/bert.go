@@ -46,6 +46,13 @@ type Config struct {
 	VocabSize             int               `json:"vocab_size"`
 	ID2Label              map[string]string `json:"id2label"`
 	ReadOnly              bool              `json:"read_only"`
+	// TieWordEmbeddings enables the tying of the Predictor output weights to the word embeddings.
+	TieWordEmbeddings bool `json:"tie_word_embeddings"`
+	// PrunedHeads contains the original indices of the attention heads removed from each layer (see PruneHeads).
+	PrunedHeads map[string][]int `json:"pruned_heads,omitempty"`
+	// IntermediateSizes contains the size of the feed-forward network of each layer after pruning (see PruneFFN).
+	// If empty, all the layers have IntermediateSize.
+	IntermediateSizes []int `json:"intermediate_sizes,omitempty"`
 }
 
 // LoadConfig loads a BERT model Config from file.
@@ -63,6 +70,18 @@ func LoadConfig(file string) (Config, error) {
 	return config, nil
 }
 
+// SaveConfig saves a BERT model Config to file.
+func SaveConfig(config Config, file string) error {
+	configFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer configFile.Close()
+	encoder := json.NewEncoder(configFile)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(config)
+}
+
 // Model implements a BERT model.
 type Model struct {
 	Config          Config
@@ -78,6 +97,8 @@ type Model struct {
 }
 
 // NewDefaultBERT returns a new model based on the original BERT architecture.
+// The encoder layers are not pruned according to Config.PrunedHeads and Config.IntermediateSizes;
+// LoadModel does it before loading the weights.
 func NewDefaultBERT(config Config, embeddingsStoragePath string) *Model {
 	return &Model{
 		Config:     config,
@@ -154,6 +175,9 @@ func LoadModel(modelPath string) (*Model, error) {
 	}
 	fmt.Printf("ok\n")
 	model := NewDefaultBERT(config, embeddingsFilename)
+	if err := model.applyConfigPruning(); err != nil {
+		return nil, err
+	}
 
 	fmt.Printf("[2/3] Loading vocabulary... ")
 	vocab, err := vocabulary.NewFromFile(vocabFilename)
@@ -162,6 +186,11 @@ func LoadModel(modelPath string) (*Model, error) {
 	}
 	fmt.Printf("ok\n")
 	model.Vocabulary = vocab
+	if config.TieWordEmbeddings {
+		if err := TieWordEmbeddings(model); err != nil {
+			return nil, err
+		}
+	}
 
 	fmt.Printf("[3/3] Loading model weights... ")
 	err = utils.DeserializeFromFile(modelFilename, nn.NewParamsSerializer(model))
@@ -0,0 +1,137 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+)
+
+// testTokens is a sequence of tokens of the vocabulary of the test models.
+var testTokens = []string{
+	wordpiecetokenizer.DefaultClassToken, "the", "cat", "sleeps", wordpiecetokenizer.DefaultSequenceSeparator,
+}
+
+// newTestDir returns a new temporary directory, removed at the end of the test.
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "bert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+// newTestModel returns a small randomly initialized BERT model, whose word embeddings are stored in the
+// default embeddings storage of the model directory.
+func newTestModel(t *testing.T, dir string, config Config, seed uint64) *Model {
+	vocab := vocabulary.New([]string{
+		wordpiecetokenizer.DefaultUnknownToken,
+		wordpiecetokenizer.DefaultClassToken,
+		wordpiecetokenizer.DefaultSequenceSeparator,
+		wordpiecetokenizer.DefaultMaskToken,
+		"the", "cat", "dog", "sleeps", "runs",
+	})
+	config.VocabSize = len(vocab.Items())
+	model := NewDefaultBERT(config, path.Join(dir, DefaultEmbeddingsStorage))
+	t.Cleanup(model.Embeddings.Word.Close)
+	model.Vocabulary = vocab
+
+	rndGen := rand.NewLockedRand(seed)
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, rndGen)
+		}
+	})
+	for _, term := range vocab.Items() {
+		embedding := mat.NewEmptyVecDense(config.HiddenSize)
+		initializers.Normal(embedding, 0.0, 1.0, rndGen)
+		model.Embeddings.Word.SetEmbedding(term, embedding)
+	}
+	return model
+}
+
+// newTestConfig returns the Config of a tiny BERT model.
+func newTestConfig() Config {
+	return Config{
+		HiddenAct:             "gelu",
+		HiddenSize:            8,
+		IntermediateSize:      12,
+		MaxPositionEmbeddings: 16,
+		NumAttentionHeads:     4,
+		NumHiddenLayers:       2,
+		TypeVocabSize:         2,
+	}
+}
+
+// encodeTokens returns the values of the hidden states of the last layer of the model for the tokens.
+func encodeTokens(m *Model, tokens []string) [][]float64 {
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := m.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	encoded := proc.Encode(tokens)
+	values := make([][]float64, len(encoded))
+	for i, x := range encoded {
+		values[i] = append([]float64(nil), x.Value().Data()...)
+	}
+	return values
+}
+
+// assertEqualEncodings fails the test if the encodings differ by more than the tolerance.
+func assertEqualEncodings(t *testing.T, expected, actual [][]float64, tolerance float64) {
+	t.Helper()
+	if len(expected) != len(actual) {
+		t.Fatalf("Expected %d encodings, got %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if len(expected[i]) != len(actual[i]) {
+			t.Fatalf("Expected encoding %d of size %d, got %d", i, len(expected[i]), len(actual[i]))
+		}
+		for j := range expected[i] {
+			if d := expected[i][j] - actual[i][j]; d > tolerance || d < -tolerance {
+				t.Fatalf("Encoding %d differs at %d: expected %g, got %g", i, j, expected[i][j], actual[i][j])
+			}
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	filename := path.Join(newTestDir(t), DefaultConfigurationFile)
+	content := `{
+  "architectures": ["BertForMaskedLM"],
+  "hidden_act": "gelu",
+  "hidden_size": 768,
+  "intermediate_size": 3072,
+  "max_position_embeddings": 512,
+  "num_attention_heads": 12,
+  "num_hidden_layers": 12,
+  "tie_word_embeddings": true,
+  "type_vocab_size": 2,
+  "vocab_size": 30522
+}`
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.TieWordEmbeddings {
+		t.Error("Expected the word embeddings to be tied")
+	}
+	if config.HiddenSize != 768 || config.NumHiddenLayers != 12 || config.VocabSize != 30522 {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+}
@@ -51,6 +51,9 @@ func ConvertHuggingFacePreTrained(modelPath string) error {
 		return err
 	}
 	model := NewDefaultBERT(config, path.Join(modelPath, DefaultEmbeddingsStorage))
+	if err := model.applyConfigPruning(); err != nil {
+		return err
+	}
 	model.Vocabulary = vocab
 
 	handler := &huggingFacePreTrainedConverter{
@@ -260,7 +263,7 @@ func mapBertEncoder(model *Encoder) map[string]mat.Matrix {
 		prefixBase := fmt.Sprintf("bert.encoder.layer.%d", i)
 		// Sublayer 1
 		for j := 0; j < model.NumOfAttentionHeads; j++ {
-			attention := layer.MultiHeadAttention.Attention[j]
+			attention := layer.SelfAttention.Attention[j]
 			prefix := fmt.Sprintf("%s.%d.attention.self", prefixBase, j)
 			paramsMap[fmt.Sprintf("%s.query.weight", prefix)] = attention.Query.W.Value()
 			paramsMap[fmt.Sprintf("%s.query.bias", prefix)] = attention.Query.B.Value()
@@ -270,17 +273,17 @@ func mapBertEncoder(model *Encoder) map[string]mat.Matrix {
 			paramsMap[fmt.Sprintf("%s.value.bias", prefix)] = attention.Value.B.Value()
 		}
 		prefix := fmt.Sprintf("bert.encoder.layer.%d.attention", i)
-		paramsMap[fmt.Sprintf("%s.output.dense.weight", prefix)] = layer.MultiHeadAttention.OutputMerge.W.Value()
-		paramsMap[fmt.Sprintf("%s.output.dense.bias", prefix)] = layer.MultiHeadAttention.OutputMerge.B.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefix)] = layer.NormAttention.W.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefix)] = layer.NormAttention.B.Value()
+		paramsMap[fmt.Sprintf("%s.output.dense.weight", prefix)] = layer.SelfAttention.OutputMerge.W.Value()
+		paramsMap[fmt.Sprintf("%s.output.dense.bias", prefix)] = layer.SelfAttention.OutputMerge.B.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefix)] = layer.SelfAttentionNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefix)] = layer.SelfAttentionNorm.B.Value()
 		// Sublayer 2
 		paramsMap[fmt.Sprintf("%s.intermediate.dense.weight", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).W.Value()
 		paramsMap[fmt.Sprintf("%s.intermediate.dense.bias", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).B.Value()
 		paramsMap[fmt.Sprintf("%s.output.dense.weight", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).W.Value()
 		paramsMap[fmt.Sprintf("%s.output.dense.bias", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).B.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefixBase)] = layer.NormFFN.W.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefixBase)] = layer.NormFFN.B.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefixBase)] = layer.FFNNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefixBase)] = layer.FFNNorm.B.Value()
 	}
 	return paramsMap
 }
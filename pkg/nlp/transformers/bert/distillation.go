@@ -0,0 +1,290 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"fmt"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/corpora"
+)
+
+// DistillationConfig provides configuration settings for a BERT DistillationTrainer.
+// The CorpusPath of the TrainingConfig is ignored, since the corpus is given to the trainer.
+type DistillationConfig struct {
+	TrainingConfig
+	// EmbeddingsPath is the path of the word embeddings storage of the student.
+	EmbeddingsPath string
+	// Temperature is the temperature of the softmax applied to the Predictor logits of both the
+	// teacher and the student to compute the soft-target loss.
+	Temperature float64
+	// SoftTargetWeight is the weight of the soft-target KL divergence loss.
+	SoftTargetWeight float64
+	// HiddenStateWeight is the weight of the hidden-state mean squared error loss.
+	HiddenStateWeight float64
+}
+
+// DistillationTrainer implements the knowledge distillation of a BERT Model (the teacher) into a
+// smaller one (the student), on a masked language modeling task (Sanh et al., 2019, "DistilBERT,
+// a distilled version of BERT: smaller, faster, cheaper and lighter").
+//
+// For each masked token, the student learns to reproduce the distribution predicted by the teacher,
+// softened by the temperature T, minimizing T² KL(teacher || student). In addition, the output of
+// each layer of the student learns to reproduce the output of the corresponding layer of the teacher,
+// minimizing their mean squared error. The i-th of the N layers of the student corresponds to the
+// ((i+1) M / N - 1)-th of the M layers of the teacher.
+type DistillationTrainer struct {
+	*Trainer
+	DistillationConfig
+	teacher *Model
+	corpus  corpora.TextCorpusIterator
+}
+
+// NewDistillationTrainer returns a new DistillationTrainer, creating the student from the given configuration.
+// The student shares the vocabulary of the teacher, and it must have the same hidden size, number of tokens,
+// positions, token types and labels. It may have fewer layers, heads and smaller feed-forward networks.
+//
+// As in DistilBERT, the student is initialized from the teacher: the embeddings and the heads are copied,
+// as well as the encoder layers corresponding to the ones of the teacher (see DistillationTrainer) when their
+// shapes match. The other layers are initialized randomly.
+func NewDistillationTrainer(
+	teacher *Model,
+	studentConfig Config,
+	config DistillationConfig,
+	corpus corpora.TextCorpusIterator,
+) (*DistillationTrainer, error) {
+	if err := checkStudentConfig(teacher.Config, studentConfig); err != nil {
+		return nil, err
+	}
+	student := NewDefaultBERT(studentConfig, config.EmbeddingsPath)
+	if err := student.applyConfigPruning(); err != nil {
+		return nil, err
+	}
+	student.Vocabulary = teacher.Vocabulary
+	if studentConfig.TieWordEmbeddings {
+		if err := TieWordEmbeddings(student); err != nil {
+			return nil, err
+		}
+	}
+	t := &DistillationTrainer{
+		Trainer:            NewTrainer(student, config.TrainingConfig),
+		DistillationConfig: config,
+		teacher:            teacher,
+		corpus:             corpus,
+	}
+	if err := t.initStudent(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func checkStudentConfig(teacher, student Config) error {
+	switch {
+	case student.HiddenSize != teacher.HiddenSize:
+		return fmt.Errorf("bert: the student must have the same hidden size of the teacher")
+	case student.VocabSize != teacher.VocabSize:
+		return fmt.Errorf("bert: the student must have the same vocabulary size of the teacher")
+	case student.MaxPositionEmbeddings != teacher.MaxPositionEmbeddings:
+		return fmt.Errorf("bert: the student must have the same max position embeddings of the teacher")
+	case student.TypeVocabSize != teacher.TypeVocabSize:
+		return fmt.Errorf("bert: the student must have the same type vocabulary size of the teacher")
+	case len(student.ID2Label) != len(teacher.ID2Label):
+		return fmt.Errorf("bert: the student must have the same labels of the teacher")
+	case student.NumHiddenLayers > teacher.NumHiddenLayers:
+		return fmt.Errorf("bert: the student must not have more layers than the teacher")
+	}
+	return nil
+}
+
+// Student returns the model being trained.
+func (t *DistillationTrainer) Student() *Model {
+	return t.model
+}
+
+// Train executes the distillation process.
+// If the training has been resumed (see Trainer.Resume), the passages already processed are skipped.
+func (t *DistillationTrainer) Train() {
+	start := t.countLine
+	t.corpus.ForEachLine(func(i int, text string) {
+		if i <= start {
+			return
+		}
+		t.distillPassage(text)
+		t.optimize(i)
+	})
+	t.optimizer.Flush() // applies the gradients of the last incomplete batch
+}
+
+// teacherLayer returns the index of the layer of the teacher corresponding to the i-th layer of the student.
+func (t *DistillationTrainer) teacherLayer(i int) int {
+	return (i+1)*len(t.teacher.Encoder.Layers)/len(t.model.Encoder.Layers) - 1
+}
+
+// teacherOutput contains the targets computed by the teacher for a passage.
+type teacherOutput struct {
+	hiddenStates [][]mat.Matrix
+	softTargets  map[int]mat.Matrix
+}
+
+// runTeacher returns the hidden states of the teacher layers corresponding to the student layers,
+// and the soft targets for the masked tokens. The values are detached from the teacher's graph.
+func (t *DistillationTrainer) runTeacher(tokens []string, maskedIds []int) teacherOutput {
+	g := ag.NewGraph(ag.ConcurrentComputations(true))
+	defer g.Clear()
+	proc := t.teacher.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+
+	states := proc.Encoder.HiddenStates(proc.Embeddings.Encode(tokens)...)
+	out := teacherOutput{
+		hiddenStates: make([][]mat.Matrix, len(t.model.Encoder.Layers)),
+		softTargets:  make(map[int]mat.Matrix, len(maskedIds)),
+	}
+	for i := range out.hiddenStates {
+		layerStates := states[t.teacherLayer(i)]
+		out.hiddenStates[i] = make([]mat.Matrix, len(layerStates))
+		for j, state := range layerStates {
+			out.hiddenStates[i][j] = state.Value().Clone()
+		}
+	}
+	temperature := g.NewScalar(t.Temperature)
+	for id, logits := range proc.PredictMasked(states[len(states)-1], maskedIds) {
+		out.softTargets[id] = g.Softmax(g.DivScalar(logits, temperature)).Value().Clone()
+	}
+	return out
+}
+
+func (t *DistillationTrainer) distillPassage(text string) {
+	tokenized := t.tokenize(text)
+	if len(tokenized) > t.model.Embeddings.MaxPositions {
+		return // skip, sequence too long
+	}
+	maskedTokens, maskedIds := t.applyMask(tokenized)
+	if len(maskedIds) == 0 {
+		return // skip, nothing to learn
+	}
+	targets := t.runTeacher(maskedTokens, maskedIds)
+
+	g := ag.NewGraph(ag.Rand(t.randGen), ag.ConcurrentComputations(true))
+	defer g.Clear()
+	proc := t.model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+
+	states := proc.Encoder.HiddenStates(proc.Embeddings.Encode(maskedTokens)...)
+	predicted := proc.PredictMasked(states[len(states)-1], maskedIds)
+
+	var softLoss ag.Node
+	for _, id := range maskedIds {
+		softLoss = g.Add(softLoss, softTargetLoss(g, predicted[id], targets.softTargets[id], t.Temperature))
+	}
+	softLoss = g.DivScalar(softLoss, g.NewScalar(float64(len(maskedIds))))
+
+	var hiddenLoss ag.Node
+	for i, layerStates := range states {
+		for j, state := range layerStates {
+			target := g.NewVariable(targets.hiddenStates[i][j], false)
+			hiddenLoss = g.Add(hiddenLoss, losses.MSE(g, state, target, true))
+		}
+	}
+	hiddenLoss = g.DivScalar(hiddenLoss, g.NewScalar(float64(len(states)*len(tokenized))))
+
+	loss := g.Add(
+		g.ProdScalar(softLoss, g.NewScalar(t.SoftTargetWeight)),
+		g.ProdScalar(hiddenLoss, g.NewScalar(t.HiddenStateWeight)),
+	)
+	g.Backward(loss)
+	t.lastBatchLoss = loss.ScalarValue()
+	fmt.Printf("Cnt: %d Loss: %.6f (soft-target: %.6f hidden-state: %.6f)\n",
+		t.countLine, t.lastBatchLoss, softLoss.ScalarValue(), hiddenLoss.ScalarValue())
+}
+
+// softTargetLoss returns the KL divergence between the target distribution and the distribution of the
+// logits softened by the temperature T, scaled by T² so that the magnitude of its gradients doesn't
+// depend on the temperature (Hinton et al., 2015, "Distilling the Knowledge in a Neural Network").
+func softTargetLoss(g *ag.Graph, logits ag.Node, target mat.Matrix, temperature float64) ag.Node {
+	x := g.DivScalar(logits, g.NewScalar(temperature))
+	kl := losses.KLDivergence(g, x, g.NewVariable(target, false))
+	return g.ProdScalar(kl, g.NewScalar(temperature*temperature))
+}
+
+// initStudent initializes the student from the teacher (see NewDistillationTrainer).
+func (t *DistillationTrainer) initStudent() error {
+	student, teacher := t.model, t.teacher
+
+	for _, word := range teacher.Vocabulary.Items() {
+		if embedding := teacher.Embeddings.Word.GetEmbedding(word); embedding != nil {
+			student.Embeddings.Word.SetEmbedding(word, embedding.Value().(*mat.Dense))
+		}
+	}
+	teacher.Embeddings.Word.ClearUsedEmbeddings()
+
+	pairs := []struct {
+		name     string
+		dst, src []*nn.Param
+	}{
+		{"position embeddings", student.Embeddings.Position, teacher.Embeddings.Position},
+		{"token type embeddings", student.Embeddings.TokenType, teacher.Embeddings.TokenType},
+		{"embeddings normalization", paramsOf(student.Embeddings.Norm), paramsOf(teacher.Embeddings.Norm)},
+		{"predictor", paramsOf(student.Predictor), paramsOf(teacher.Predictor)},
+		{"discriminator", paramsOf(student.Discriminator), paramsOf(teacher.Discriminator)},
+		{"pooler", paramsOf(student.Pooler), paramsOf(teacher.Pooler)},
+		{"sequence relationship", paramsOf(student.SeqRelationship), paramsOf(teacher.SeqRelationship)},
+		{"span classifier", paramsOf(student.SpanClassifier), paramsOf(teacher.SpanClassifier)},
+		{"classifier", paramsOf(student.Classifier), paramsOf(teacher.Classifier)},
+	}
+	for _, pair := range pairs {
+		if !copyParams(pair.dst, pair.src) {
+			return fmt.Errorf("bert: the %s of the student doesn't match the teacher", pair.name)
+		}
+	}
+
+	for i := range student.Encoder.Layers {
+		layer := student.Encoder.LayerAt(i)
+		if !copyParams(paramsOf(layer), paramsOf(teacher.Encoder.LayerAt(t.teacherLayer(i)))) {
+			initEncoderLayer(layer, t.randGen)
+		}
+	}
+	return nil
+}
+
+// paramsOf returns the parameters of the model, or nil if the model is nil.
+func paramsOf(m nn.Model) []*nn.Param {
+	if m == nil {
+		return nil
+	}
+	return nn.NewDefaultParamsIterator(m).ParamsList()
+}
+
+// copyParams copies the values of src into dst, returning false without any change if their shapes don't match.
+func copyParams(dst, src []*nn.Param) bool {
+	if len(dst) != len(src) {
+		return false
+	}
+	for i := range dst {
+		if !mat.SameDims(dst[i].Value(), src[i].Value()) {
+			return false
+		}
+	}
+	for i := range dst {
+		dst[i].Value().SetData(src[i].Value().Data())
+	}
+	return true
+}
+
+// initEncoderLayer initializes the weights of the layer with a normal distribution (standard deviation 0.02),
+// the biases with zeros and the normalization layers to the identity.
+func initEncoderLayer(layer *EncoderLayer, rndGen *rand.LockedRand) {
+	nn.ForEachParam(layer, func(param *nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.Normal(param.Value(), 0, 0.02, rndGen)
+		} else {
+			initializers.Zeros(param.Value())
+		}
+	})
+	initializers.Ones(layer.SelfAttentionNorm.W.Value())
+	initializers.Ones(layer.FFNNorm.W.Value())
+}
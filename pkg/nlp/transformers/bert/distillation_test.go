@@ -0,0 +1,262 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"math"
+	"path"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"gonum.org/v1/gonum/floats"
+)
+
+// sliceCorpus is a corpora.TextCorpusIterator over the given lines.
+type sliceCorpus []string
+
+func (c sliceCorpus) ForEachLine(callback func(i int, line string)) {
+	for i, line := range c {
+		callback(i+1, line)
+	}
+}
+
+// newTestDistillation returns a new DistillationTrainer of a student with two layers from a teacher with four.
+func newTestDistillation(t *testing.T, studentConfig Config, corpus sliceCorpus) *DistillationTrainer {
+	teacherConfig := newTestConfig()
+	teacherConfig.NumHiddenLayers = 4
+	teacher := newTestModel(t, newTestDir(t), teacherConfig, 42)
+	studentConfig.VocabSize = teacher.Config.VocabSize
+
+	trainer, err := NewDistillationTrainer(teacher, studentConfig, DistillationConfig{
+		TrainingConfig: TrainingConfig{
+			Seed:         1,
+			UpdateMethod: adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8),
+		},
+		EmbeddingsPath:    path.Join(newTestDir(t), DefaultEmbeddingsStorage),
+		Temperature:       2.0,
+		SoftTargetWeight:  1.0,
+		HiddenStateWeight: 1.0,
+	}, corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(trainer.Student().Embeddings.Word.Close)
+	return trainer
+}
+
+// newTestStudentConfig returns the Config of a student with two layers.
+func newTestStudentConfig() Config {
+	config := newTestConfig()
+	config.NumHiddenLayers = 2
+	return config
+}
+
+// equalParams reports whether the two lists of parameters have the same values.
+func equalParams(a, b []*nn.Param) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !floats.Equal(a[i].Value().Data(), b[i].Value().Data()) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDistillationTrainer_InitStudent(t *testing.T) {
+	trainer := newTestDistillation(t, newTestStudentConfig(), nil)
+	student, teacher := trainer.Student(), trainer.teacher
+
+	if trainer.teacherLayer(0) != 1 || trainer.teacherLayer(1) != 3 {
+		t.Errorf("Expected the student layers to correspond to the teacher layers 1 and 3, got %d and %d",
+			trainer.teacherLayer(0), trainer.teacherLayer(1))
+	}
+	for _, word := range teacher.Vocabulary.Items() {
+		expected := teacher.Embeddings.Word.GetEmbedding(word).Value().Data()
+		if !floats.Equal(student.Embeddings.Word.GetEmbedding(word).Value().Data(), expected) {
+			t.Errorf("The embedding of %q is expected to be copied from the teacher", word)
+		}
+	}
+	if !equalParams(student.Embeddings.Position, teacher.Embeddings.Position) ||
+		!equalParams(paramsOf(student.Predictor), paramsOf(teacher.Predictor)) ||
+		!equalParams(paramsOf(student.Classifier), paramsOf(teacher.Classifier)) {
+		t.Error("The embeddings and the heads are expected to be copied from the teacher")
+	}
+	for i := range student.Encoder.Layers {
+		teacherLayer := teacher.Encoder.LayerAt(trainer.teacherLayer(i))
+		if !equalParams(paramsOf(student.Encoder.LayerAt(i)), paramsOf(teacherLayer)) {
+			t.Errorf("The layer %d is expected to be copied from the teacher layer %d", i, trainer.teacherLayer(i))
+		}
+	}
+}
+
+func TestDistillationTrainer_InitStudentFallback(t *testing.T) {
+	config := newTestStudentConfig()
+	config.IntermediateSize = 6
+	trainer := newTestDistillation(t, config, nil)
+	student, teacher := trainer.Student(), trainer.teacher
+
+	if !equalParams(paramsOf(student.Predictor), paramsOf(teacher.Predictor)) {
+		t.Error("The heads are expected to be copied from the teacher")
+	}
+	for i := range student.Encoder.Layers {
+		layer := student.Encoder.LayerAt(i)
+		nn.ForEachParam(layer, func(param *nn.Param) {
+			switch param.Type() {
+			case nn.Weights:
+				if std(param.Value().Data()) > 0.05 {
+					t.Errorf("Expected the weights of the layer %d to be sampled with standard deviation 0.02", i)
+				}
+			case nn.Biases:
+				if floats.Max(param.Value().Abs().Data()) != 0 {
+					t.Errorf("Expected zero biases in the layer %d", i)
+				}
+			}
+		})
+		if !floats.Equal(layer.FFNNorm.W.Value().Data(), mat.NewInitVecDense(8, 1.0).Data()) ||
+			!floats.Equal(layer.SelfAttentionNorm.W.Value().Data(), mat.NewInitVecDense(8, 1.0).Data()) {
+			t.Errorf("Expected the normalization of the layer %d to be initialized to the identity", i)
+		}
+	}
+}
+
+// std returns the standard deviation of the values.
+func std(values []float64) float64 {
+	mean := floats.Sum(values) / float64(len(values))
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+func TestCopyParams(t *testing.T) {
+	dst := []*nn.Param{nn.NewParam(mat.NewVecDense([]float64{1, 2})), nn.NewParam(mat.NewVecDense([]float64{3}))}
+	src := []*nn.Param{nn.NewParam(mat.NewVecDense([]float64{4, 5})), nn.NewParam(mat.NewVecDense([]float64{6, 7}))}
+	if copyParams(dst, src) {
+		t.Error("Expected the copy to fail with different shapes")
+	}
+	if !floats.Equal(dst[0].Value().Data(), []float64{1, 2}) {
+		t.Error("Expected no changes when the copy fails")
+	}
+	if copyParams(dst, src[:1]) {
+		t.Error("Expected the copy to fail with a different number of params")
+	}
+	src[1] = nn.NewParam(mat.NewVecDense([]float64{6}))
+	if !copyParams(dst, src) || !equalParams(dst, src) {
+		t.Error("Expected the params to be copied")
+	}
+}
+
+func TestSoftTargetLoss(t *testing.T) {
+	logits := []float64{1.0, 2.0, 3.0}
+	target := []float64{0.2, 0.3, 0.5}
+	for _, temperature := range []float64{1.0, 2.0, 4.0} {
+		g := ag.NewGraph()
+		x := g.NewVariable(mat.NewVecDense(logits), true)
+		loss := softTargetLoss(g, x, mat.NewVecDense(target), temperature)
+		g.Backward(loss)
+
+		// T² KL(target || softmax(logits / T)), whose gradients w.r.t. the logits are T (softmax(logits / T) - target)
+		q := softmax(logits, temperature)
+		expected := 0.0
+		for i := range target {
+			expected += target[i] * (math.Log(target[i]) - math.Log(q[i]))
+		}
+		expected *= temperature * temperature
+		if math.Abs(loss.ScalarValue()-expected) > 1.0e-9 {
+			t.Errorf("T=%g: expected loss %g, got %g", temperature, expected, loss.ScalarValue())
+		}
+		grads := make([]float64, len(q))
+		for i := range q {
+			grads[i] = temperature * (q[i] - target[i])
+		}
+		if !floats.EqualApprox(x.Grad().Data(), grads, 1.0e-9) {
+			t.Errorf("T=%g: expected gradients %v, got %v", temperature, grads, x.Grad().Data())
+		}
+		g.Clear()
+	}
+}
+
+// softmax returns the softmax of the values divided by the temperature.
+func softmax(values []float64, temperature float64) []float64 {
+	out := make([]float64, len(values))
+	sum := 0.0
+	for i, v := range values {
+		out[i] = math.Exp(v / temperature)
+		sum += out[i]
+	}
+	for i := range out {
+		out[i] /= sum
+	}
+	return out
+}
+
+func TestEncoderProcessor_HiddenStates(t *testing.T) {
+	model := newTestModel(t, newTestDir(t), newTestConfig(), 42)
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	xs := proc.Embeddings.Encode(testTokens)
+	states := proc.Encoder.HiddenStates(xs...)
+
+	if len(states) != len(model.Encoder.Layers) {
+		t.Fatalf("Expected %d hidden states, got %d", len(model.Encoder.Layers), len(states))
+	}
+	first := proc.Encoder.Layers[0].Forward(xs...)
+	last := proc.Encoder.Forward(xs...)
+	for j := range testTokens {
+		if !floats.Equal(states[0][j].Value().Data(), first[j].Value().Data()) ||
+			!floats.Equal(states[len(states)-1][j].Value().Data(), last[j].Value().Data()) {
+			t.Fatalf("The hidden states of the token %d don't match the output of the layers", j)
+		}
+	}
+}
+
+// hiddenStateLoss returns the mean squared error between the hidden states of the student and of the
+// corresponding layers of the teacher for the test tokens.
+func hiddenStateLoss(trainer *DistillationTrainer) float64 {
+	targets := trainer.runTeacher(testTokens, nil)
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := trainer.Student().NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*Processor)
+	loss := 0.0
+	for i, layerStates := range proc.Encoder.HiddenStates(proc.Embeddings.Encode(testTokens)...) {
+		for j, state := range layerStates {
+			loss += losses.MSE(g, state, g.NewVariable(targets.hiddenStates[i][j], false), true).ScalarValue()
+		}
+	}
+	return loss
+}
+
+func TestDistillationTrainer_Train(t *testing.T) {
+	passages := []string{
+		"the cat sleeps the dog runs",
+		"the dog sleeps the cat runs",
+		"the cat runs the dog sleeps",
+	}
+	var corpus sliceCorpus
+	for i := 0; i < 10; i++ {
+		corpus = append(corpus, passages...)
+	}
+	config := newTestStudentConfig()
+	config.IntermediateSize = 6 // the layers of the student are initialized randomly
+	trainer := newTestDistillation(t, config, corpus)
+
+	before := hiddenStateLoss(trainer)
+	trainer.Train()
+	after := hiddenStateLoss(trainer)
+	if trainer.countLine != len(corpus) {
+		t.Errorf("Expected %d processed passages, got %d", len(corpus), trainer.countLine)
+	}
+	if !(after < before) {
+		t.Errorf("Expected the hidden-state loss to decrease, got %g before and %g after the training", before, after)
+	}
+}
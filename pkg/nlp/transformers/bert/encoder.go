@@ -15,11 +15,8 @@ package bert
 import (
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/multiheadattention"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
 )
 
 var (
@@ -70,22 +67,8 @@ func (p *EncoderProcessor) LayerAt(i int) *EncoderLayerProcessor {
 func NewBertEncoder(config EncoderConfig) *Encoder {
 	return &Encoder{
 		EncoderConfig: config,
-		Model: stack.Make(config.NumOfLayers, func(i int) nn.Model {
-			return &EncoderLayer{
-				MultiHeadAttention: multiheadattention.New(
-					config.Size,
-					config.NumOfAttentionHeads,
-					false, // don't use causal mask
-				),
-				NormAttention: layernorm.New(config.Size),
-				FFN: stack.New(
-					linear.New(config.Size, config.IntermediateSize),
-					activation.New(config.IntermediateActivation),
-					linear.New(config.IntermediateSize, config.Size),
-				),
-				NormFFN: layernorm.New(config.Size),
-				Index:   i,
-			}
+		Model: stack.Make(config.NumOfLayers, func(_ int) nn.Model {
+			return transformer.NewEncoderLayer(config.layerConfig())
 		}),
 	}
 }
@@ -93,20 +76,7 @@ func NewBertEncoder(config EncoderConfig) *Encoder {
 // NewAlbertEncoder returns a new variant of the BERT encoder model.
 // In this variant the stack of N identical BERT encoder layers share the same parameters.
 func NewAlbertEncoder(config EncoderConfig) *Encoder {
-	sharedLayer := &EncoderLayer{
-		MultiHeadAttention: multiheadattention.New(
-			config.Size,
-			config.NumOfAttentionHeads,
-			false, // don't use causal mask
-		),
-		NormAttention: layernorm.New(config.Size),
-		FFN: stack.New(
-			linear.New(config.Size, config.IntermediateSize),
-			activation.New(config.IntermediateActivation),
-			linear.New(config.IntermediateSize, config.Size),
-		),
-		NormFFN: layernorm.New(config.Size),
-	}
+	sharedLayer := transformer.NewEncoderLayer(config.layerConfig())
 	return &Encoder{
 		EncoderConfig: config,
 		Model: stack.Make(config.NumOfLayers, func(_ int) nn.Model {
@@ -114,3 +84,25 @@ func NewAlbertEncoder(config EncoderConfig) *Encoder {
 		}),
 	}
 }
+
+// layerConfig returns the configuration of the Transformer encoder layers.
+func (c EncoderConfig) layerConfig() transformer.Config {
+	return transformer.Config{
+		Size:                   c.Size,
+		NumOfAttentionHeads:    c.NumOfAttentionHeads,
+		IntermediateSize:       c.IntermediateSize,
+		IntermediateActivation: c.IntermediateActivation,
+		NormalizeBefore:        false, // BERT uses post-norm residual connections
+	}
+}
+
+// HiddenStates performs the forward step for each input and returns the output of each layer.
+// The last element corresponds to the output of Forward.
+func (p *EncoderProcessor) HiddenStates(xs ...ag.Node) [][]ag.Node {
+	states := make([][]ag.Node, len(p.Layers))
+	for i, layer := range p.Layers {
+		xs = layer.Forward(xs...)
+		states[i] = xs
+	}
+	return states
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+)
+
+// Example_semanticSimilarity fine-tunes the Pooler of a BERT model on pairs of paraphrases with the
+// InfoNCE loss, using the other pairs of the batch as negative examples, so that the sentence encodings
+// used by the `similarity` command of cmd/bert (the normalized pooled [CLS] hidden state) bring the
+// paraphrases closer together. The rest of the model is frozen.
+//
+// A pre-trained model would be loaded with bert.LoadModel in practice, and the fine-tuned parameters
+// saved with nn.NewParamsSerializer to be used by the BERT server.
+func Example_semanticSimilarity() {
+	pairs := [][2]string{
+		{"the cat sleeps", "a cat is sleeping"},
+		{"the dog runs", "a dog is running"},
+		{"the sun shines", "it is sunny"},
+		{"we eat pizza", "pizza for dinner"},
+	}
+	dir, err := ioutil.TempDir("", "bert")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	model := newModel(pairs, path.Join(dir, "embeddings"))
+
+	nn.Freeze(model)
+	nn.Unfreeze(model.Pooler)
+	optimizer := gd.NewOptimizer(adam.New(adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8)), nn.NewDefaultParamsIterator(model.Pooler))
+
+	for epoch := 0; epoch < 100; epoch++ {
+		g := ag.NewGraph()
+		proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*bert.Processor)
+		queries := make([]ag.Node, len(pairs))
+		keys := make([]ag.Node, len(pairs))
+		for i, pair := range pairs {
+			queries[i] = encode(model, proc, pair[0])
+			keys[i] = encode(model, proc, pair[1])
+		}
+		g.Backward(losses.InfoNCE(g, queries, keys, 0.1, true))
+		optimizer.Optimize()
+		optimizer.IncExample()
+	}
+
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Inference}).(*bert.Processor)
+	similarity := func(text1, text2 string) float64 {
+		vec1 := encode(model, proc, text1).Value().(*mat.Dense).Normalize2()
+		vec2 := encode(model, proc, text2).Value().(*mat.Dense).Normalize2()
+		return vec1.DotUnitary(vec2)
+	}
+	for _, pair := range pairs {
+		best, bestSimilarity := "", -1.0
+		for _, candidate := range pairs {
+			if sim := similarity(pair[0], candidate[1]); sim > bestSimilarity {
+				best, bestSimilarity = candidate[1], sim
+			}
+		}
+		fmt.Printf("%s => %s\n", pair[0], best)
+	}
+	// Output:
+	// the cat sleeps => a cat is sleeping
+	// the dog runs => a dog is running
+	// the sun shines => it is sunny
+	// we eat pizza => pizza for dinner
+}
+
+// encode returns the pooled encoding of the text, as computed by the BERT server.
+func encode(model *bert.Model, proc *bert.Processor, text string) ag.Node {
+	tokenizer := wordpiecetokenizer.New(model.Vocabulary)
+	tokens := tokenizers.GetStrings(tokenizer.Tokenize(text))
+	tokens = append(append([]string{wordpiecetokenizer.DefaultClassToken}, tokens...), wordpiecetokenizer.DefaultSequenceSeparator)
+	return proc.Pool(proc.Encode(tokens))
+}
+
+// newModel returns a small randomly initialized BERT model whose vocabulary contains the words of the pairs.
+func newModel(pairs [][2]string, embeddingsPath string) *bert.Model {
+	terms := []string{
+		wordpiecetokenizer.DefaultUnknownToken,
+		wordpiecetokenizer.DefaultClassToken,
+		wordpiecetokenizer.DefaultSequenceSeparator,
+		wordpiecetokenizer.DefaultMaskToken,
+	}
+	vocab := vocabulary.New(terms)
+	for _, pair := range pairs {
+		for _, text := range pair {
+			for _, word := range strings.Fields(text) {
+				vocab.Add(word)
+			}
+		}
+	}
+	model := bert.NewDefaultBERT(bert.Config{
+		HiddenSize:            16,
+		IntermediateSize:      32,
+		MaxPositionEmbeddings: 16,
+		NumAttentionHeads:     2,
+		NumHiddenLayers:       2,
+		TypeVocabSize:         2,
+		VocabSize:             vocab.Size(),
+	}, embeddingsPath)
+	model.Vocabulary = vocab
+
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param *nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, rndGen)
+		}
+	})
+	for _, term := range vocab.Items() {
+		embedding := mat.NewEmptyVecDense(16)
+		initializers.Normal(embedding, 0.0, 1.0, rndGen)
+		model.Embeddings.Word.SetEmbedding(term, embedding)
+	}
+	return model
+}
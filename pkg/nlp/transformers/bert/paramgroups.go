@@ -0,0 +1,85 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
+	"math"
+)
+
+// NoDecayParams returns a gd.ParamSelector which selects the params that are usually excluded from
+// the weight decay during the fine-tuning: the biases and the weights of the layer normalizations.
+func NoDecayParams(m *Model) gd.ParamSelector {
+	return gd.SelectByPath(m, `(\.B|Norm\.W)$`)
+}
+
+// LayerwiseConfig provides configuration settings for LayerwiseParamGroups.
+type LayerwiseConfig struct {
+	// Method is the update method of the groups, whose learning rate is scaled by layer.
+	Method gd.MethodConfig
+	// NoDecayMethod, if not nil, is the update method of the NoDecayParams (e.g. the same method without
+	// weight decay), whose learning rate is scaled by layer as well.
+	NoDecayMethod gd.MethodConfig
+	// Decay is the factor of the learning rate of each layer with respect to the layer above.
+	Decay float64
+	// Options, if not nil, returns the options of each group given its scaled learning rate,
+	// e.g. a learning rate schedule starting from it.
+	Options func(learningRate float64) []gd.Option
+}
+
+// LayerwiseParamGroups returns the options to optimize the embeddings and each encoder layer with
+// discriminative learning rates (Howard and Ruder, 2018, "Universal Language Model Fine-tuning for Text
+// Classification"). The learning rate of the methods is multiplied by decay^d, where d is the distance
+// from the top of the encoder: 0 for the last layer, up to NumHiddenLayers for the embeddings.
+//
+// If a NoDecayMethod is given, the NoDecayParams of the embeddings and of each layer get their own group
+// with the same scale, and those of the other params (e.g. the classifier) a group with the unscaled
+// NoDecayMethod. The other params use the default method of the optimizer.
+// It panics if a method doesn't implement gd.LearningRateSetter.
+func LayerwiseParamGroups(m *Model, config LayerwiseConfig) []gd.Option {
+	numOfLayers := len(m.Encoder.Layers)
+	models := make([]nn.Model, 0, numOfLayers+1)
+	models = append(models, m.Embeddings)
+	for _, layer := range m.Encoder.Layers {
+		models = append(models, layer)
+	}
+
+	var opts []gd.Option
+	for i, model := range models {
+		scale := math.Pow(config.Decay, float64(numOfLayers-i))
+		if config.NoDecayMethod != nil {
+			selector := gd.SelectAll(gd.SelectByModel(model), NoDecayParams(m))
+			opts = append(opts, config.group(selector, config.NoDecayMethod, scale))
+		}
+		opts = append(opts, config.group(gd.SelectByModel(model), config.Method, scale))
+	}
+	if config.NoDecayMethod != nil {
+		opts = append(opts, config.group(NoDecayParams(m), config.NoDecayMethod, 1.0))
+	}
+	return opts
+}
+
+// group returns the option of a param group optimized by the method with the learning rate scaled by the factor.
+func (c LayerwiseConfig) group(selector gd.ParamSelector, method gd.MethodConfig, scale float64) gd.Option {
+	scaled := scaledMethod(method, scale)
+	var opts []gd.Option
+	if c.Options != nil {
+		opts = c.Options(scaled.(gd.LearningRateSetter).LearningRate())
+	}
+	return gd.WithParamGroup(selector, scaled, opts...)
+}
+
+// scaledMethod returns a new method whose learning rate is multiplied by the scale.
+func scaledMethod(config gd.MethodConfig, scale float64) gd.Method {
+	method := gdmbuilder.NewMethod(config)
+	setter, ok := method.(gd.LearningRateSetter)
+	if !ok {
+		panic("bert: the optimization method doesn't support the change of the learning rate")
+	}
+	setter.SetLearningRate(setter.LearningRate() * scale)
+	return method
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/lrschedule"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestLayerwiseParamGroups(t *testing.T) {
+	model := newTestModel(t, newTestDir(t), newTestConfig(), 42)
+	var learningRates []float64
+	opts := LayerwiseParamGroups(model, LayerwiseConfig{
+		Method:        sgd.NewConfig(1.0, 0.0, false),
+		NoDecayMethod: sgd.NewConfig(0.1, 0.0, false),
+		Decay:         0.5,
+		Options: func(learningRate float64) []gd.Option {
+			learningRates = append(learningRates, learningRate)
+			return []gd.Option{gd.ScheduleLRByEpoch(lrschedule.NewStep(learningRate, 1, 0.5))}
+		},
+	})
+	// the no-decay and the other params of the embeddings, of the two layers, and the remaining no-decay params
+	expected := []float64{0.025, 0.25, 0.05, 0.5, 0.1, 1.0, 0.1}
+	if !floats.EqualApprox(learningRates, expected, 1.0e-12) {
+		t.Fatalf("Expected the learning rates %v, got %v", expected, learningRates)
+	}
+
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(2.0, 0.0, false)), nn.NewDefaultParamsIterator(model), opts...)
+	optimizer.IncEpoch() // the schedules of the groups halve their learning rates
+
+	before := make(map[*nn.Param]mat.Matrix)
+	nn.ForEachParam(model, func(param *nn.Param) {
+		before[param] = param.Value().Clone()
+		param.PropagateGrad(param.Value().OnesLike())
+	})
+	optimizer.Optimize()
+
+	assertUpdate := func(name string, params []*nn.Param, expected float64) {
+		t.Helper()
+		for _, param := range params {
+			for _, v := range before[param].Sub(param.Value()).Data() {
+				if d := v - expected; d > 1.0e-12 || d < -1.0e-12 {
+					t.Errorf("%s: expected an update of %g, got %g", name, expected, v)
+					return
+				}
+			}
+		}
+	}
+	assertUpdate("position embeddings", model.Embeddings.Position, 0.125)
+	assertUpdate("embeddings normalization biases", []*nn.Param{model.Embeddings.Norm.B}, 0.0125)
+	assertUpdate("second layer weights", []*nn.Param{model.Encoder.LayerAt(1).FFN.Layers[0].(*linear.Model).W}, 0.5)
+	assertUpdate("first layer normalization weights", []*nn.Param{model.Encoder.LayerAt(0).FFNNorm.W}, 0.025)
+	assertUpdate("classifier biases", []*nn.Param{model.Classifier.B}, 0.05)
+	assertUpdate("classifier weights", []*nn.Param{model.Classifier.W}, 2.0)
+}
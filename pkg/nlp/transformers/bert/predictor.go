@@ -5,12 +5,15 @@
 package bert
 
 import (
+	"fmt"
+
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
 )
 
 var (
@@ -45,6 +48,46 @@ func NewPredictor(config PredictorConfig) *Predictor {
 	}
 }
 
+// TieWordEmbeddings replaces the output layer of the Predictor with a projection whose weights are tied
+// to the word embeddings (see embeddings.OutputProjection), keeping the output biases.
+// The vocabulary of the model must be already loaded, and the word embeddings must have the same
+// size of the hidden layer of the Predictor.
+//
+// The tied weights are not parameters of the Predictor anymore, so a model saved after tying the embeddings
+// must be loaded with Config.TieWordEmbeddings enabled (see LoadModel).
+func TieWordEmbeddings(m *Model) error {
+	if m.Vocabulary == nil {
+		return fmt.Errorf("bert: the vocabulary is required to tie the word embeddings")
+	}
+	index, err := m.Predictor.outputLayer()
+	if err != nil {
+		return err
+	}
+	output := m.Predictor.Layers[index].(*linear.Model)
+	if _, in := output.W.Value().Dims(); in != m.Embeddings.Word.Size {
+		return fmt.Errorf("bert: the word embeddings size %d doesn't match the predictor size %d",
+			m.Embeddings.Word.Size, in)
+	}
+	projection := embeddings.NewOutputProjection(m.Embeddings.Word, m.Vocabulary.Items())
+	projection.B = output.B
+	m.Predictor.Layers[index] = projection
+	return nil
+}
+
+// outputLayer returns the index of the output layer of the Predictor, that is its last linear layer.
+// It returns an error if the output layer is already an embeddings.OutputProjection.
+func (m *Predictor) outputLayer() (int, error) {
+	for i := len(m.Layers) - 1; i >= 0; i-- {
+		switch m.Layers[i].(type) {
+		case *linear.Model:
+			return i, nil
+		case *embeddings.OutputProjection:
+			return 0, fmt.Errorf("bert: the word embeddings are already tied")
+		}
+	}
+	return 0, fmt.Errorf("bert: the predictor has no output layer")
+}
+
 // PredictorProcessor implements a nn.Processor for a BERT Predictor.
 type PredictorProcessor struct {
 	*stack.Processor
@@ -0,0 +1,72 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/mat"
+	"github.com/nlpodyssey/spago/pkg/mat/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestTieWordEmbeddings(t *testing.T) {
+	model := newTestModel(t, newTestDir(t), newTestConfig(), 42)
+	rndGen := rand.NewLockedRand(1)
+	// the output layer is found wherever it is
+	model.Predictor.Layers = append([]nn.Model{activation.New(ag.OpIdentity)}, model.Predictor.Layers...)
+	biases := model.Predictor.Layers[4].(*linear.Model).B
+	initializers.Normal(biases.Value(), 0.0, 1.0, rndGen)
+
+	if err := TieWordEmbeddings(model); err != nil {
+		t.Fatal(err)
+	}
+	projection, ok := model.Predictor.Layers[4].(*embeddings.OutputProjection)
+	if !ok {
+		t.Fatalf("Expected the output layer to be an OutputProjection, got %T", model.Predictor.Layers[4])
+	}
+	if projection.B != biases {
+		t.Error("Expected the projection to keep the output biases")
+	}
+	if err := TieWordEmbeddings(model); err == nil {
+		t.Error("Expected an error tying the word embeddings twice")
+	}
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	x := mat.NewEmptyVecDense(model.Config.HiddenSize)
+	initializers.Normal(x, 0.0, 1.0, rndGen)
+	xNode := g.NewVariable(x, false)
+	y := projection.NewProc(nn.Context{Graph: g, Mode: nn.Training}).Forward(xNode)[0]
+
+	// y = E x + b
+	words := model.Vocabulary.Items()
+	expected := make([]float64, len(words))
+	for i, word := range words {
+		expected[i] = model.Embeddings.Word.GetEmbedding(word).Value().(*mat.Dense).DotUnitary(x) +
+			biases.Value().AtVec(i)
+	}
+	if !floats.EqualApprox(y.Value().Data(), expected, 1.0e-12) {
+		t.Errorf("Expected %v, got %v", expected, y.Value().Data())
+	}
+
+	// the gradients of sum(E x + b) w.r.t. each word embedding are equal to x
+	g.Backward(g.ReduceSum(y))
+	for _, word := range words {
+		embedding := model.Embeddings.Word.GetEmbedding(word)
+		if !embedding.HasGrad() || !floats.EqualApprox(embedding.Grad().Data(), x.Data(), 1.0e-12) {
+			t.Errorf("Expected the gradients of the embedding of %q to be equal to the input", word)
+		}
+	}
+	if !floats.EqualApprox(biases.Grad().Data(), mat.NewInitVecDense(len(words), 1.0).Data(), 1.0e-12) {
+		t.Errorf("Unexpected gradients of the biases %v", biases.Grad().Data())
+	}
+}
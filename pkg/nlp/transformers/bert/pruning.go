@@ -0,0 +1,258 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ImportanceScorer accumulates the gradient-based importance of the attention heads and of the
+// feed-forward neurons of each layer of a BERT Model (see transformer.EncoderLayer.NeuronImportance
+// and multiheadattention.Model.HeadImportance), to choose what to prune.
+type ImportanceScorer struct {
+	model *Model
+	// HeadScores contains the importance of each head of each layer.
+	HeadScores [][]float64
+	// NeuronScores contains the importance of each feed-forward neuron of each layer.
+	NeuronScores [][]float64
+}
+
+// NewImportanceScorer returns a new ImportanceScorer for the given model, with all the scores set to zero.
+func NewImportanceScorer(m *Model) *ImportanceScorer {
+	numOfLayers := len(m.Encoder.Layers)
+	s := &ImportanceScorer{
+		model:        m,
+		HeadScores:   make([][]float64, numOfLayers),
+		NeuronScores: make([][]float64, numOfLayers),
+	}
+	for i := 0; i < numOfLayers; i++ {
+		layer := m.Encoder.LayerAt(i)
+		s.HeadScores[i] = make([]float64, layer.SelfAttention.NumOfHeads())
+		s.NeuronScores[i] = make([]float64, layer.IntermediateSize)
+	}
+	return s
+}
+
+// Accumulate adds the importance estimated from the current gradients of the model to the scores.
+// It must be called after each backward step on the data used to score the model, before the
+// gradients are zeroed.
+func (s *ImportanceScorer) Accumulate() error {
+	for i := range s.HeadScores {
+		layer := s.model.Encoder.LayerAt(i)
+		for h, v := range layer.SelfAttention.HeadImportance() {
+			s.HeadScores[i][h] += v
+		}
+		neurons, err := layer.NeuronImportance()
+		if err != nil {
+			return err
+		}
+		for j, v := range neurons {
+			s.NeuronScores[i][j] += v
+		}
+	}
+	return nil
+}
+
+// HeadsToPrune returns the n least important heads across all the layers, indexed by layer.
+// The scores are normalized by layer (L2 norm) before ranking them, and at least one head
+// is kept in each layer.
+func (s *ImportanceScorer) HeadsToPrune(n int) map[int][]int {
+	type head struct {
+		layer, index int
+		score        float64
+	}
+	var heads []head
+	for i, scores := range s.HeadScores {
+		norm := 0.0
+		for _, v := range scores {
+			norm += v * v
+		}
+		norm = math.Sqrt(norm)
+		for h, v := range scores {
+			if norm > 0 {
+				v /= norm
+			}
+			heads = append(heads, head{layer: i, index: h, score: v})
+		}
+	}
+	sort.SliceStable(heads, func(i, j int) bool { return heads[i].score < heads[j].score })
+
+	selected := make(map[int][]int)
+	for _, h := range heads {
+		if n == 0 {
+			break
+		}
+		if len(selected[h.layer])+1 == len(s.HeadScores[h.layer]) {
+			continue // keep at least one head
+		}
+		selected[h.layer] = append(selected[h.layer], h.index)
+		n--
+	}
+	return selected
+}
+
+// NeuronsToPrune returns the n least important feed-forward neurons of each layer.
+// At least one neuron is kept in each layer.
+func (s *ImportanceScorer) NeuronsToPrune(n int) map[int][]int {
+	selected := make(map[int][]int, len(s.NeuronScores))
+	for i, scores := range s.NeuronScores {
+		indices := make([]int, len(scores))
+		for j := range indices {
+			indices[j] = j
+		}
+		sort.SliceStable(indices, func(a, b int) bool { return scores[indices[a]] < scores[indices[b]] })
+		if n < len(indices) {
+			indices = indices[:n]
+		} else {
+			indices = indices[:len(indices)-1]
+		}
+		if len(indices) > 0 {
+			selected[i] = indices
+		}
+	}
+	return selected
+}
+
+// PruneHeads removes the given attention heads of each layer, indexed by layer. The heads are
+// identified by their current position in the layer, which differs from the original one after
+// a previous pruning. The original indices of the removed heads are recorded in Config.PrunedHeads,
+// so that the pruned model can be saved and loaded again (see LoadModel).
+// All the layers are checked before pruning any of them, so the model is left unchanged on error.
+func PruneHeads(m *Model, heads map[int][]int) error {
+	if err := checkPrunableLayers(m, heads); err != nil {
+		return err
+	}
+	for i, layerHeads := range heads {
+		removed := make(map[int]bool, len(layerHeads))
+		numOfHeads := m.Encoder.LayerAt(i).SelfAttention.NumOfHeads()
+		for _, h := range layerHeads {
+			if h < 0 || h >= numOfHeads {
+				return fmt.Errorf("bert: head %d of layer %d out of range", h, i)
+			}
+			removed[h] = true
+		}
+		if len(removed) == numOfHeads {
+			return fmt.Errorf("bert: cannot prune all the heads of layer %d", i)
+		}
+	}
+	for i, layerHeads := range heads {
+		remaining := m.remainingHeads(i)
+		m.Encoder.LayerAt(i).PruneHeads(layerHeads)
+		if m.Config.PrunedHeads == nil {
+			m.Config.PrunedHeads = make(map[string][]int)
+		}
+		key := strconv.Itoa(i)
+		removed := make(map[int]bool, len(layerHeads))
+		for _, h := range layerHeads {
+			if !removed[h] {
+				removed[h] = true
+				m.Config.PrunedHeads[key] = append(m.Config.PrunedHeads[key], remaining[h])
+			}
+		}
+		sort.Ints(m.Config.PrunedHeads[key])
+	}
+	return nil
+}
+
+// PruneFFN removes the given hidden neurons of the feed-forward network of each layer, indexed by layer.
+// The resulting sizes are recorded in Config.IntermediateSizes, so that the pruned model can be saved
+// and loaded again (see LoadModel).
+// All the layers are checked before pruning any of them, so the model is left unchanged on error.
+func PruneFFN(m *Model, neurons map[int][]int) error {
+	if err := checkPrunableLayers(m, neurons); err != nil {
+		return err
+	}
+	for i, layerNeurons := range neurons {
+		if err := m.Encoder.LayerAt(i).CheckFFNPruning(layerNeurons); err != nil {
+			return fmt.Errorf("bert: layer %d: %w", i, err)
+		}
+	}
+	for i, layerNeurons := range neurons {
+		layer := m.Encoder.LayerAt(i)
+		if err := layer.PruneFFN(layerNeurons); err != nil {
+			return fmt.Errorf("bert: layer %d: %w", i, err)
+		}
+		if m.Config.IntermediateSizes == nil {
+			m.Config.IntermediateSizes = make([]int, len(m.Encoder.Layers))
+			for j := range m.Config.IntermediateSizes {
+				m.Config.IntermediateSizes[j] = m.Config.IntermediateSize
+			}
+		}
+		m.Config.IntermediateSizes[i] = layer.IntermediateSize
+	}
+	return nil
+}
+
+// checkPrunableLayers returns an error if a layer index is out of range, or if the layers share
+// their parameters (e.g. ALBERT), since pruning a shared layer would affect all the others.
+func checkPrunableLayers(m *Model, layers map[int][]int) error {
+	seen := make(map[*EncoderLayer]bool, len(m.Encoder.Layers))
+	for i := range m.Encoder.Layers {
+		layer := m.Encoder.LayerAt(i)
+		if seen[layer] {
+			return fmt.Errorf("bert: cannot prune layers with shared parameters")
+		}
+		seen[layer] = true
+	}
+	for i := range layers {
+		if i < 0 || i >= len(m.Encoder.Layers) {
+			return fmt.Errorf("bert: layer %d out of range", i)
+		}
+	}
+	return nil
+}
+
+// remainingHeads returns the original indices of the heads not yet pruned from the i-th layer.
+func (m *Model) remainingHeads(i int) []int {
+	pruned := make(map[int]bool)
+	for _, h := range m.Config.PrunedHeads[strconv.Itoa(i)] {
+		pruned[h] = true
+	}
+	var remaining []int
+	for h := 0; h < m.Config.NumAttentionHeads; h++ {
+		if !pruned[h] {
+			remaining = append(remaining, h)
+		}
+	}
+	return remaining
+}
+
+// applyConfigPruning prunes the layers of a newly created model according to Config.PrunedHeads
+// and Config.IntermediateSizes, so that its parameters match the ones of the saved pruned model.
+// The values of the parameters are irrelevant, since they are expected to be loaded afterwards.
+func (m *Model) applyConfigPruning() error {
+	for key, heads := range m.Config.PrunedHeads {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(m.Encoder.Layers) {
+			return fmt.Errorf("bert: invalid pruned heads layer %q", key)
+		}
+		if len(heads) > 0 {
+			m.Encoder.LayerAt(i).PruneHeads(heads)
+		}
+	}
+	if m.Config.IntermediateSizes == nil {
+		return nil
+	}
+	if len(m.Config.IntermediateSizes) != len(m.Encoder.Layers) {
+		return fmt.Errorf("bert: expected %d intermediate sizes, got %d",
+			len(m.Encoder.Layers), len(m.Config.IntermediateSizes))
+	}
+	for i, size := range m.Config.IntermediateSizes {
+		var neurons []int
+		for j := size; j < m.Config.IntermediateSize; j++ {
+			neurons = append(neurons, j)
+		}
+		if len(neurons) == 0 {
+			continue
+		}
+		if err := m.Encoder.LayerAt(i).PruneFFN(neurons); err != nil {
+			return fmt.Errorf("bert: layer %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/utils"
+)
+
+func TestPruning_SaveAndLoad(t *testing.T) {
+	dir := newTestDir(t)
+	model := newTestModel(t, dir, newTestConfig(), 42)
+
+	scorer := NewImportanceScorer(model)
+	g := ag.NewGraph()
+	proc := model.NewProc(nn.Context{Graph: g, Mode: nn.Training}).(*Processor)
+	encoded := proc.Encode(testTokens)
+	g.Backward(g.ReduceSum(g.Square(g.Concat(encoded...))))
+	if err := scorer.Accumulate(); err != nil {
+		t.Fatal(err)
+	}
+	g.Clear()
+	nn.ForEachParam(model, func(param *nn.Param) { param.ZeroGrad() })
+
+	if err := PruneHeads(model, scorer.HeadsToPrune(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := PruneFFN(model, scorer.NeuronsToPrune(4)); err != nil {
+		t.Fatal(err)
+	}
+	numOfHeads := 0
+	for i := range model.Encoder.Layers {
+		layer := model.Encoder.LayerAt(i)
+		numOfHeads += layer.SelfAttention.NumOfHeads()
+		if layer.IntermediateSize != 8 || model.Config.IntermediateSizes[i] != 8 {
+			t.Errorf("Expected intermediate size 8 in layer %d, got %d", i, layer.IntermediateSize)
+		}
+	}
+	if numOfHeads != 5 {
+		t.Errorf("Expected 5 heads left, got %d", numOfHeads)
+	}
+	expected := encodeTokens(model, testTokens)
+
+	if err := SaveConfig(model.Config, path.Join(dir, DefaultConfigurationFile)); err != nil {
+		t.Fatal(err)
+	}
+	vocab := strings.Join(model.Vocabulary.Items(), "\n")
+	if err := ioutil.WriteFile(path.Join(dir, DefaultVocabularyFile), []byte(vocab), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model.Embeddings.Word.ClearUsedEmbeddings() // the word embeddings are kept in their storage
+	if err := utils.SerializeToFile(path.Join(dir, DefaultModelFile), nn.NewParamsSerializer(model)); err != nil {
+		t.Fatal(err)
+	}
+	model.Embeddings.Word.Close() // release the embeddings storage
+
+	loaded, err := LoadModel(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Embeddings.Word.Close()
+	for i := range loaded.Encoder.Layers {
+		layer, original := loaded.Encoder.LayerAt(i), model.Encoder.LayerAt(i)
+		if layer.SelfAttention.NumOfHeads() != original.SelfAttention.NumOfHeads() ||
+			layer.IntermediateSize != original.IntermediateSize {
+			t.Errorf("The loaded layer %d doesn't match the pruned one", i)
+		}
+	}
+	assertEqualEncodings(t, expected, encodeTokens(loaded, testTokens), 0.0)
+}
+
+func TestPruning_UnchangedOnError(t *testing.T) {
+	model := newTestModel(t, newTestDir(t), newTestConfig(), 42)
+	expected := encodeTokens(model, testTokens)
+
+	if err := PruneHeads(model, map[int][]int{0: {0}, 1: {0, 1, 2, 3}}); err == nil {
+		t.Error("Expected an error pruning all the heads of a layer")
+	}
+	if err := PruneHeads(model, map[int][]int{0: {0}, 1: {4}}); err == nil {
+		t.Error("Expected an error for a head out of range")
+	}
+	if err := PruneFFN(model, map[int][]int{0: {0}, 1: {12}}); err == nil {
+		t.Error("Expected an error for a neuron out of range")
+	}
+	if err := PruneFFN(model, map[int][]int{0: {0}, 2: {0}}); err == nil {
+		t.Error("Expected an error for a layer out of range")
+	}
+
+	for i := range model.Encoder.Layers {
+		layer := model.Encoder.LayerAt(i)
+		if layer.SelfAttention.NumOfHeads() != 4 || layer.IntermediateSize != 12 {
+			t.Errorf("Expected the layer %d to be unchanged", i)
+		}
+	}
+	if model.Config.PrunedHeads != nil || model.Config.IntermediateSizes != nil {
+		t.Error("Expected the config to be unchanged")
+	}
+	assertEqualEncodings(t, expected, encodeTokens(model, testTokens), 0.0)
+}
@@ -5,9 +5,6 @@
 package bert
 
 import (
-	"archive/tar"
-	"bufio"
-	"compress/gzip"
 	"fmt"
 	"github.com/nlpodyssey/spago/pkg/mat/rand"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
@@ -15,23 +12,25 @@ import (
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
+	"github.com/nlpodyssey/spago/pkg/nlp/corpora"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 	"github.com/nlpodyssey/spago/pkg/utils"
-	"io"
-	"log"
 	"math"
-	"os"
 )
 
 // TrainingConfig provides configuration settings for a BERT Trainer.
 type TrainingConfig struct {
-	Seed             uint64
+	Seed uint64
+	// BatchSize is the number of passages whose gradients are accumulated before each update of the model.
 	BatchSize        int
 	GradientClipping float64
 	UpdateMethod     gd.MethodConfig
 	CorpusPath       string
 	ModelPath        string
+	// CheckpointPath is the file where the state of the training (optimizer, random generator and progress)
+	// is saved together with the model, so that it can be resumed (see Resume). If empty, it is not saved.
+	CheckpointPath string
 }
 
 // Trainer implements the training process for a BERT Model.
@@ -43,40 +42,91 @@ type Trainer struct {
 	lastBatchLoss float64
 	model         *Model
 	countLine     int
+	checkpoint    *gd.Checkpoint
+	// serializationDue reports whether the serialization is postponed to the end of the accumulation cycle.
+	serializationDue bool
 }
 
 // NewTrainer returns a new BERT Trainer.
 func NewTrainer(model *Model, config TrainingConfig) *Trainer {
-	optimizer := gd.NewOptimizer(gdmbuilder.NewMethod(config.UpdateMethod), nn.NewDefaultParamsIterator(model))
-	if config.GradientClipping != 0.0 {
-		gd.ClipGradByNorm(config.GradientClipping, 2.0)(optimizer)
-	}
+	randGen := rand.NewLockedRand(config.Seed)
+	optimizer := newOptimizer(config, model)
 	return &Trainer{
 		TrainingConfig: config,
-		randGen:        rand.NewLockedRand(config.Seed),
+		randGen:        randGen,
 		optimizer:      optimizer,
 		model:          model,
+		checkpoint:     gd.NewCheckpoint(config.CheckpointPath, optimizer, randGen, model),
+	}
+}
+
+// newOptimizer returns a new optimizer of the given models, configured according to the TrainingConfig.
+func newOptimizer(config TrainingConfig, models ...nn.Model) *gd.GradientDescent {
+	optimizer := gd.NewOptimizer(gdmbuilder.NewMethod(config.UpdateMethod), nn.NewDefaultParamsIterator(models...))
+	if config.GradientClipping != 0.0 {
+		gd.ClipGradByNorm(config.GradientClipping, 2.0)(optimizer)
+	}
+	if config.BatchSize > 1 {
+		gd.AccumulateGrads(config.BatchSize)(optimizer)
 	}
+	return optimizer
 }
 
 // Train executes the training process.
+// If the training has been resumed, the passages already processed are skipped.
 func (t *Trainer) Train() {
-	t.forEachLine(func(i int, text string) {
-		t.trainPassage(text)
-		t.optimizer.IncBatch()
-		t.optimizer.IncExample()
-		t.optimizer.Optimize()
-
-		if i > 0 && i%1000 == 0 {
-			fmt.Println("=== MODEL SERIALIZATION")
-			err := utils.SerializeToFile(t.ModelPath, nn.NewParamsSerializer(t.model))
-			if err != nil {
-				panic("bert: error during model serialization.")
-			}
+	start := t.countLine
+	corpora.NewGZipCorpusIterator(t.CorpusPath).ForEachLine(func(i int, text string) {
+		if i <= start {
+			return
 		}
-
-		t.countLine++
+		t.trainPassage(text)
+		t.optimize(i)
 	})
+	t.optimizer.Flush() // applies the gradients of the last incomplete batch
+}
+
+// optimize updates the model after the i-th passage, serializing it every 1000 passages.
+// When the gradients are accumulated (see BatchSize), the serialization is postponed to the end of the
+// current cycle, since the state of the optimizer doesn't include the pending gradients.
+func (t *Trainer) optimize(i int) {
+	t.optimizer.IncBatch()
+	t.optimizer.IncExample()
+	t.optimizer.Optimize()
+	t.countLine++
+
+	if i > 0 && i%1000 == 0 {
+		t.serializationDue = true
+	}
+	if t.serializationDue && t.optimizer.PendingMicroBatches() == 0 {
+		t.serialize()
+		t.serializationDue = false
+	}
+}
+
+// serialize saves the model to ModelPath and the state of the training to CheckpointPath.
+// The used word embeddings are released before, since they are kept in their own storage together with
+// their optimizer state, so that the model can be loaded again (see LoadModel and Resume).
+func (t *Trainer) serialize() {
+	fmt.Println("=== MODEL SERIALIZATION")
+	t.model.Embeddings.Word.ClearUsedEmbeddings()
+	err := utils.SerializeToFile(t.ModelPath, nn.NewParamsSerializer(t.model))
+	if err != nil {
+		panic("bert: error during model serialization.")
+	}
+	if err := t.checkpoint.Save(t.countLine); err != nil {
+		panic(fmt.Sprintf("bert: error during checkpoint serialization (%s)", err.Error()))
+	}
+}
+
+// Resume restores the model from ModelPath and the state of the training from CheckpointPath, so that the
+// next call to Train continues from the first passage not yet processed.
+func (t *Trainer) Resume() error {
+	err := utils.DeserializeFromFile(t.ModelPath, nn.NewParamsSerializer(t.model))
+	if err != nil {
+		return err
+	}
+	return t.checkpoint.Load(&t.countLine)
 }
 
 func (t *Trainer) tokenize(text string) []string {
@@ -146,36 +196,3 @@ func (t *Trainer) getMaskedForm(orig string) string {
 		return orig
 	}
 }
-
-func (t *Trainer) forEachLine(callback func(i int, line string)) {
-	f, err := os.Open(t.CorpusPath)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-	uncompressedStream, err := gzip.NewReader(f)
-	if err != nil {
-		log.Fatal(err)
-	}
-	tarReader := tar.NewReader(uncompressedStream)
-	i := 0
-	for true {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Fatalf("Next() failed: %s", err.Error())
-		}
-		if header.Typeflag == tar.TypeReg {
-			scanner := bufio.NewScanner(tarReader)
-			for scanner.Scan() {
-				i++
-				callback(i, scanner.Text())
-			}
-			if err := scanner.Err(); err != nil {
-				log.Fatal(err)
-			}
-		}
-	}
-}
@@ -0,0 +1,136 @@
+// Copyright 2020 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+)
+
+var testCorpus = []string{
+	"the cat sleeps the dog runs",
+	"the dog sleeps the cat runs",
+	"the cat runs the dog sleeps",
+	"the dog runs the cat sleeps",
+	"the cat sleeps the cat runs",
+	"the dog sleeps the dog runs",
+}
+
+// writeTestCorpus writes the lines to a gzipped tar archive, as expected by the Trainer.
+func writeTestCorpus(t *testing.T, filename string, lines []string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gzipWriter := gzip.NewWriter(f)
+	tarWriter := tar.NewWriter(gzipWriter)
+	content := strings.Join(lines, "\n") + "\n"
+	header := &tar.Header{Name: "corpus.txt", Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tarWriter.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestTrainingConfig returns the TrainingConfig of a Trainer whose files are in the directory.
+func newTestTrainingConfig(dir string, corpusPath string) TrainingConfig {
+	return TrainingConfig{
+		Seed:           7,
+		BatchSize:      1,
+		UpdateMethod:   adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8),
+		CorpusPath:     corpusPath,
+		ModelPath:      path.Join(dir, DefaultModelFile),
+		CheckpointPath: path.Join(dir, "checkpoint.bin"),
+	}
+}
+
+func TestTrainer_Resume(t *testing.T) {
+	const checkpointLine = 3
+	corpusDir := newTestDir(t)
+	fullCorpus := path.Join(corpusDir, "full.tar.gz")
+	partialCorpus := path.Join(corpusDir, "partial.tar.gz")
+	writeTestCorpus(t, fullCorpus, testCorpus)
+	writeTestCorpus(t, partialCorpus, testCorpus[:checkpointLine])
+
+	// uninterrupted training
+	expectedModel := newTestModel(t, newTestDir(t), newTestConfig(), 42)
+	expected := NewTrainer(expectedModel, newTestTrainingConfig(newTestDir(t), fullCorpus))
+	expected.Train()
+	if expected.countLine != len(testCorpus) {
+		t.Fatalf("Expected %d trained lines, got %d", len(testCorpus), expected.countLine)
+	}
+
+	// training interrupted after the checkpoint
+	dir := newTestDir(t)
+	interruptedModel := newTestModel(t, dir, newTestConfig(), 42)
+	interrupted := NewTrainer(interruptedModel, newTestTrainingConfig(dir, partialCorpus))
+	interrupted.Train()
+	interrupted.serialize()
+	interruptedModel.Embeddings.Word.Close()
+
+	config := newTestConfig()
+	config.VocabSize = len(interruptedModel.Vocabulary.Items())
+	resumedModel := NewDefaultBERT(config, path.Join(dir, DefaultEmbeddingsStorage))
+	t.Cleanup(resumedModel.Embeddings.Word.Close)
+	resumedModel.Vocabulary = interruptedModel.Vocabulary
+	resumed := NewTrainer(resumedModel, newTestTrainingConfig(dir, fullCorpus))
+	if err := resumed.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.countLine != checkpointLine {
+		t.Fatalf("Expected to resume after %d lines, got %d", checkpointLine, resumed.countLine)
+	}
+	resumed.Train()
+	if resumed.countLine != len(testCorpus) {
+		t.Fatalf("Expected %d trained lines, got %d", len(testCorpus), resumed.countLine)
+	}
+
+	assertEqualEncodings(t, encodeTokens(expectedModel, testTokens), encodeTokens(resumedModel, testTokens), 1.0e-9)
+	if !equalParams(paramsOf(resumedModel.Predictor), paramsOf(expectedModel.Predictor)) {
+		t.Error("The predictor of the resumed training differs from the uninterrupted one")
+	}
+}
+
+func TestTrainer_SerializeAtEndOfCycle(t *testing.T) {
+	dir := newTestDir(t)
+	model := newTestModel(t, dir, newTestConfig(), 42)
+	config := newTestTrainingConfig(dir, "")
+	config.BatchSize = 2
+	trainer := NewTrainer(model, config)
+
+	trainer.trainPassage(testCorpus[0])
+	trainer.optimize(1000)
+	if _, err := os.Stat(config.CheckpointPath); !os.IsNotExist(err) {
+		t.Fatal("Expected the checkpoint to be postponed to the end of the accumulation cycle")
+	}
+	trainer.trainPassage(testCorpus[1])
+	trainer.optimize(1001)
+	if _, err := os.Stat(config.CheckpointPath); err != nil {
+		t.Fatalf("Expected the checkpoint at the end of the accumulation cycle: %v", err)
+	}
+
+	resumed := NewTrainer(model, config)
+	if err := resumed.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.countLine != 2 {
+		t.Errorf("Expected a checkpoint after 2 lines, got %d", resumed.countLine)
+	}
+}
@@ -18,6 +18,8 @@ type WorkerPool struct {
 	size       int
 	ingestChan chan interface{}
 	jobsChan   chan interface{}
+	stopChan   chan struct{}
+	stopOnce   sync.Once
 }
 
 // WorkerFunc is a function to perform a single worker job.
@@ -29,11 +31,24 @@ func New(size int) *WorkerPool {
 		size:       size,
 		ingestChan: make(chan interface{}, 1),
 		jobsChan:   make(chan interface{}, size),
+		stopChan:   make(chan struct{}),
 	}
 }
 
-// Run runs all workers and blocks until a signal is received.
+// Run runs all workers and blocks until a signal is received, or Stop is called.
 func (wp *WorkerPool) Run(workerFunc WorkerFunc) {
+	wp.run(workerFunc, wp.blockUntilSignal)
+}
+
+// RunUntilStopped runs all workers and blocks until Stop is called.
+// Unlike Run, it doesn't handle the interrupt and termination signals, which keep their default behavior.
+func (wp *WorkerPool) RunUntilStopped(workerFunc WorkerFunc) {
+	wp.run(workerFunc, func() {
+		<-wp.stopChan
+	})
+}
+
+func (wp *WorkerPool) run(workerFunc WorkerFunc, wait func()) {
 	ctx, ctxCancelFunc := context.WithCancel(context.Background())
 
 	wg := &sync.WaitGroup{}
@@ -45,12 +60,20 @@ func (wp *WorkerPool) Run(workerFunc WorkerFunc) {
 		go wp.runWorker(workerID, wg, workerFunc)
 	}
 
-	wp.blockUntilSignal()
+	wait()
 
 	ctxCancelFunc()
 	wg.Wait()
 }
 
+// Stop makes Run terminate gracefully, as if a termination signal were received.
+// The jobs already taken by the workers are completed before Run returns.
+func (wp *WorkerPool) Stop() {
+	wp.stopOnce.Do(func() {
+		close(wp.stopChan)
+	})
+}
+
 // PublishJobData adds some data to be processed by the workers.
 func (wp *WorkerPool) PublishJobData(jobData interface{}) {
 	wp.ingestChan <- jobData
@@ -76,7 +99,11 @@ func (wp *WorkerPool) runConsumer(ctx context.Context) {
 }
 
 func (wp *WorkerPool) blockUntilSignal() {
-	termChan := make(chan os.Signal)
+	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
-	<-termChan
+	defer signal.Stop(termChan)
+	select {
+	case <-termChan:
+	case <-wp.stopChan:
+	}
 }
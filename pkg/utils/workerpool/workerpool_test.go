@@ -147,6 +147,40 @@ func TestWorkerPool(t *testing.T) {
 	mutex.Unlock()
 }
 
+func TestWorkerPool_RunUntilStopped(t *testing.T) {
+	var mutex sync.Mutex
+	executed := make(map[int]bool)
+	wp := New(3)
+	wg := sync.WaitGroup{}
+	done := make(chan struct{})
+	go func() {
+		wp.RunUntilStopped(func(_ int, jobData interface{}) {
+			mutex.Lock()
+			executed[jobData.(int)] = true
+			mutex.Unlock()
+			wg.Done()
+		})
+		close(done)
+	}()
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		wp.PublishJobData(i)
+	}
+	wg.Wait()
+	wp.Stop()
+	wp.Stop() // no effect
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("exptected RunUntilStopped() execution to be completed after Stop()")
+	}
+	if len(executed) != 10 {
+		t.Errorf("expected 10 executed jobs, actual %d", len(executed))
+	}
+}
+
 type ExecutedJob struct {
 	workerID  int
 	jobData   string